@@ -0,0 +1,164 @@
+// Package adrref checks references to decision records, such as ADR-0042, in
+// Go comments against the catalog exported by "adr export -format catalog"
+package adrref
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// CatalogFile is looked up from the directory of each checked file upwards
+// when no -catalog is given
+const CatalogFile = "adr-catalog.json"
+
+// Record is an entry of the catalog export
+type Record struct {
+	Label  string `json:"label"`
+	Index  int    `json:"index"`
+	Title  string `json:"title"`
+	Status string `json:"status"`
+	// Lifecycle is pending, active or terminal, catalogs of older releases
+	// leave it out
+	Lifecycle string `json:"lifecycle,omitempty"`
+	Path      string `json:"path"`
+	// URL is the published page when the catalog was exported with a siteURL
+	URL string `json:"url,omitempty"`
+}
+
+var Analyzer = &analysis.Analyzer{
+	Name: "adrref",
+	Doc:  "check that decision record references in comments exist and are still in force",
+	Run:  run,
+}
+
+var catalogPath string
+
+func init() {
+	Analyzer.Flags.StringVar(&catalogPath, "catalog", "", "catalog exported with adr export -format catalog, defaults to the nearest "+CatalogFile)
+}
+
+// terminalStatuses no longer apply, they stand in for the lifecycle in catalogs
+// without one. Superseded covers "Superseded by ADR-12" as well
+var terminalStatuses = []string{"Rejected", "Deprecated", "Superseded"}
+
+// isTerminal tells whether a record is no longer in force
+func isTerminal(r Record) bool {
+	if r.Lifecycle != "" {
+		return r.Lifecycle == "terminal"
+	}
+	for _, s := range terminalStatuses {
+		if strings.HasPrefix(r.Status, s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+var refRegex = regexp.MustCompile(`\b([A-Za-z]+)-(\d+)\b`)
+
+type catalog struct {
+	records map[string]Record
+	labels  map[string]bool
+}
+
+var (
+	catalogsMu sync.Mutex
+	catalogs   = map[string]*catalog{}
+)
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	for _, f := range pass.Files {
+		name := pass.Fset.File(f.Pos()).Name()
+		c, err := catalogFor(filepath.Dir(name))
+		if err != nil {
+			return nil, err
+		}
+		if c == nil {
+			continue
+		}
+
+		for _, group := range f.Comments {
+			for _, comment := range group.List {
+				for _, m := range refRegex.FindAllStringSubmatchIndex(comment.Text, -1) {
+					label := comment.Text[m[2]:m[3]]
+					if !c.labels[strings.ToLower(label)] {
+						continue
+					}
+					index, _ := strconv.Atoi(comment.Text[m[4]:m[5]])
+					ref := comment.Text[m[0]:m[1]]
+					pos := comment.Pos() + token.Pos(m[0])
+
+					r, ok := c.records[key(label, index)]
+					switch {
+					case !ok:
+						pass.Reportf(pos, "%s does not exist in the decision catalog", ref)
+					case isTerminal(r):
+						pass.Reportf(pos, "%s %q is %s", ref, r.Title, r.Status)
+					}
+				}
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+func key(label string, index int) string {
+	return fmt.Sprintf("%s-%d", strings.ToLower(label), index)
+}
+
+// catalogFor loads the configured catalog or the nearest one above dir, it
+// returns nil when there is none so packages outside a catalog are skipped
+func catalogFor(dir string) (*catalog, error) {
+	path := catalogPath
+	if path == "" {
+		for d := dir; ; d = filepath.Dir(d) {
+			candidate := filepath.Join(d, CatalogFile)
+			if _, err := os.Stat(candidate); err == nil {
+				path = candidate
+				break
+			}
+			if filepath.Dir(d) == d {
+				return nil, nil
+			}
+		}
+	}
+
+	catalogsMu.Lock()
+	defer catalogsMu.Unlock()
+
+	if c, ok := catalogs[path]; ok {
+		return c, nil
+	}
+
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	records := []Record{}
+	err = json.Unmarshal(body, &records)
+	if err != nil {
+		return nil, fmt.Errorf("invalid decision catalog %s: %s", path, err)
+	}
+
+	c := &catalog{records: map[string]Record{}, labels: map[string]bool{}}
+	for _, r := range records {
+		c.records[key(r.Label, r.Index)] = r
+		c.labels[strings.ToLower(r.Label)] = true
+	}
+	catalogs[path] = c
+
+	return c, nil
+}
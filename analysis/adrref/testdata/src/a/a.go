@@ -0,0 +1,27 @@
+package a
+
+// Store follows ADR-1 and ADR-0001, a zero padded reference
+type Store struct{}
+
+// Documents were kept in MongoDB, see ADR-2 // want `ADR.2 "Use MongoDB" is Superseded by ADR.4`
+func Documents() {}
+
+// Query runs the queries
+func Query() {
+	/* implements ADR-3 */ // want `ADR.3 "Use GraphQL" is Rejected`
+}
+
+// Legacy speaks SOAP per ADR-5 // want `ADR.5 "Use SOAP" is Deprecated`
+func Legacy() {}
+
+// Publish follows ADR-6 // want `ADR.6 "Use Kafka" is Retired`
+func Publish() {}
+
+// Migrate is pending on ADR-4 and implements RFC-1
+func Migrate() {}
+
+// Cache follows ADR-42 // want `ADR.42 does not exist in the decision catalog`
+func Cache() {}
+
+// Retry is unrelated to labels outside the catalog, e.g. JIRA-7 or UTF-8
+func Retry() {}
@@ -0,0 +1,14 @@
+// Package legacy is checked against a catalog exported without lifecycles
+package legacy
+
+// Store follows ADR-1
+type Store struct{}
+
+// Documents were kept in MongoDB, see ADR-2 // want `ADR.2 "Use MongoDB" is Superseded by ADR.4`
+func Documents() {}
+
+// Query implements ADR-3 // want `ADR.3 "Use GraphQL" is Rejected`
+func Query() {}
+
+// Legacy speaks SOAP per ADR-5 // want `ADR.5 "Use SOAP" is Deprecated`
+func Legacy() {}
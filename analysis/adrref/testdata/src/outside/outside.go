@@ -0,0 +1,5 @@
+// Package outside has no catalog above it, its references are not checked
+package outside
+
+// Store follows ADR-2 and ADR-42
+type Store struct{}
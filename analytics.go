@@ -0,0 +1,304 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// AnalyticsConfig configures what serve records about its readers, views are
+// counted per record and day only, nothing identifies a reader
+type AnalyticsConfig struct {
+	// Views is the file serve keeps the daily view counts of the records in,
+	// e.g. .adr-views.json, no views are counted when empty
+	Views string `yaml:"views"`
+	// RetainDays drops view counts older than this many days, 365 when zero
+	RetainDays int `yaml:"retainDays"`
+	// AccessLog is a file serve appends a line per request to, - for stderr,
+	// client addresses are shortened to their network unless KeepAddresses
+	AccessLog     string `yaml:"accessLog"`
+	KeepAddresses bool   `yaml:"keepAddresses"`
+}
+
+func (a AnalyticsConfig) retain() time.Duration {
+	days := a.RetainDays
+	if days <= 0 {
+		days = 365
+	}
+
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// viewDay is the layout of the days views are counted by
+const viewDay = "2006-01-02"
+
+// pageViews are the view counts by record label and day
+type pageViews struct {
+	mu    sync.Mutex
+	file  string
+	dirty bool
+	Views map[string]map[string]int `json:"views"`
+}
+
+func loadPageViews(file string) (*pageViews, error) {
+	v := &pageViews{file: file, Views: map[string]map[string]int{}}
+
+	body, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return v, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(body, v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid view counts %s: %s", file, err)
+	}
+	if v.Views == nil {
+		v.Views = map[string]map[string]int{}
+	}
+
+	return v, nil
+}
+
+func (v *pageViews) record(label string, at time.Time) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	days, ok := v.Views[label]
+	if !ok {
+		days = map[string]int{}
+		v.Views[label] = days
+	}
+	days[at.UTC().Format(viewDay)]++
+	v.dirty = true
+}
+
+// save writes the counts when they changed, days older than retain are
+// dropped first
+func (v *pageViews) save(now time.Time, retain time.Duration) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if !v.dirty {
+		return nil
+	}
+
+	oldest := now.UTC().Add(-retain).Format(viewDay)
+	for label, days := range v.Views {
+		for day := range days {
+			if day < oldest {
+				delete(days, day)
+			}
+		}
+		if len(days) == 0 {
+			delete(v.Views, label)
+		}
+	}
+
+	err := writeOutput(v.file, func(w io.Writer) error {
+		e := json.NewEncoder(w)
+		e.SetIndent("", "  ")
+		return e.Encode(v)
+	})
+	if err != nil {
+		return err
+	}
+	v.dirty = false
+
+	return nil
+}
+
+// since sums the views of every record from the day of from on
+func (v *pageViews) since(from time.Time) map[string]int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	first := from.UTC().Format(viewDay)
+	totals := map[string]int{}
+	for label, days := range v.Views {
+		for day, n := range days {
+			if day >= first {
+				totals[label] += n
+			}
+		}
+	}
+
+	return totals
+}
+
+// countsView tells whether a request is a view worth counting, readers asking
+// not to be tracked and crawlers are left out
+func countsView(r *http.Request) bool {
+	if r.Method != http.MethodGet || r.Header.Get("DNT") == "1" || r.Header.Get("Sec-GPC") == "1" {
+		return false
+	}
+	agent := strings.ToLower(r.UserAgent())
+	for _, bot := range []string{"bot", "crawl", "spider", "slurp", "curl", "wget"} {
+		if strings.Contains(agent, bot) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// anonymizeAddress shortens an IPv4 address to its /24 and an IPv6 address to
+// its /48 network
+func anonymizeAddress(remote string) string {
+	host, _, err := net.SplitHostPort(remote)
+	if err != nil {
+		host = remote
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return "-"
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String()
+	}
+
+	return ip.Mask(net.CIDRMask(48, 128)).String()
+}
+
+// loggedWriter remembers the status and size of a response for the access log
+type loggedWriter struct {
+	http.ResponseWriter
+	status int
+	size   int
+}
+
+func (w *loggedWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *loggedWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.size += n
+
+	return n, err
+}
+
+// logAccess writes a line per request to out, the time, client, method, path,
+// status, size and duration, query strings are left out
+func logAccess(next http.Handler, out io.Writer, keepAddresses bool) http.Handler {
+	var mu sync.Mutex
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		lw := &loggedWriter{ResponseWriter: w}
+		next.ServeHTTP(lw, r)
+
+		client := anonymizeAddress(r.RemoteAddr)
+		if keepAddresses {
+			client = r.RemoteAddr
+		}
+		if lw.status == 0 {
+			lw.status = http.StatusOK
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Fprintf(out, "%s %s %s %s %d %d %s\n", start.UTC().Format(time.RFC3339), client, r.Method, r.URL.Path, lw.status, lw.size, time.Since(start).Round(time.Millisecond))
+	})
+}
+
+// openAccessLog opens the configured access log for appending, nil when none
+// is configured
+func openAccessLog(file string) (io.Writer, error) {
+	switch file {
+	case "":
+		return nil, nil
+	case "-":
+		return os.Stderr, nil
+	}
+
+	return os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+}
+
+// recordViews is a record with its views in the reported period
+type recordViews struct {
+	Record string `json:"record"`
+	Title  string `json:"title"`
+	Status string `json:"status"`
+	Views  int    `json:"views"`
+	Path   string `json:"path"`
+}
+
+// mostRead ranks adrs by their views, the most read first and records nobody
+// read last
+func mostRead(adrs []*ADR, totals map[string]int) []recordViews {
+	ranked := []recordViews{}
+	for _, a := range adrs {
+		label := recordLabel(a)
+		ranked = append(ranked, recordViews{Record: label, Title: a.Heading, Status: a.Meta.Status, Views: totals[label], Path: a.Meta.Path})
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Views > ranked[j].Views })
+
+	return ranked
+}
+
+// runViews reports the most read decisions from the view counts of serve
+// and the records nobody opened in the period
+func runViews(args []string) error {
+	fs := flag.NewFlagSet("views", flag.ExitOnError)
+	dir := fs.String("dir", settings.Dir, "directory containing ADR files")
+	days := fs.Int("days", 30, "number of days to report, counting today")
+	limit := fs.Int("limit", 10, "number of records to list, 0 lists all")
+	fs.Parse(args)
+
+	if cfg.Analytics.Views == "" {
+		return fmt.Errorf("no view counts, set analytics.views for serve to count them in %s", configFile)
+	}
+	views, err := loadPageViews(cfg.Analytics.Views)
+	if err != nil {
+		return err
+	}
+	adrs, err := loadADRs(*dir)
+	if err != nil {
+		return err
+	}
+	adrs = searchADRs(settings.Filter.apply(adrs), "")
+
+	ranked := mostRead(adrs, views.since(time.Now().AddDate(0, 0, 1-*days)))
+	unread := []string{}
+	for _, r := range ranked {
+		if r.Views == 0 {
+			unread = append(unread, r.Record)
+		}
+	}
+	read := ranked[:len(ranked)-len(unread)]
+	if *limit > 0 && len(read) > *limit {
+		read = read[:*limit]
+	}
+	setResults(struct {
+		Days   int           `json:"days"`
+		Read   []recordViews `json:"read"`
+		Unread []string      `json:"unread"`
+	}{*days, read, unread})
+
+	fmt.Printf("Most read decisions of the last %d days\n", *days)
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	p := newPainter(os.Stdout)
+	for _, r := range read {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", r.Views, r.Record, p.status(r.Status), r.Title)
+	}
+	w.Flush()
+	if len(unread) > 0 {
+		fmt.Printf("%d records were not read: %s\n", len(unread), strings.Join(unread, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,440 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// metadataKeys are the metadata rows known to the parser, document attributes
+// with these names, or names required by a record type, are read as metadata
+var metadataKeys = []string{"Date", "Author", "Status", "Approvers", "Tags", "Impact", "Cost", "Outcome", "Reviewed", "Incidents", "Supersedes", "Superseded by", "Conflicts with", "Decides", "Scope", "Type"}
+
+var attributeRegex = regexp.MustCompile(`^:([A-Za-z0-9][\w-]*):\s*(.*)$`)
+
+var (
+	frontMatterRegex       = regexp.MustCompile(`^([A-Za-z0-9][\w -]*):\s*(.*)$`)
+	markdownMetadataRegex  = regexp.MustCompile(`^\|\s*Metadata\s*\|`)
+	markdownSeparatorRegex = regexp.MustCompile(`^\|[\s:|-]+$`)
+	blockAttributeRegex    = regexp.MustCompile(`^\[[^\]]*\]$`)
+)
+
+// attributeKey returns the metadata key of a document attribute name, status
+// becomes Status and last-reviewed becomes Last Reviewed, authors is accepted
+// for Author
+func attributeKey(name string) string {
+	words := strings.Split(strings.ToLower(name), "-")
+	key := strings.Join(words, " ")
+	if key == "authors" {
+		key = "author"
+	}
+
+	for _, k := range metadataKeys {
+		if strings.EqualFold(k, key) {
+			return k
+		}
+	}
+	for _, t := range cfg.types {
+		for _, k := range t.Required {
+			if strings.EqualFold(k, key) {
+				return k
+			}
+		}
+	}
+	for _, k := range cfg.Metadata.names() {
+		if strings.EqualFold(k, key) {
+			return k
+		}
+	}
+
+	return strings.Title(key)
+}
+
+// attributeName is the inverse of attributeKey
+func attributeName(key string) string {
+	return strings.ToLower(strings.Replace(strings.TrimSpace(key), " ", "-", -1))
+}
+
+// isMetadataAttribute tells metadata attributes from AsciiDoc ones such as toc
+// or icons which are left alone
+func isMetadataAttribute(name string) bool {
+	key := attributeKey(name)
+	for _, k := range metadataKeys {
+		if k == key {
+			return true
+		}
+	}
+	for _, t := range cfg.types {
+		if t.requires(key) {
+			return true
+		}
+	}
+
+	return cfg.Metadata.key(key) != nil
+}
+
+// metaRow is a metadata entry with its 0 based first and last line, a table
+// cell may continue on the following lines
+type metaRow struct {
+	Key   string
+	Value string
+	Line  int
+	End   int
+}
+
+// metadataBlock locates the metadata of a record, either the |Metadata table or
+// the document attributes below the title
+type metadataBlock struct {
+	// Attributes is set when the metadata is kept in document attributes
+	Attributes bool
+	// Markdown is set for a Markdown | Metadata | Value | table, it has no
+	// closing delimiter
+	Markdown bool
+	// FrontMatter is set for key: value lines fenced by --- at the top, they
+	// take precedence over a table
+	FrontMatter bool
+	// Start and End are the first and last line of the table including its
+	// delimiters, or of the metadata attributes
+	Start int
+	End   int
+	// Title is the line of the document title, -1 without one
+	Title int
+	Rows  []metaRow
+}
+
+// format renders a metadata entry the way the block holds them
+func (b metadataBlock) format(key string, value string) string {
+	if strings.Contains(value, "\n") {
+		value = strings.Join(splitValues(value), ", ")
+	}
+	switch {
+	case b.Attributes:
+		return ":" + attributeName(key) + ": " + value
+	case b.FrontMatter:
+		return frontMatterLine(key, value)
+	case b.Markdown:
+		return "| " + key + " | " + value + " |"
+	}
+
+	return "|" + key + " |" + value
+}
+
+// tableStart is Start including a block attribute line such as [cols="1,2"]
+// directly above the table, it styles the table and goes with it
+func (b metadataBlock) tableStart(lines []string) int {
+	if b.Start > 0 && !b.Attributes && !b.Markdown && !b.FrontMatter && blockAttributeRegex.MatchString(strings.TrimSpace(lines[b.Start-1])) {
+		return b.Start - 1
+	}
+
+	return b.Start
+}
+
+// findMetadata prefers the table and falls back to document attributes, Start
+// is -1 when the record has neither
+func findMetadata(lines []string) metadataBlock {
+	b := metadataBlock{Start: -1, End: -1, Title: -1}
+
+	front := frontMatter(lines)
+	start := 0
+	if front > 0 {
+		start = front + 1
+	}
+	for i := start; i < len(lines); i++ {
+		line := lines[i]
+		if isTitleLine(line) {
+			b.Title = i
+			break
+		}
+		if strings.TrimSpace(line) != "" && !strings.HasPrefix(line, "//") && !strings.HasPrefix(line, ":") {
+			break
+		}
+	}
+	if front > 0 {
+		b.FrontMatter = true
+		b.Start, b.End = 0, front
+		b.Rows = frontMatterRows(lines, front)
+		return b
+	}
+
+	inTable := false
+	pending := -1
+	for i, line := range lines {
+		switch {
+		case markdownMetadataRegex.MatchString(line) && strings.HasSuffix(strings.TrimSpace(line), "|") && !inTable:
+			inTable = true
+			b.Markdown = true
+			b.Start = i
+		case b.Markdown && markdownSeparatorRegex.MatchString(line):
+		case b.Markdown && !strings.HasPrefix(strings.TrimSpace(line), "|"):
+			b.End = i - 1
+			return b
+		case strings.HasPrefix(line, "|Metadata"):
+			inTable = true
+			b.Start = i
+			if i > 0 && strings.HasPrefix(lines[i-1], "|===") {
+				b.Start = i - 1
+			}
+		case inTable && strings.HasPrefix(line, "|==="):
+			b.End = i
+			return b
+		case inTable && strings.HasPrefix(strings.TrimSpace(line), "|"):
+			parts := strings.Split(strings.TrimSpace(line), "|")
+			switch {
+			case len(parts) > 2:
+				b.Rows = append(b.Rows, metaRow{Key: strings.TrimSpace(parts[1]), Value: strings.TrimSpace(parts[2]), Line: i, End: i})
+				pending = -1
+			case pending >= 0:
+				// the value cell of a key given on the line before
+				b.Rows = append(b.Rows, metaRow{Key: strings.TrimSpace(strings.TrimPrefix(lines[pending], "|")), Value: strings.TrimSpace(parts[1]), Line: pending, End: i})
+				pending = -1
+			default:
+				pending = i
+			}
+		case inTable && strings.TrimSpace(line) != "" && len(b.Rows) > 0:
+			// the cell continues on the next line, e.g. one list item per line
+			last := &b.Rows[len(b.Rows)-1]
+			last.Value = strings.TrimSpace(last.Value + "\n" + strings.TrimSpace(line))
+			last.End = i
+		}
+	}
+	if inTable {
+		b.End = len(lines) - 1
+		return b
+	}
+
+	b.Rows = nil
+	if b.Title < 0 {
+		return b
+	}
+	b.Attributes = true
+	for i := b.Title + 1; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i++ {
+		m := attributeRegex.FindStringSubmatch(lines[i])
+		if m == nil || !isMetadataAttribute(m[1]) {
+			continue
+		}
+		if b.Start < 0 {
+			b.Start = i
+		}
+		b.End = i
+		b.Rows = append(b.Rows, metaRow{Key: attributeKey(m[1]), Value: strings.TrimSpace(m[2]), Line: i, End: i})
+	}
+
+	return b
+}
+
+// isTitleLine matches the document title of AsciiDoc and Markdown records
+func isTitleLine(line string) bool {
+	return strings.HasPrefix(line, "= ") || strings.HasPrefix(line, "# ")
+}
+
+// frontMatter returns the line closing a --- fenced block at the top, 0 when
+// there is none
+func frontMatter(lines []string) int {
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != "---" {
+		return 0
+	}
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "---" {
+			return i
+		}
+	}
+
+	return 0
+}
+
+// frontMatterRows reads the yaml between the fences, lists become one item per
+// line, falling back to plain key: value lines when it is not valid yaml
+func frontMatterRows(lines []string, front int) []metaRow {
+	rows := []metaRow{}
+
+	var doc yaml.Node
+	err := yaml.Unmarshal([]byte(strings.Join(lines[1:front], "\n")), &doc)
+	if err != nil || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		for i := 1; i < front; i++ {
+			if m := frontMatterRegex.FindStringSubmatch(lines[i]); m != nil {
+				rows = append(rows, metaRow{Key: attributeKey(m[1]), Value: strings.Trim(strings.TrimSpace(m[2]), "[]"), Line: i, End: i})
+			}
+		}
+		return rows
+	}
+
+	pairs := doc.Content[0].Content
+	for i := 0; i+1 < len(pairs); i += 2 {
+		key := attributeKey(pairs[i].Value)
+		value := pairs[i+1]
+
+		items := []string{}
+		switch value.Kind {
+		case yaml.SequenceNode:
+			for _, item := range value.Content {
+				v := item.Value
+				if strings.Contains(v, ",") {
+					v = `"` + v + `"`
+				}
+				items = append(items, v)
+			}
+		default:
+			items = append(items, value.Value)
+		}
+		v := strings.Join(items, "\n")
+		if key == "Date" || key == "Reviewed" {
+			if t, err := time.Parse("2006-01-02", v); err == nil {
+				v = t.Format(dateLayout)
+			}
+		}
+
+		// node lines count from the line after the opening fence
+		end := front - 1
+		if i+2 < len(pairs) {
+			end = pairs[i+2].Line - 1
+		}
+		rows = append(rows, metaRow{Key: key, Value: v, Line: pairs[i].Line, End: end})
+	}
+
+	return rows
+}
+
+// frontMatterLine renders a front matter entry, lists in flow style and dates
+// as YYYY-MM-DD
+func frontMatterLine(key string, value string) string {
+	if key == "Date" || key == "Reviewed" {
+		if t, err := time.Parse(dateLayout, value); err == nil {
+			value = t.Format("2006-01-02")
+		}
+	}
+
+	node := &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+	if isListKey(key) {
+		node = &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+		for _, item := range splitValues(value) {
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: strings.Trim(item, `"`)})
+		}
+	}
+	body, err := yaml.Marshal(&yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{{Kind: yaml.ScalarNode, Value: attributeName(key)}, node}})
+	if err != nil {
+		return attributeName(key) + ": " + value
+	}
+
+	return strings.TrimRight(string(body), "\n")
+}
+
+// headerEnd returns the line after the document header, the first blank line
+// after the title
+func headerEnd(lines []string, title int) int {
+	i := title + 1
+	for i < len(lines) && strings.TrimSpace(lines[i]) != "" {
+		i++
+	}
+
+	return i
+}
+
+// metadataToAttributes moves the metadata table into document attributes below
+// the title, it returns body unchanged when there is no table
+func metadataToAttributes(body string) string {
+	lines := strings.Split(body, "\n")
+	b := findMetadata(lines)
+	if b.Start < 0 || b.Attributes || b.Markdown || b.FrontMatter || b.Title < 0 {
+		return body
+	}
+
+	attrs := []string{}
+	for _, r := range b.Rows {
+		attrs = append(attrs, metadataBlock{Attributes: true}.format(r.Key, r.Value))
+	}
+
+	start, end := b.tableStart(lines), b.End+1
+	if end < len(lines) && strings.TrimSpace(lines[end]) == "" && start > 0 && strings.TrimSpace(lines[start-1]) == "" {
+		end++
+	}
+	rest := append(append([]string{}, lines[:start]...), lines[end:]...)
+
+	at := headerEnd(rest, b.Title)
+	out := append(append(append([]string{}, rest[:at]...), attrs...), rest[at:]...)
+
+	return strings.Join(out, "\n")
+}
+
+// metadataToFrontMatter moves the metadata table or attributes into yaml front
+// matter at the top of the record
+func metadataToFrontMatter(body string) string {
+	lines := strings.Split(body, "\n")
+	b := findMetadata(lines)
+	if b.Start < 0 || b.FrontMatter || b.Markdown {
+		return body
+	}
+
+	front := []string{"---"}
+	drop := map[int]bool{}
+	for _, r := range b.Rows {
+		front = append(front, frontMatterLine(r.Key, r.Value))
+	}
+	front = append(front, "---")
+
+	if b.Attributes {
+		for _, r := range b.Rows {
+			for l := r.Line; l <= r.End; l++ {
+				drop[l] = true
+			}
+		}
+	} else {
+		start := b.tableStart(lines)
+		for l := start; l <= b.End; l++ {
+			drop[l] = true
+		}
+		// the blank line the table was set off with
+		if b.End+1 < len(lines) && strings.TrimSpace(lines[b.End+1]) == "" && start > 0 && strings.TrimSpace(lines[start-1]) == "" {
+			drop[b.End+1] = true
+		}
+	}
+
+	out := front
+	for i, line := range lines {
+		if !drop[i] {
+			out = append(out, line)
+		}
+	}
+
+	return strings.Join(out, "\n")
+}
+
+// metadataToTable moves metadata attributes or front matter into a |Metadata
+// table after the document header
+func metadataToTable(body string) string {
+	lines := strings.Split(body, "\n")
+	b := findMetadata(lines)
+	if b.Start < 0 || !(b.Attributes || b.FrontMatter) {
+		return body
+	}
+
+	table := []string{"|===", "|Metadata |Value", ""}
+	drop := map[int]bool{}
+	for _, r := range b.Rows {
+		table = append(table, metadataBlock{}.format(r.Key, r.Value))
+		for l := r.Line; l <= r.End; l++ {
+			drop[l] = true
+		}
+	}
+	table = append(table, "|===")
+	if b.FrontMatter {
+		for l := b.Start; l <= b.End; l++ {
+			drop[l] = true
+		}
+		b.Title -= b.End + 1
+	}
+
+	rest := []string{}
+	for i, line := range lines {
+		if !drop[i] {
+			rest = append(rest, line)
+		}
+	}
+
+	at := headerEnd(rest, b.Title)
+	block := append([]string{""}, table...)
+	out := append(append(append([]string{}, rest[:at]...), block...), rest[at:]...)
+
+	return strings.Join(out, "\n")
+}
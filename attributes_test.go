@@ -0,0 +1,226 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFindMetadata(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want metadataBlock
+	}{
+		{
+			name: "table",
+			body: `= Use Kafka
+
+[cols="1,2"]
+|===
+|Metadata |Value
+|Date |01-02-2024
+|Status |Approved
+|Tags |messaging, infra
+|===
+
+== Context`,
+			want: metadataBlock{Start: 3, End: 8, Title: 0, Rows: []metaRow{
+				{Key: "Date", Value: "01-02-2024", Line: 5, End: 5},
+				{Key: "Status", Value: "Approved", Line: 6, End: 6},
+				{Key: "Tags", Value: "messaging, infra", Line: 7, End: 7},
+			}},
+		},
+		{
+			name: "table cells on their own lines",
+			body: `= Use Kafka
+
+|===
+|Metadata |Value
+|Status
+|Approved
+|===`,
+			want: metadataBlock{Start: 2, End: 6, Title: 0, Rows: []metaRow{
+				{Key: "Status", Value: "Approved", Line: 4, End: 5},
+			}},
+		},
+		{
+			name: "multi-line cell",
+			body: `= Use Kafka
+
+|===
+|Metadata |Value
+|Author |@alice
+@bob
+|Status |Approved
+|===`,
+			want: metadataBlock{Start: 2, End: 7, Title: 0, Rows: []metaRow{
+				{Key: "Author", Value: "@alice\n@bob", Line: 4, End: 5},
+				{Key: "Status", Value: "Approved", Line: 6, End: 6},
+			}},
+		},
+		{
+			name: "table without closing delimiter",
+			body: `= Use Kafka
+
+|===
+|Metadata |Value
+|Status |Approved`,
+			want: metadataBlock{Start: 2, End: 4, Title: 0, Rows: []metaRow{
+				{Key: "Status", Value: "Approved", Line: 4, End: 4},
+			}},
+		},
+		{
+			name: "attributes",
+			body: `= Use Kafka
+:toc:
+:status: Approved
+:authors: @alice, @bob
+:superseded-by: ADR-7
+
+== Context`,
+			want: metadataBlock{Attributes: true, Start: 2, End: 4, Title: 0, Rows: []metaRow{
+				{Key: "Status", Value: "Approved", Line: 2, End: 2},
+				{Key: "Author", Value: "@alice, @bob", Line: 3, End: 3},
+				{Key: "Superseded by", Value: "ADR-7", Line: 4, End: 4},
+			}},
+		},
+		{
+			name: "attributes end at the first blank line",
+			body: `= Use Kafka
+:status: Approved
+
+:tags: messaging`,
+			want: metadataBlock{Attributes: true, Start: 1, End: 1, Title: 0, Rows: []metaRow{
+				{Key: "Status", Value: "Approved", Line: 1, End: 1},
+			}},
+		},
+		{
+			name: "markdown",
+			body: `# Use Kafka
+
+| Metadata | Value |
+|----------|-------|
+| Status | Approved |
+| Tags | messaging |
+
+## Context`,
+			want: metadataBlock{Markdown: true, Start: 2, End: 5, Title: 0, Rows: []metaRow{
+				{Key: "Status", Value: "Approved", Line: 4, End: 4},
+				{Key: "Tags", Value: "messaging", Line: 5, End: 5},
+			}},
+		},
+		{
+			name: "front matter",
+			body: `---
+status: Approved
+tags: [messaging, "a,b"]
+date: 2024-02-01
+---
+# Use Kafka`,
+			want: metadataBlock{FrontMatter: true, Start: 0, End: 4, Title: 5, Rows: []metaRow{
+				{Key: "Status", Value: "Approved", Line: 1, End: 1},
+				{Key: "Tags", Value: "messaging\n\"a,b\"", Line: 2, End: 2},
+				{Key: "Date", Value: "01-02-2024", Line: 3, End: 3},
+			}},
+		},
+		{
+			name: "front matter list over several lines",
+			body: `---
+authors:
+  - "@alice"
+  - "@bob"
+status: Approved
+---`,
+			want: metadataBlock{FrontMatter: true, Start: 0, End: 5, Title: -1, Rows: []metaRow{
+				{Key: "Author", Value: "@alice\n@bob", Line: 1, End: 3},
+				{Key: "Status", Value: "Approved", Line: 4, End: 4},
+			}},
+		},
+		{
+			name: "front matter that is not yaml",
+			body: `---
+status: Approved
+tags: [messaging
+---`,
+			want: metadataBlock{FrontMatter: true, Start: 0, End: 3, Title: -1, Rows: []metaRow{
+				{Key: "Status", Value: "Approved", Line: 1, End: 1},
+				{Key: "Tags", Value: "messaging", Line: 2, End: 2},
+			}},
+		},
+		{
+			name: "front matter takes precedence over a table",
+			body: `---
+status: Approved
+---
+= Use Kafka
+
+|===
+|Metadata |Value
+|Status |Rejected
+|===`,
+			want: metadataBlock{FrontMatter: true, Start: 0, End: 2, Title: 3, Rows: []metaRow{
+				{Key: "Status", Value: "Approved", Line: 1, End: 1},
+			}},
+		},
+		{
+			name: "unclosed front matter",
+			body: `---
+status: Approved
+= Use Kafka`,
+			want: metadataBlock{Start: -1, End: -1, Title: -1},
+		},
+		{
+			name: "no metadata",
+			body: `= Use Kafka
+
+== Context`,
+			want: metadataBlock{Attributes: true, Start: -1, End: -1, Title: 0},
+		},
+		{
+			name: "no title",
+			body: `Some text`,
+			want: metadataBlock{Start: -1, End: -1, Title: -1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := findMetadata(strings.Split(test.body, "\n"))
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("findMetadata() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestMetadataConversions(t *testing.T) {
+	table := "= Use Kafka\n\n[cols=\"1,2\"]\n|===\n|Metadata |Value\n|Status |Approved\n|Tags |messaging, infra\n|===\n\n== Context\n\n[source,go]\n----\nx := 1\n----\n"
+	attributes := "= Use Kafka\n:status: Approved\n:tags: messaging, infra\n\n== Context\n\n[source,go]\n----\nx := 1\n----\n"
+	frontMatter := "---\nstatus: Approved\ntags: [messaging, infra]\n---\n= Use Kafka\n\n== Context\n\n[source,go]\n----\nx := 1\n----\n"
+	plainTable := "= Use Kafka\n\n|===\n|Metadata |Value\n\n|Status |Approved\n|Tags |messaging, infra\n|===\n\n== Context\n\n[source,go]\n----\nx := 1\n----\n"
+
+	tests := []struct {
+		name    string
+		convert func(string) string
+		body    string
+		want    string
+	}{
+		{"table to attributes", metadataToAttributes, table, attributes},
+		{"table to front matter", metadataToFrontMatter, table, frontMatter},
+		{"attributes to table", metadataToTable, attributes, plainTable},
+		{"front matter to table", metadataToTable, frontMatter, plainTable},
+		{"attributes to front matter", metadataToFrontMatter, attributes, frontMatter},
+		{"attributes stay", metadataToAttributes, attributes, attributes},
+		{"front matter stays", metadataToFrontMatter, frontMatter, frontMatter},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.convert(test.body)
+			if got != test.want {
+				t.Errorf("got\n%s\nwant\n%s", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+type blameLine struct {
+	Commit  string
+	Author  string
+	Time    time.Time
+	Summary string
+	Text    string
+}
+
+// blameRegion is a metadata row or a section of an ADR with the most recent
+// change to any of its lines
+type blameRegion struct {
+	Name  string
+	Start int
+	End   int
+	Last  blameLine
+}
+
+var blameHeadingRegex = regexp.MustCompile(`^={2,6}\s+(.*)$`)
+
+func runBlame(args []string) error {
+	fs := flag.NewFlagSet("blame", flag.ExitOnError)
+	dir := fs.String("dir", settings.Dir, "directory containing ADR files")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: blame [flags] <index>")
+	}
+
+	adr, err := resolveFromDir(*dir, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	lines, err := blameFile(adr.Meta.Path)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintf(w, "%s %s (%s)\n\n", recordLabel(adr), adr.Heading, adr.Meta.Path)
+	fmt.Fprintln(w, "Region\tCommit\tAuthor\tDate\tSummary")
+	for _, r := range blameRegions(lines) {
+		commit := r.Last.Commit
+		if len(commit) > 8 {
+			commit = commit[:8]
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.Name, commit, r.Last.Author, r.Last.Time.Format("2006-01-02"), r.Last.Summary)
+	}
+
+	return nil
+}
+
+// blameFile runs git blame in porcelain mode, it repeats the commit details on
+// every line so each line can be parsed on its own
+func blameFile(file string) ([]blameLine, error) {
+	out, err := git("blame", "--line-porcelain", "--", file)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := []blameLine{}
+	current := blameLine{}
+	for _, l := range strings.Split(out, "\n") {
+		switch {
+		case strings.HasPrefix(l, "\t"):
+			current.Text = l[1:]
+			lines = append(lines, current)
+			current = blameLine{}
+		case strings.HasPrefix(l, "author "):
+			current.Author = strings.TrimPrefix(l, "author ")
+		case strings.HasPrefix(l, "author-time "):
+			sec, _ := strconv.ParseInt(strings.TrimPrefix(l, "author-time "), 10, 64)
+			current.Time = time.Unix(sec, 0)
+		case strings.HasPrefix(l, "summary "):
+			current.Summary = strings.TrimPrefix(l, "summary ")
+		case current.Commit == "":
+			fields := strings.Fields(l)
+			if len(fields) > 0 {
+				current.Commit = fields[0]
+			}
+		}
+	}
+
+	return lines, nil
+}
+
+func blameRegions(lines []blameLine) []blameRegion {
+	regions := []blameRegion{}
+	section := -1
+
+	texts := []string{}
+	for _, l := range lines {
+		texts = append(texts, l.Text)
+	}
+	meta := findMetadata(texts)
+	// a row may continue on the following lines, e.g. |Status above |Approved
+	rows := map[int]metaRow{}
+	for _, r := range meta.Rows {
+		rows[r.Line] = r
+	}
+
+	rowEnd := -1
+	for i, l := range lines {
+		if r, ok := rows[i]; ok {
+			regions = append(regions, blameRegion{Name: r.Key, Start: i, End: maxInt(r.End, i)})
+			rowEnd = r.End
+			continue
+		}
+		if i <= rowEnd {
+			continue
+		}
+		if !meta.Attributes && i > meta.Start && i <= meta.End {
+			continue
+		}
+
+		if i == 0 && strings.HasPrefix(l.Text, "= ") {
+			regions = append(regions, blameRegion{Name: "Title", Start: i, End: i})
+			continue
+		}
+
+		if m := blameHeadingRegex.FindStringSubmatch(l.Text); m != nil {
+			regions = append(regions, blameRegion{Name: "§ " + strings.TrimSpace(m[1]), Start: i, End: i})
+			section = len(regions) - 1
+			continue
+		}
+
+		if section >= 0 {
+			regions[section].End = i
+		}
+	}
+
+	for i := range regions {
+		r := &regions[i]
+		for _, l := range lines[r.Start : r.End+1] {
+			if l.Time.After(r.Last.Time) {
+				r.Last = l
+			}
+		}
+	}
+
+	return regions
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const maxBotResults = 10
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type slackButton struct {
+	Type     string    `json:"type"`
+	Text     slackText `json:"text"`
+	ActionID string    `json:"action_id"`
+	Value    string    `json:"value"`
+}
+
+type slackBlock struct {
+	Type      string       `json:"type"`
+	Text      *slackText   `json:"text,omitempty"`
+	Fields    []slackText  `json:"fields,omitempty"`
+	Elements  []slackText  `json:"elements,omitempty"`
+	Accessory *slackButton `json:"accessory,omitempty"`
+}
+
+type slackMessage struct {
+	ResponseType    string       `json:"response_type,omitempty"`
+	ReplaceOriginal bool         `json:"replace_original"`
+	Text            string       `json:"text"`
+	Blocks          []slackBlock `json:"blocks"`
+}
+
+type slackInteraction struct {
+	Type        string `json:"type"`
+	ResponseURL string `json:"response_url"`
+	Actions     []struct {
+		ActionID string `json:"action_id"`
+		Value    string `json:"value"`
+	} `json:"actions"`
+}
+
+type bot struct {
+	dir     string
+	baseURL string
+	secret  string
+}
+
+func runBot(args []string) error {
+	fs := flag.NewFlagSet("bot", flag.ExitOnError)
+	listen := fs.String("listen", ":8080", "address to listen on")
+	dir := fs.String("dir", settings.Dir, "directory containing ADR files")
+	baseURL := fs.String("base-url", "", "URL prefix used to link ADR files, e.g. https://github.com/org/repo/blob/main/")
+	secret := fs.String("signing-secret", "", "Slack signing secret, defaults to the slack credential or $SLACK_SIGNING_SECRET")
+	fs.Parse(args)
+
+	if *secret == "" {
+		resolved, err := resolveCredential("slack", "SLACK_SIGNING_SECRET")
+		if err != nil {
+			return err
+		}
+		*secret = resolved
+	}
+
+	if *secret == "" {
+		return fmt.Errorf("a Slack signing secret is required, set SLACK_SIGNING_SECRET, a slack credential or -signing-secret")
+	}
+
+	b := &bot{dir: *dir, baseURL: *baseURL, secret: *secret}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/slack/command", b.handleCommand)
+	mux.HandleFunc("/slack/interactive", b.handleInteractive)
+
+	log.Printf("ADR bot listening on %s", *listen)
+	return http.ListenAndServe(*listen, mux)
+}
+
+// readVerified reads the request body and checks it against the Slack
+// request signature, see https://api.slack.com/authentication/verifying-requests-from-slack
+func (b *bot) readVerified(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	ts := r.Header.Get("X-Slack-Request-Timestamp")
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request timestamp %q", ts)
+	}
+	if age := time.Since(time.Unix(sec, 0)); age > 5*time.Minute || age < -5*time.Minute {
+		return nil, fmt.Errorf("stale request timestamp %q", ts)
+	}
+
+	mac := hmac.New(sha256.New, []byte(b.secret))
+	fmt.Fprintf(mac, "v0:%s:%s", ts, body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(r.Header.Get("X-Slack-Signature"))) {
+		return nil, fmt.Errorf("signature mismatch")
+	}
+
+	return body, nil
+}
+
+func (b *bot) handleCommand(w http.ResponseWriter, r *http.Request) {
+	body, err := b.readVerified(w, r)
+	if err != nil {
+		log.Printf("Rejected slash command: %s", err)
+		http.Error(w, "invalid request", http.StatusUnauthorized)
+		return
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, "invalid form body", http.StatusBadRequest)
+		return
+	}
+
+	writeSlackMessage(w, b.respond(form.Get("text")))
+}
+
+func (b *bot) handleInteractive(w http.ResponseWriter, r *http.Request) {
+	body, err := b.readVerified(w, r)
+	if err != nil {
+		log.Printf("Rejected interaction: %s", err)
+		http.Error(w, "invalid request", http.StatusUnauthorized)
+		return
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, "invalid form body", http.StatusBadRequest)
+		return
+	}
+
+	var payload slackInteraction
+	err = json.Unmarshal([]byte(form.Get("payload")), &payload)
+	if err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	// Slack ignores the response body for block actions, replies go to response_url
+	w.WriteHeader(http.StatusOK)
+
+	for _, action := range payload.Actions {
+		if action.ActionID != "show_adr" || payload.ResponseURL == "" {
+			continue
+		}
+
+		msg := b.respond("show " + action.Value)
+		go func(responseURL string) {
+			err := postSlackMessage(responseURL, msg)
+			if err != nil {
+				log.Printf("Could not deliver interactive response: %s", err)
+			}
+		}(payload.ResponseURL)
+	}
+}
+
+func (b *bot) respond(text string) slackMessage {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return b.usage()
+	}
+
+	// an invalid record is left out rather than taking the whole bot down
+	adrs, errs, err := scanADRs(b.dir)
+	if err != nil {
+		log.Printf("Could not load ADRs: %s", err)
+		return textMessage(fmt.Sprintf(":warning: The ADR catalog could not be loaded: %s", slackEscape(err.Error())))
+	}
+	for _, err := range errs {
+		log.Printf("Skipped invalid ADR: %s", err)
+	}
+
+	switch fields[0] {
+	case "search":
+		if len(fields) < 2 {
+			return textMessage("Usage: `/adr search <terms>`")
+		}
+		query := strings.Join(fields[1:], " ")
+		return b.listMessage(fmt.Sprintf("ADRs matching %q", query), searchADRs(adrs, query))
+
+	case "show":
+		if len(fields) != 2 {
+			return textMessage("Usage: `/adr show <index>`")
+		}
+		idx, err := strconv.Atoi(strings.TrimPrefix(strings.ToUpper(fields[1]), "ADR-"))
+		if err != nil {
+			return textMessage(fmt.Sprintf("%q is not an ADR index", slackEscape(fields[1])))
+		}
+		adr := findADR(adrs, idx)
+		if adr == nil {
+			return textMessage(fmt.Sprintf("ADR-%d does not exist", idx))
+		}
+		return b.showMessage(adr)
+
+	case "pending":
+		return b.listMessage("ADRs awaiting a decision", searchADRs(NewCatalog(adrs).ByLifecycle(lifecyclePending), ""))
+	}
+
+	return b.usage()
+}
+
+func (b *bot) usage() slackMessage {
+	return textMessage("Usage: `/adr search <terms>`, `/adr show <index>` or `/adr pending`")
+}
+
+func (b *bot) link(adr *ADR) string {
+	label := fmt.Sprintf("ADR-%d", adr.Meta.Index)
+	if b.baseURL == "" {
+		return label
+	}
+	return fmt.Sprintf("<%s%s|%s>", b.baseURL, adr.Meta.Path, label)
+}
+
+func (b *bot) listMessage(title string, adrs []*ADR) slackMessage {
+	if len(adrs) == 0 {
+		return textMessage(title + ": none found")
+	}
+
+	msg := slackMessage{
+		ResponseType: "ephemeral",
+		Text:         title,
+		Blocks: []slackBlock{
+			{Type: "header", Text: &slackText{Type: "plain_text", Text: title}},
+		},
+	}
+
+	for i, adr := range adrs {
+		if i == maxBotResults {
+			msg.Blocks = append(msg.Blocks, slackBlock{
+				Type:     "context",
+				Elements: []slackText{{Type: "mrkdwn", Text: fmt.Sprintf("and %d more, refine the search to see them", len(adrs)-maxBotResults)}},
+			})
+			break
+		}
+
+		msg.Blocks = append(msg.Blocks, slackBlock{
+			Type: "section",
+			Text: &slackText{Type: "mrkdwn", Text: fmt.Sprintf("*%s* %s\n%s · %s", b.link(adr), slackEscape(adr.Heading), slackEscape(adr.Meta.Status), slackEscape(strings.Join(adr.Meta.Tags, ", ")))},
+			Accessory: &slackButton{
+				Type:     "button",
+				Text:     slackText{Type: "plain_text", Text: "Show"},
+				ActionID: "show_adr",
+				Value:    strconv.Itoa(adr.Meta.Index),
+			},
+		})
+	}
+
+	return msg
+}
+
+func (b *bot) showMessage(adr *ADR) slackMessage {
+	return slackMessage{
+		ResponseType: "ephemeral",
+		Text:         fmt.Sprintf("ADR-%d %s", adr.Meta.Index, slackEscape(adr.Heading)),
+		Blocks: []slackBlock{
+			{Type: "header", Text: &slackText{Type: "plain_text", Text: fmt.Sprintf("ADR-%d %s", adr.Meta.Index, adr.Heading)}},
+			{Type: "section", Fields: []slackText{
+				{Type: "mrkdwn", Text: "*Status*\n" + slackEscape(adr.Meta.Status)},
+				{Type: "mrkdwn", Text: "*Date*\n" + adr.Meta.Date.Format("2006-01-02")},
+				{Type: "mrkdwn", Text: "*Authors*\n" + slackEscape(strings.Join(adr.Meta.Authors, ", "))},
+				{Type: "mrkdwn", Text: "*Tags*\n" + slackEscape(strings.Join(adr.Meta.Tags, ", "))},
+			}},
+			{Type: "context", Elements: []slackText{{Type: "mrkdwn", Text: b.link(adr) + " · " + slackEscape(adr.Meta.Path)}}},
+		},
+	}
+}
+
+// slackEscape escapes the control characters of Slack mrkdwn, record text
+// would otherwise be read as links and mentions
+func slackEscape(text string) string {
+	return slackEscaper.Replace(text)
+}
+
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func textMessage(text string) slackMessage {
+	return slackMessage{
+		ResponseType: "ephemeral",
+		Text:         text,
+		Blocks:       []slackBlock{{Type: "section", Text: &slackText{Type: "mrkdwn", Text: text}}},
+	}
+}
+
+func writeSlackMessage(w http.ResponseWriter, msg slackMessage) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(msg)
+	if err != nil {
+		log.Printf("Could not write response: %s", err)
+	}
+}
+
+func postSlackMessage(responseURL string, msg slackMessage) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	resp, err := newHTTPClient(10*time.Second).Post(responseURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack responded with %s", resp.Status)
+	}
+
+	return nil
+}
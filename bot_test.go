@@ -0,0 +1,28 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBotEscapesRecords(t *testing.T) {
+	adr := &ADR{Heading: "Use <!channel> & <https://evil.example|Kafka>", Meta: ADRMeta{Index: 1, Status: "Approved", Path: "adr/0001-use-kafka.adoc"}}
+	b := &bot{}
+
+	for _, msg := range []slackMessage{b.showMessage(adr), b.listMessage("ADRs", []*ADR{adr})} {
+		texts := []string{msg.Text}
+		for _, block := range msg.Blocks {
+			if block.Text != nil && block.Text.Type == "mrkdwn" {
+				texts = append(texts, block.Text.Text)
+			}
+			for _, f := range block.Fields {
+				texts = append(texts, f.Text)
+			}
+		}
+		for _, text := range texts {
+			if strings.Contains(text, "<!channel>") || strings.Contains(text, "<https://evil") {
+				t.Errorf("unescaped heading in %q", text)
+			}
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"io/ioutil"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Catalog is a set of decision records with the queries the index, exports and
+// reports are built from, methods keep the order of the records they are given.
+// It is not importable, programs that need the records read the catalog.json
+// of export -format catalog into adrref.Record values
+type Catalog struct {
+	ADRs []*ADR
+}
+
+// Edge is a reference from one record to another, Kind tells a Supersedes or
+// Conflicts with row from a mention in the content
+type Edge struct {
+	From *ADR
+	To   *ADR
+	Kind string
+}
+
+const (
+	edgeSupersedes = "supersedes"
+	edgeConflicts  = "conflicts-with"
+	edgeRelates    = "relates-to"
+)
+
+var recordRefRegex = regexp.MustCompile(`\b([A-Za-z]+-\d+)\b`)
+
+func NewCatalog(adrs []*ADR) *Catalog {
+	return &Catalog{ADRs: adrs}
+}
+
+// Filter returns the catalog of records matching predicate
+func (c *Catalog) Filter(predicate func(a *ADR) bool) *Catalog {
+	matched := []*ADR{}
+	for _, a := range c.ADRs {
+		if predicate(a) {
+			matched = append(matched, a)
+		}
+	}
+
+	return NewCatalog(matched)
+}
+
+func (c *Catalog) ByTag(tag string) []*ADR {
+	return c.Filter(func(a *ADR) bool {
+		for _, t := range a.Meta.Tags {
+			if t == tag {
+				return true
+			}
+		}
+		return false
+	}).ADRs
+}
+
+func (c *Catalog) ByStatus(status string) []*ADR {
+	return c.Filter(func(a *ADR) bool {
+		return a.Meta.Status == status
+	}).ADRs
+}
+
+// Between returns the records dated from, inclusive, up to to, exclusive, a zero
+// bound leaves that side open
+func (c *Catalog) Between(from time.Time, to time.Time) []*ADR {
+	return c.Filter(func(a *ADR) bool {
+		if !from.IsZero() && a.Meta.Date.Before(from) {
+			return false
+		}
+		return to.IsZero() || a.Meta.Date.Before(to)
+	}).ADRs
+}
+
+// Tags groups the records by tag, tags sorted by name and records by index
+func (c *Catalog) Tags() []TagADRs {
+	tags := map[string]bool{}
+	for _, a := range c.ADRs {
+		for _, t := range a.Meta.Tags {
+			tags[t] = true
+		}
+	}
+
+	tagsList := []string{}
+	for k := range tags {
+		tagsList = append(tagsList, k)
+	}
+	sort.Strings(tagsList)
+
+	grouped := []TagADRs{}
+	for _, tag := range tagsList {
+		matched := c.ByTag(tag)
+
+		sort.Slice(matched, func(i, j int) bool {
+			if matched[i].Meta.Index == matched[j].Meta.Index {
+				return matched[i].Meta.Component < matched[j].Meta.Component
+			}
+			return matched[i].Meta.Index < matched[j].Meta.Index
+		})
+
+		grouped = append(grouped, TagADRs{Tag: tag, Adrs: matched})
+	}
+
+	return grouped
+}
+
+// Graph returns the references between records, Supersedes and Conflicts with
+// rows give the supersedes and conflicts edges and a record relates to another
+// it names by its label such as ADR-12 or links to by its file, the pairs of a
+// supersession or conflict do not relate
+func (c *Catalog) Graph() []Edge {
+	edges := []Edge{}
+	superseded := map[[2]*ADR]bool{}
+	rows := func(a *ADR, refs []string, kind string) {
+		for _, ref := range refs {
+			if to, err := resolveRecord(c.ADRs, ref); err == nil && to != a && !superseded[[2]*ADR{a, to}] {
+				superseded[[2]*ADR{a, to}] = true
+				superseded[[2]*ADR{to, a}] = true
+				edges = append(edges, Edge{From: a, To: to, Kind: kind})
+			}
+		}
+	}
+	for _, a := range c.ADRs {
+		rows(a, a.Meta.Supersedes, edgeSupersedes)
+	}
+	for _, a := range c.ADRs {
+		rows(a, a.Meta.ConflictsWith, edgeConflicts)
+	}
+
+	for _, a := range c.ADRs {
+		body, err := ioutil.ReadFile(a.Meta.Path)
+		if err != nil {
+			continue
+		}
+
+		seen := map[*ADR]bool{a: true}
+		add := func(to *ADR) {
+			if to != nil && !seen[to] && !superseded[[2]*ADR{a, to}] {
+				seen[to] = true
+				edges = append(edges, Edge{From: a, To: to, Kind: edgeRelates})
+			}
+		}
+
+		for _, ref := range recordRefRegex.FindAllString(string(body), -1) {
+			to, err := resolveRecord(c.ADRs, ref)
+			if err == nil {
+				add(to)
+			}
+		}
+		for _, to := range c.ADRs {
+			if strings.Contains(string(body), path.Base(to.Meta.Path)) {
+				add(to)
+			}
+		}
+	}
+
+	return edges
+}
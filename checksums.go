@@ -0,0 +1,187 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const checksumManifest = "checksums.yaml"
+
+// checksumEntry pins the content of an accepted record, a change is only
+// legitimate together with a status change or a new Revision table row
+type checksumEntry struct {
+	File      string `yaml:"file"`
+	SHA256    string `yaml:"sha256"`
+	Status    string `yaml:"status"`
+	Revisions int    `yaml:"revisions"`
+}
+
+func runChecksums(args []string) error {
+	fs := flag.NewFlagSet("checksums", flag.ExitOnError)
+	dir := fs.String("dir", settings.Dir, "directory containing ADR files")
+	update := fs.Bool("update", false, "record the current content of accepted records in the manifest")
+	fs.Parse(args)
+
+	adrs, err := loadADRs(*dir)
+	if err != nil {
+		return err
+	}
+
+	current := map[string]checksumEntry{}
+	for _, a := range adrs {
+		// the content of decisions in force is pinned by the manifest
+		if lifecycleOf(a.Meta.Status) != lifecycleActive {
+			continue
+		}
+		e, err := checksumOf(a)
+		if err != nil {
+			return err
+		}
+		current[e.File] = e
+	}
+
+	manifest := path.Join(*dir, checksumManifest)
+	if *update {
+		return writeChecksums(manifest, current)
+	}
+
+	recorded, err := readChecksums(manifest)
+	if err != nil {
+		return err
+	}
+
+	problems := 0
+	results := []checksumResult{}
+	for _, file := range sortedChecksumFiles(current) {
+		now := current[file]
+		before, ok := recorded[file]
+		state := ""
+		switch {
+		case !ok:
+			state = "untracked"
+			fmt.Printf("UNTRACKED  %s is %s but not in %s\n", file, now.Status, manifest)
+			problems++
+		case before.SHA256 == now.SHA256:
+			state = "unchanged"
+		case before.Status != now.Status || now.Revisions > before.Revisions:
+			state = "amended"
+			fmt.Printf("AMENDED    %s changed with a status change or new revision, run checksums -update\n", file)
+		default:
+			state = "modified"
+			fmt.Printf("MODIFIED   %s changed while %s without a status change or new Revision row\n", file, now.Status)
+			problems++
+		}
+		results = append(results, checksumResult{File: file, Status: now.Status, State: state})
+	}
+	setResults(results)
+
+	if problems > 0 {
+		return fmt.Errorf("%d accepted records do not match %s", problems, manifest)
+	}
+
+	return nil
+}
+
+// checksumResult is the structured outcome of checking one record
+type checksumResult struct {
+	File   string `json:"file"`
+	Status string `json:"status"`
+	State  string `json:"state"`
+}
+
+func checksumOf(a *ADR) (checksumEntry, error) {
+	body, err := ioutil.ReadFile(a.Meta.Path)
+	if err != nil {
+		return checksumEntry{}, err
+	}
+
+	content := strings.Replace(string(body), "\r\n", "\n", -1)
+	sum := sha256.Sum256([]byte(content))
+
+	return checksumEntry{
+		File:      path.Base(a.Meta.Path),
+		SHA256:    hex.EncodeToString(sum[:]),
+		Status:    a.Meta.Status,
+		Revisions: countRevisions(content),
+	}, nil
+}
+
+// countRevisions counts the rows of the Revision table, rows start with a cell
+// holding the revision number
+func countRevisions(content string) int {
+	rows := 0
+	inTable := false
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "|Revision"):
+			inTable = true
+		case inTable && strings.HasPrefix(line, "|==="):
+			return rows
+		case inTable && strings.HasPrefix(line, "|"):
+			rows++
+		}
+	}
+
+	return rows
+}
+
+func readChecksums(manifest string) (map[string]checksumEntry, error) {
+	recorded := map[string]checksumEntry{}
+
+	body, err := ioutil.ReadFile(manifest)
+	if os.IsNotExist(err) {
+		return recorded, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []checksumEntry{}
+	err = yaml.Unmarshal(body, &entries)
+	if err != nil {
+		return nil, fmt.Errorf("invalid checksum manifest %s: %s", manifest, err)
+	}
+	for _, e := range entries {
+		recorded[e.File] = e
+	}
+
+	return recorded, nil
+}
+
+func writeChecksums(manifest string, current map[string]checksumEntry) error {
+	entries := []checksumEntry{}
+	for _, file := range sortedChecksumFiles(current) {
+		entries = append(entries, current[file])
+	}
+
+	body, err := yaml.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(manifest, func(w io.Writer) error {
+		_, err := w.Write(body)
+		return err
+	})
+}
+
+func sortedChecksumFiles(m map[string]checksumEntry) []string {
+	files := []string{}
+	for f := range m {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+
+	return files
+}
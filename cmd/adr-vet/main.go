@@ -0,0 +1,15 @@
+// Command adr-vet runs the adrref analyzer with go vet:
+//
+//	adr export -format catalog -output adr-catalog.json
+//	go vet -vettool=$(which adr-vet) ./...
+package main
+
+import (
+	"adr-index/analysis/adrref"
+
+	"golang.org/x/tools/go/analysis/unitchecker"
+)
+
+func main() {
+	unitchecker.Main(adrref.Analyzer)
+}
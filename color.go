@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// colorMode is set with --color, auto colors terminals unless NO_COLOR is set
+var colorMode = "auto"
+
+const (
+	colorReset   = "\x1b[0m"
+	colorDefault = "\x1b[39m"
+	colorRed     = "\x1b[31m"
+	colorGreen   = "\x1b[32m"
+	colorYellow  = "\x1b[33m"
+	colorBlue    = "\x1b[34m"
+	colorGray    = "\x1b[90m"
+)
+
+// statusColors badge the status of a record, other statuses take the color of
+// their lifecycle
+var statusColors = map[string]string{
+	"Proposed":              colorYellow,
+	"Approved":              colorBlue,
+	"Partially Implemented": colorGreen,
+	"Implemented":           colorGreen,
+	"Superseded":            colorGray,
+	"Deprecated":            colorGray,
+	"Rejected":              colorRed,
+}
+
+var lifecycleColors = map[string]string{
+	lifecyclePending:  colorYellow,
+	lifecycleTerminal: colorGray,
+}
+
+func setColorMode(mode string) error {
+	switch mode {
+	case "auto", "always", "never":
+		colorMode = mode
+		return nil
+	}
+
+	return fmt.Errorf("invalid --color %q, expected auto, always or never", mode)
+}
+
+// colorEnabled reports whether w gets escape codes, only terminals do in auto
+// mode and NO_COLOR or TERM=dumb turn them off
+func colorEnabled(w io.Writer) bool {
+	switch colorMode {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+
+	if _, ok := os.LookupEnv("NO_COLOR"); ok || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// painter colors text for one writer, every painted text grows by the same
+// number of bytes so tabwriter columns stay aligned as long as all cells of a
+// column are painted
+type painter struct {
+	enabled bool
+}
+
+func newPainter(w io.Writer) painter {
+	return painter{enabled: colorEnabled(w)}
+}
+
+func (p painter) paint(color string, s string) string {
+	if !p.enabled {
+		return s
+	}
+
+	return color + s + colorReset
+}
+
+// status paints a status badge, statuses such as "Superseded by ADR-12" take
+// the color of their lifecycle
+func (p painter) status(s string) string {
+	color, ok := statusColors[s]
+	if !ok {
+		color = lifecycleColors[lifecycleOf(s)]
+	}
+	if color == "" {
+		color = colorDefault
+	}
+
+	return p.paint(color, s)
+}
+
+func (p painter) error(s string) string {
+	return p.paint(colorRed, s)
+}
+
+func (p painter) ok(s string) string {
+	return p.paint(colorGreen, s)
+}
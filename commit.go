@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+type commitEntry struct {
+	Verb   string
+	Index  int
+	Slug   string
+	Title  string
+	Status string
+}
+
+var statusVerbs = map[string]string{
+	"Proposed":              "propose",
+	"Approved":              "approve",
+	"Partially Implemented": "partially implement",
+	"Implemented":           "implement",
+	"Rejected":              "reject",
+	"Deprecated":            "deprecate",
+	"Superseded":            "supersede",
+}
+
+func runCommit(args []string) error {
+	fs := flag.NewFlagSet("commit", flag.ExitOnError)
+	dir := fs.String("dir", settings.Dir, "directory containing ADR files")
+	dryRun := fs.Bool("dry-run", false, "print the commit message without staging or committing")
+	fs.Parse(args)
+
+	// -z keeps paths unquoted and gives renames as the new path followed by
+	// the old one, e.g. after renumber -fix
+	out, err := git("status", "--porcelain", "-z", "--untracked-files=all", "--", *dir)
+	if err != nil {
+		return err
+	}
+
+	// status prints paths from the top of the work tree, the records are read
+	// and staged from the current directory
+	prefix, err := git("rev-parse", "--show-prefix")
+	if err != nil {
+		return err
+	}
+	relative := func(file string) string {
+		if rel, err := filepath.Rel(filepath.FromSlash(prefix), filepath.FromSlash(file)); err == nil {
+			return filepath.ToSlash(rel)
+		}
+		return file
+	}
+
+	files := []string{}
+	renamed := []string{}
+	entries := []commitEntry{}
+	fields := strings.Split(out, "\x00")
+	for i := 0; i < len(fields); i++ {
+		line := fields[i]
+		if len(line) < 4 {
+			continue
+		}
+		file := relative(line[3:])
+		previous := file
+		if strings.ContainsAny(line[:2], "RC") && i+1 < len(fields) {
+			i++
+			previous = relative(fields[i])
+		}
+		if !isRecordFile(file) {
+			continue
+		}
+
+		files = append(files, file)
+		// the removal of the old path is staged already and committed with it
+		if previous != file && strings.Contains(line[:2], "R") {
+			renamed = append(renamed, previous)
+		}
+
+		// deletions are staged but have nothing to parse
+		if strings.Contains(line[:2], "D") {
+			entries = append(entries, commitEntry{Verb: "remove", Slug: fileSlug(file), Index: fileIndex(file)})
+			continue
+		}
+
+		adr, err := parseADR(file)
+		if err != nil {
+			return err
+		}
+
+		entry := commitEntry{Verb: "update", Index: adr.Meta.Index, Slug: fileSlug(file), Title: adr.Heading, Status: adr.Meta.Status}
+		old, err := parseADRAt("HEAD", previous)
+		switch {
+		case err != nil:
+			entry.Verb = "add"
+			if adr.Meta.Status == "Proposed" {
+				entry.Verb = statusVerbs["Proposed"]
+			}
+		case old.Meta.Status != adr.Meta.Status:
+			if verb, ok := statusVerbs[adr.Meta.Status]; ok {
+				entry.Verb = verb
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if len(entries) == 0 {
+		return fmt.Errorf("no modified ADRs in %s", *dir)
+	}
+
+	lines := []string{}
+	for _, e := range entries {
+		line, err := formatCommit(e)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, line)
+	}
+
+	msg := lines[0]
+	if len(lines) > 1 {
+		msg = fmt.Sprintf("adr: update %d records\n\n* %s", len(lines), strings.Join(lines, "\n* "))
+	}
+
+	if *dryRun {
+		fmt.Println(msg)
+		return nil
+	}
+
+	_, err = git(append([]string{"add", "--all", "--"}, files...)...)
+	if err != nil {
+		return err
+	}
+
+	_, err = git(append(append([]string{"commit", "-m", msg, "--"}, files...), renamed...)...)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(msg)
+
+	return nil
+}
+
+// formatCommit renders the commit template of the config for one record
+func formatCommit(e commitEntry) (string, error) {
+	tmpl, err := template.New("commit").Parse(cfg.Commit.Template)
+	if err != nil {
+		return "", fmt.Errorf("invalid commit template: %s", err)
+	}
+
+	var b bytes.Buffer
+	err = tmpl.Execute(&b, e)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(b.String()), nil
+}
+
+// fileSlug returns the file name without index and extension, 0042-use-kafka.adoc becomes use-kafka
+func fileSlug(file string) string {
+	base := strings.TrimSuffix(path.Base(file), path.Ext(file))
+	parts := strings.SplitN(base, "-", 2)
+	if len(parts) < 2 {
+		return base
+	}
+
+	return parts[1]
+}
+
+func fileIndex(file string) int {
+	idx, _ := strconv.Atoi(strings.SplitN(path.Base(file), "-", 2)[0])
+	return idx
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// gitRepo creates a repository with an identity for commits and changes into
+// dir inside it, the returned func changes back
+func gitRepo(t *testing.T, dir string) (string, func()) {
+	root, err := ioutil.TempDir("", "adr-index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{{"init", "-q"}, {"config", "user.name", "Alice"}, {"config", "user.email", "alice@example.com"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %s: %s", args, err, out)
+		}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.MkdirAll(filepath.Join(root, dir), 0755)
+	if err == nil {
+		err = os.Chdir(filepath.Join(root, dir))
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return root, func() {
+		os.Chdir(wd)
+		os.RemoveAll(root)
+	}
+}
+
+func TestCommitFromSubdirectory(t *testing.T) {
+	_, cleanup := gitRepo(t, "sub")
+	defer cleanup()
+
+	err := os.MkdirAll("adr", 0755)
+	if err == nil {
+		err = ioutil.WriteFile("adr/0001-use-kafka.adoc", []byte(recordBody("|Date |01-02-2024", "|Author |@alice", "|Status |Proposed", "|Tags |messaging")), 0644)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = runCommit([]string{"-dir", "adr"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := git("log", "-1", "--format=%s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "adr: propose 0001 use-kafka"; msg != want {
+		t.Errorf("commit message %q, want %q", msg, want)
+	}
+	files, err := git("show", "--name-only", "--format=", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files != "sub/adr/0001-use-kafka.adoc" {
+		t.Errorf("committed %q, want sub/adr/0001-use-kafka.adoc", files)
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+func runCommitMsg(args []string) error {
+	fs := flag.NewFlagSet("commit-msg", flag.ExitOnError)
+	dir := fs.String("dir", settings.Dir, "directory containing ADR files")
+	install := fs.Bool("install", false, "install the commit-msg git hook running this check")
+	fs.Parse(args)
+
+	if *install {
+		return installCommitMsgHook()
+	}
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: commit-msg [flags] <message file>")
+	}
+
+	if len(cfg.Commit.RequireRef) == 0 {
+		return nil
+	}
+
+	body, err := ioutil.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	msg := commitMessage(string(body))
+	if strings.HasPrefix(msg, "Merge ") {
+		return nil
+	}
+
+	out, err := git("diff", "--cached", "--name-only")
+	if err != nil {
+		return err
+	}
+	guarded := []string{}
+	for _, file := range strings.Split(out, "\n") {
+		if file != "" && requiresRef(file, cfg.Commit.RequireRef) {
+			guarded = append(guarded, file)
+		}
+	}
+	if len(guarded) == 0 {
+		return nil
+	}
+
+	adrs, err := loadADRs(*dir)
+	if err != nil {
+		return err
+	}
+
+	refs := recordRefPattern().FindAllString(msg, -1)
+	if len(refs) == 0 {
+		return fmt.Errorf("commit changes %s and must reference an accepted decision, e.g. ADR-12", strings.Join(guarded, ", "))
+	}
+
+	for _, ref := range refs {
+		a, err := resolveRecord(adrs, ref)
+		if err != nil {
+			return fmt.Errorf("invalid decision reference in commit message: %s", err)
+		}
+		if lifecycleOf(a.Meta.Status) != lifecycleActive {
+			return fmt.Errorf("commit message references %s which is %s, only %s decisions can be implemented", ref, a.Meta.Status, strings.Join(statusesIn(lifecycleActive), ", "))
+		}
+	}
+
+	return nil
+}
+
+// commitMessage drops the comment lines git adds to the message template
+func commitMessage(body string) string {
+	lines := []string{}
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(line, "# ------------------------ >8 ------------------------") {
+			break
+		}
+		if !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// requiresRef matches file against directory prefixes ending in a slash and
+// path globs
+func requiresRef(file string, patterns []string) bool {
+	for _, p := range patterns {
+		if strings.HasSuffix(p, "/") && strings.HasPrefix(file, p) {
+			return true
+		}
+		if ok, _ := path.Match(p, file); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func installCommitMsgHook() error {
+	hooks, err := git("rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return err
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		exe = "adr-index"
+	}
+
+	hook := filepath.Join(hooks, "commit-msg")
+	if _, err := os.Stat(hook); err == nil {
+		return fmt.Errorf("%s already exists, add %q to it instead", hook, exe+" commit-msg \"$1\"")
+	}
+
+	err = os.MkdirAll(hooks, 0755)
+	if err != nil {
+		return err
+	}
+
+	script := fmt.Sprintf("#!/bin/sh\nexec %q commit-msg \"$1\"\n", exe)
+	err = ioutil.WriteFile(hook, []byte(script), 0755)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Installed %s\n", hook)
+
+	return nil
+}
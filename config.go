@@ -0,0 +1,416 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	configFile     = ".adr.yaml"
+	tomlConfigFile = ".adrconfig.toml"
+)
+
+type Config struct {
+	// Analytics configures the view counts and access log of serve
+	Analytics AnalyticsConfig `yaml:"analytics"`
+	Commit    CommitConfig    `yaml:"commit"`
+	// Components lists the monorepo component directories for rollup, when empty
+	// every directory holding an adr directory is a component
+	Components []string `yaml:"components"`
+	// Credentials reference the secrets of integrations by name, github, gitlab
+	// and slack, see resolveCredential
+	Credentials map[string]string `yaml:"credentials"`
+	// DateLayout is the format of Date and Reviewed values spelled with DD, MM
+	// and YYYY, DD-MM-YYYY when empty
+	DateLayout string `yaml:"dateLayout"`
+	// Dir is the directory containing ADR files, adr when empty
+	Dir string `yaml:"dir"`
+	// HTTP configures retries, backoff, proxying and TLS of outbound requests
+	HTTP HTTPConfig `yaml:"http"`
+	// Inherit lists upstream catalogs whose decisions apply to this repository
+	Inherit []InheritConfig `yaml:"inherit"`
+	// Lifecycle marks statuses pending, active or terminal, e.g. Retired:
+	// terminal, the default statuses have theirs already
+	Lifecycle map[string]string `yaml:"lifecycle"`
+	// Includes mirror records of other repositories into the index
+	Includes []Include `yaml:"includes"`
+	// Lint configures the sections lint requires in the body of ADRs
+	Lint LintConfig `yaml:"lint"`
+	// Metadata declares the additional metadata rows records may carry
+	Metadata MetadataConfig `yaml:"metadata"`
+	// MinVersion is the oldest adr-index release that understands the records
+	// and configuration, older binaries refuse to run
+	MinVersion string `yaml:"minVersion"`
+	// NotesDir is the directory of the meeting notes whose decision logs notes
+	// reads, notes when empty
+	NotesDir string `yaml:"notesDir"`
+	// Output is the file build writes the index to, profiles may override it,
+	// the index goes to stdout when neither sets one
+	Output string `yaml:"output"`
+	// Personal enables the page of what is relevant to the reader in serve
+	Personal PersonalConfig `yaml:"personal"`
+	// Profiles are named sets of settings selected with --profile
+	Profiles map[string]Profile `yaml:"profiles"`
+	// SiteURL is the root of the published site, ADR pages are expected at the
+	// ADR path with an .html extension below it
+	SiteURL string `yaml:"siteURL"`
+	// Sandbox limits the templates serve renders for the catalog and its
+	// profiles and those of build -sandbox
+	Sandbox SandboxConfig `yaml:"sandbox"`
+	// Scopes give scopes their parent, e.g. service:payments:
+	// department:finance, decisions of a parent apply to its children and
+	// every scope inherits from the org
+	Scopes map[string]string `yaml:"scopes"`
+	// SLOs are the decision latency objectives adr-index slo checks
+	SLOs []SLO `yaml:"slos"`
+	// Statuses replace the statuses allowed for ADRs and record types that do
+	// not declare their own
+	Statuses []string `yaml:"statuses"`
+	// TagIndex has build write an index per tag as well
+	TagIndex TagIndexConfig `yaml:"tagIndex"`
+	// Template is the index template, .readme.templ when empty
+	Template string `yaml:"template"`
+	// Types declares additional record types next to ADRs and design notes
+	Types map[string]RecordType `yaml:"types"`
+	// Update configures where self-update finds releases and how it verifies them
+	Update UpdateConfig `yaml:"update"`
+
+	types []*RecordType
+	// envCredentials are the credentials set with ADR_CREDENTIAL_<NAME>, the
+	// ones trusted to run a command
+	envCredentials map[string]bool
+}
+
+// Profile holds the settings that differ between invocation contexts, empty
+// values leave the defaults in place
+type Profile struct {
+	Dir      string `yaml:"dir"`
+	Template string `yaml:"template"`
+	Output   string `yaml:"output"`
+	Filter   Filter `yaml:"filter"`
+}
+
+// Filter restricts which ADRs are published, an ADR must carry one of Tags,
+// have one of Statuses and one of Authors, be dated from Since to Until when
+// those are set and must carry none of ExcludeTags
+type Filter struct {
+	Tags        []string  `yaml:"tags"`
+	Statuses    []string  `yaml:"statuses"`
+	Authors     []string  `yaml:"authors"`
+	Since       time.Time `yaml:"since"`
+	Until       time.Time `yaml:"until"`
+	ExcludeTags []string  `yaml:"excludeTags"`
+}
+
+type CommitConfig struct {
+	// Template is a text/template producing one line per changed ADR, it receives
+	// the Verb, Index, Slug, Title and Status of the change
+	Template string `yaml:"template"`
+	// RequireRef lists the paths, directory prefixes like infra/ or globs, whose
+	// changes must reference an accepted record in the commit message, enforced
+	// by the commit-msg hook
+	RequireRef []string `yaml:"requireRef"`
+}
+
+func defaultConfig() *Config {
+	types, _ := compileTypes(nil)
+
+	return &Config{
+		types: types,
+		HTTP:  defaultHTTPConfig(),
+		Commit: CommitConfig{
+			Template: `adr: {{.Verb}} {{printf "%04d" .Index}} {{.Slug}}`,
+		},
+	}
+}
+
+var (
+	cfg      = defaultConfig()
+	settings = Profile{Dir: "adr", Template: ".readme.templ"}
+)
+
+func (c *Config) notesDir() string {
+	if c.NotesDir == "" {
+		return "notes"
+	}
+
+	return c.NotesDir
+}
+
+// profile returns the default settings overlaid with the named profile
+func (c *Config) profile(name string) (Profile, error) {
+	p := Profile{Dir: "adr", Template: ".readme.templ", Output: c.Output}
+	if c.Dir != "" {
+		p.Dir = c.Dir
+	}
+	if c.Template != "" {
+		p.Template = c.Template
+	}
+	if name == "" {
+		return p, nil
+	}
+
+	named, ok := c.Profiles[name]
+	if !ok {
+		known := []string{}
+		for k := range c.Profiles {
+			known = append(known, k)
+		}
+		sort.Strings(known)
+		return p, fmt.Errorf("unknown profile %q, configured profiles: %s", name, strings.Join(known, ", "))
+	}
+
+	if named.Dir != "" {
+		p.Dir = named.Dir
+	}
+	if named.Template != "" {
+		p.Template = named.Template
+	}
+	if named.Output != "" {
+		p.Output = named.Output
+	}
+	p.Filter = named.Filter
+
+	return p, nil
+}
+
+func (f Filter) match(adr *ADR) bool {
+	if len(f.Statuses) > 0 && !containsFold(f.Statuses, adr.Meta.Status) {
+		return false
+	}
+
+	if len(f.Tags) > 0 {
+		found := false
+		for _, t := range adr.Meta.Tags {
+			if containsFold(f.Tags, t) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(f.Authors) > 0 {
+		found := false
+		for _, a := range adr.Meta.Authors {
+			for _, want := range f.Authors {
+				if strings.EqualFold(strings.TrimPrefix(a, "@"), strings.TrimPrefix(want, "@")) {
+					found = true
+				}
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if (!f.Since.IsZero() && adr.Meta.Date.Before(f.Since)) || (!f.Until.IsZero() && adr.Meta.Date.After(f.Until)) {
+		return false
+	}
+
+	for _, t := range adr.Meta.Tags {
+		if containsFold(f.ExcludeTags, t) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// filterFlags adds the -tag, -status, -author, -since and -until flags to fs,
+// the returned function reads them into a Filter after fs is parsed
+func filterFlags(fs *flag.FlagSet) func() (Filter, error) {
+	tags := fs.String("tag", "", "only records carrying one of these comma separated tags")
+	statuses := fs.String("status", "", "only records with one of these comma separated statuses")
+	authors := fs.String("author", "", "only records written by one of these comma separated authors")
+	since := fs.String("since", "", "only records dated from this YYYY, YYYY-MM or YYYY-MM-DD on")
+	until := fs.String("until", "", "only records dated up to the end of this YYYY, YYYY-MM or YYYY-MM-DD")
+
+	return func() (Filter, error) {
+		f := Filter{}
+		list := func(s string) []string {
+			if strings.TrimSpace(s) == "" {
+				return nil
+			}
+			return parseCommaList(s)
+		}
+		f.Tags, f.Statuses, f.Authors = list(*tags), list(*statuses), list(*authors)
+
+		var err error
+		if *since != "" {
+			f.Since, _, err = parsePeriod(*since)
+			if err != nil {
+				return f, fmt.Errorf("invalid -since: %s", err)
+			}
+		}
+		if *until != "" {
+			_, f.Until, err = parsePeriod(*until)
+			if err != nil {
+				return f, fmt.Errorf("invalid -until: %s", err)
+			}
+		}
+
+		return f, nil
+	}
+}
+
+// parsePeriod reads a year, a month or a day and returns its first and last day
+func parsePeriod(s string) (time.Time, time.Time, error) {
+	for _, p := range []struct {
+		layout string
+		years  int
+		months int
+		days   int
+	}{{"2006-01-02", 0, 0, 1}, {"2006-01", 0, 1, 0}, {"2006", 1, 0, 0}} {
+		if t, err := time.Parse(p.layout, s); err == nil {
+			return t, t.AddDate(p.years, p.months, p.days-1), nil
+		}
+	}
+
+	return time.Time{}, time.Time{}, fmt.Errorf("%q is not a YYYY, YYYY-MM or YYYY-MM-DD date", s)
+}
+
+func (f Filter) apply(adrs []*ADR) []*ADR {
+	return NewCatalog(adrs).Filter(f.match).ADRs
+}
+
+func containsFold(list []string, s string) bool {
+	for _, l := range list {
+		if strings.EqualFold(l, s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// loadConfig reads the yaml or, for a .toml path, the TOML configuration, the
+// default .adr.yaml falls back to .adrconfig.toml, values missing from the file
+// keep their defaults and a missing file is not an error
+func loadConfig(path string) (*Config, error) {
+	cfg := defaultConfig()
+
+	body, err := readConfigFile(path)
+	if err != nil || body == nil {
+		return cfg, err
+	}
+	err = yaml.Unmarshal(body, cfg)
+	if err != nil {
+		return nil, err
+	}
+	err = cfg.compile()
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// readConfigFile returns the configuration at path as yaml, nil when the file
+// does not exist
+func readConfigFile(path string) ([]byte, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) && path == configFile {
+		path = tomlConfigFile
+	}
+	body, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if filepath.Ext(path) == ".toml" {
+		body, err = tomlToYAML(body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TOML configuration %s: %s", path, err)
+		}
+	}
+
+	return body, nil
+}
+
+// checkConfigVersion checks the minVersion of the configuration at path before
+// anything else of it is read, a configuration written for a newer release may
+// not parse with this one. A file that is no yaml at all is left to loadConfig
+func checkConfigVersion(path string) error {
+	body, err := readConfigFile(path)
+	if err != nil || body == nil {
+		return nil
+	}
+	var c struct {
+		MinVersion string `yaml:"minVersion"`
+	}
+	if yaml.Unmarshal(body, &c) != nil {
+		return nil
+	}
+
+	return checkMinVersion(c.MinVersion)
+}
+
+// compile checks the record types, metadata keys and SLOs and prepares them
+// for parsing
+func (c *Config) compile() error {
+	var err error
+	c.types, err = compileTypes(c.Types)
+	if err != nil {
+		return err
+	}
+	err = c.Metadata.compile()
+	if err != nil {
+		return err
+	}
+
+	return checkSLOs(c.SLOs)
+}
+
+// applyRecordFormats makes the configured statuses, their lifecycles and the
+// date layout the ones records are parsed and written with
+func (c *Config) applyRecordFormats() error {
+	if len(c.Statuses) > 0 {
+		validStatus = c.Statuses
+	}
+	err := applyLifecycles(c.Lifecycle)
+	if err != nil {
+		return err
+	}
+	if c.DateLayout == "" {
+		return nil
+	}
+
+	layout, err := goDateLayout(c.DateLayout)
+	if err != nil {
+		return err
+	}
+	dateLayout, dateFormat = layout, c.DateLayout
+
+	return nil
+}
+
+// goDateLayout turns a DD-MM-YYYY style format into a time layout, the format
+// must hold the day, month and year exactly once
+func goDateLayout(format string) (string, error) {
+	layout := strings.NewReplacer("YYYY", "2006", "MM", "01", "DD", "02").Replace(format)
+	for _, part := range []string{"2006", "01", "02"} {
+		if strings.Count(layout, part) != 1 {
+			return "", fmt.Errorf("invalid dateLayout %q in %s, expected DD, MM and YYYY once each, e.g. DD-MM-YYYY", format, configFile)
+		}
+	}
+
+	sample := time.Date(2006, 1, 2, 0, 0, 0, 0, time.UTC)
+	if t, err := time.Parse(layout, sample.Format(layout)); err != nil || !t.Equal(sample) {
+		return "", fmt.Errorf("invalid dateLayout %q in %s, expected DD, MM and YYYY once each, e.g. DD-MM-YYYY", format, configFile)
+	}
+
+	return layout, nil
+}
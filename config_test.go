@@ -0,0 +1,42 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestCheckConfigVersion reads a configuration written for a newer release, it
+// must ask for the upgrade rather than fail on what this release does not know
+func TestCheckConfigVersion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "adr-index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, ".adr.yaml")
+	err = ioutil.WriteFile(file, []byte("minVersion: 2.0.0\nreviewBoard: architecture\nstatuses:\n  approved: [Approved]\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(v string) { version = v }(version)
+	version = "1.4.0"
+
+	_, err = loadConfig(file)
+	if err == nil {
+		t.Fatal("loadConfig read a configuration of a newer release")
+	}
+	err = checkConfigVersion(file)
+	if err == nil || !strings.Contains(err.Error(), "requires adr-index 2.0.0 or newer and this is 1.4.0") {
+		t.Errorf("checkConfigVersion() = %v, want the upgrade hint", err)
+	}
+
+	version = "2.1.0"
+	err = checkConfigVersion(file)
+	if err != nil {
+		t.Errorf("checkConfigVersion() = %v for a newer release", err)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+func runConfig(args []string) error {
+	if len(args) == 1 && args[0] == "env" {
+		return printEnv()
+	}
+	if len(args) == 0 || args[0] != "convert" {
+		return fmt.Errorf("usage: config convert [flags] | config env")
+	}
+
+	fs := flag.NewFlagSet("config convert", flag.ExitOnError)
+	from := fs.String("from", configFile, "configuration file to convert, yaml or TOML by extension")
+	output := fs.String("output", "", "file to write the converted configuration to, defaults to stdout")
+	fs.Parse(args[1:])
+
+	body, err := ioutil.ReadFile(*from)
+	if err != nil {
+		return err
+	}
+
+	convert, target := yamlToTOML, "TOML"
+	if filepath.Ext(*from) == ".toml" {
+		convert, target = tomlToYAML, "yaml"
+	}
+	converted, err := convert(body)
+	if err != nil {
+		return fmt.Errorf("cannot convert %s to %s: %s", *from, target, err)
+	}
+
+	return writeOutput(*output, func(w io.Writer) error {
+		_, err := w.Write(converted)
+		return err
+	})
+}
+
+// tomlToYAML re-encodes a TOML document as yaml so both formats decode into
+// the configuration with the same keys, comments are lost
+func tomlToYAML(body []byte) ([]byte, error) {
+	doc := map[string]interface{}{}
+	_, err := toml.Decode(string(body), &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	err = enc.Encode(doc)
+	if err != nil {
+		return nil, err
+	}
+	err = enc.Close()
+
+	return out.Bytes(), err
+}
+
+func yamlToTOML(body []byte) ([]byte, error) {
+	doc := map[string]interface{}{}
+	err := yaml.Unmarshal(body, &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	err = toml.NewEncoder(&out).Encode(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
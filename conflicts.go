@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// verifyConflicts checks that no two active records contradict each other, a
+// pair conflicts when one names the other in its Conflicts with row or both
+// decide the same question in their Decides row, the newer record is reported
+func verifyConflicts(adrs []*ADR) []error {
+	errs := []error{}
+	reported := map[[2]*ADR]bool{}
+	report := func(a *ADR, other *ADR, reason string) {
+		if reported[[2]*ADR{a, other}] || reported[[2]*ADR{other, a}] {
+			return
+		}
+		reported[[2]*ADR{a, other}] = true
+		errs = append(errs, &ErrConflictingDecision{Path: a.Meta.Path, Other: other.Meta.Path, Label: recordLabel(other), Reason: reason})
+	}
+	active := func(a *ADR) bool {
+		return lifecycleOf(a.Meta.Status) == lifecycleActive
+	}
+
+	for _, a := range adrs {
+		for _, ref := range a.Meta.ConflictsWith {
+			other, err := resolveRecord(adrs, ref)
+			if err != nil {
+				errs = append(errs, &ErrInvalidReference{Path: a.Meta.Path, Key: "Conflicts with", Ref: ref, Reason: fmt.Sprintf("conflicts with %s which does not exist", ref)})
+				continue
+			}
+			if other == a {
+				errs = append(errs, &ErrInvalidReference{Path: a.Meta.Path, Key: "Conflicts with", Ref: ref, Reason: "conflicts with itself"})
+				continue
+			}
+			if !active(a) || !active(other) {
+				continue
+			}
+			statuses := "both are " + a.Meta.Status
+			if a.Meta.Status != other.Meta.Status {
+				statuses = fmt.Sprintf("they are %s and %s", a.Meta.Status, other.Meta.Status)
+			}
+			report(a, other, fmt.Sprintf("conflicts with %s but %s", recordLabel(other), statuses))
+		}
+	}
+
+	for i, a := range adrs {
+		if a.Meta.Decides == "" || !active(a) {
+			continue
+		}
+		for _, other := range adrs[i+1:] {
+			if !active(other) || !strings.EqualFold(strings.TrimSpace(a.Meta.Decides), strings.TrimSpace(other.Meta.Decides)) {
+				continue
+			}
+			newer, older := other, a
+			if older.Meta.Date.After(newer.Meta.Date) {
+				newer, older = older, newer
+			}
+			report(newer, older, fmt.Sprintf("decides %s like %s and both are active", newer.Meta.Decides, recordLabel(older)))
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// CostItem is one estimate of a Cost metadata row such as "one-off 12000 EUR"
+// or "recurring 800 EUR/month"
+type CostItem struct {
+	Kind     string  `json:"kind"`
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+	Period   string  `json:"period,omitempty"`
+}
+
+const (
+	costOneOff    = "one-off"
+	costRecurring = "recurring"
+)
+
+var costItemRegex = regexp.MustCompile(`^(one-off|recurring)\s+([0-9][0-9_]*(?:\.[0-9]+)?)([kKmM]?)\s+([A-Z]{3})(?:\s*/\s*(month|year))?$`)
+
+// parseCost reads a comma separated list of estimates, recurring costs need a
+// /month or /year period
+func parseCost(value string) ([]CostItem, error) {
+	items := []CostItem{}
+
+	for _, part := range parseCommaList(value) {
+		m := costItemRegex.FindStringSubmatch(part)
+		if m == nil {
+			return nil, fmt.Errorf("invalid cost %q, expected e.g. \"one-off 12000 EUR\" or \"recurring 800 EUR/month\"", part)
+		}
+
+		amount, err := strconv.ParseFloat(strings.Replace(m[2], "_", "", -1), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cost amount %q", m[2])
+		}
+		switch strings.ToLower(m[3]) {
+		case "k":
+			amount *= 1000
+		case "m":
+			amount *= 1000000
+		}
+
+		item := CostItem{Kind: m[1], Amount: amount, Currency: m[4], Period: m[5]}
+		if item.Kind == costRecurring && item.Period == "" {
+			return nil, fmt.Errorf("invalid cost %q, recurring costs need a /month or /year period", part)
+		}
+		if item.Kind == costOneOff && item.Period != "" {
+			return nil, fmt.Errorf("invalid cost %q, one-off costs have no period", part)
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// yearly returns the recurring amount per year
+func (c CostItem) yearly() float64 {
+	if c.Period == "month" {
+		return c.Amount * 12
+	}
+
+	return c.Amount
+}
+
+type costTotals struct {
+	OneOff map[string]float64
+	Yearly map[string]float64
+}
+
+func runCosts(args []string) error {
+	fs := flag.NewFlagSet("costs", flag.ExitOnError)
+	dir := fs.String("dir", settings.Dir, "directory containing ADR files")
+	all := fs.Bool("all", false, "include proposed decisions, by default only accepted decisions are counted")
+	fs.Parse(args)
+
+	adrs, err := loadADRs(*dir)
+	if err != nil {
+		return err
+	}
+
+	totals := map[string]*costTotals{}
+	add := func(key string, c CostItem) {
+		t, ok := totals[key]
+		if !ok {
+			t = &costTotals{OneOff: map[string]float64{}, Yearly: map[string]float64{}}
+			totals[key] = t
+		}
+		if c.Kind == costOneOff {
+			t.OneOff[c.Currency] += c.Amount
+		} else {
+			t.Yearly[c.Currency] += c.yearly()
+		}
+	}
+
+	for _, a := range adrs {
+		if !*all && (lifecycleOf(a.Meta.Status) == lifecyclePending || a.Meta.Status == "") {
+			continue
+		}
+		for _, c := range a.Meta.Cost {
+			for _, tag := range a.Meta.Tags {
+				add(tag, c)
+			}
+			add("Total", c)
+		}
+	}
+
+	keys := []string{}
+	for k := range totals {
+		if k != "Total" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	if _, ok := totals["Total"]; ok {
+		keys = append(keys, "Total")
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', tabwriter.AlignRight)
+	defer w.Flush()
+
+	fmt.Fprintln(w, "Tag\tOne-off\tRecurring per year\t")
+	for _, k := range keys {
+		fmt.Fprintf(w, "%s\t%s\t%s\t\n", k, formatAmounts(totals[k].OneOff), formatAmounts(totals[k].Yearly))
+	}
+
+	return nil
+}
+
+func formatAmounts(amounts map[string]float64) string {
+	currencies := []string{}
+	for c := range amounts {
+		currencies = append(currencies, c)
+	}
+	sort.Strings(currencies)
+
+	parts := []string{}
+	for _, c := range currencies {
+		parts = append(parts, fmt.Sprintf("%.2f %s", amounts[c], c))
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+
+	return strings.Join(parts, " + ")
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// credentialSources resolve the references allowed in the credentials section
+// of the configuration, tokens themselves never belong in committed config
+var credentialSources = map[string]func(ref string) (string, error){
+	"env":      credentialFromEnv,
+	"file":     credentialFromFile,
+	"keychain": credentialFromKeychain,
+	"cmd":      credentialFromCommand,
+}
+
+// allowCommandCredentialsEnv opts in to the cmd: credentials of the config
+// file, which is committed and would otherwise run commands for anyone working
+// in a clone
+const allowCommandCredentialsEnv = "ADR_ALLOW_CMD_CREDENTIALS"
+
+// resolveCredential returns the secret of an integration, configured as e.g.
+// "github: env:GH_TOKEN" or "gitlab: cmd:pass show gitlab/token", and falls
+// back to the conventional environment variable when none is configured. A
+// cmd: reference runs only from ADR_CREDENTIAL_<NAME> or with the opt-in
+func resolveCredential(name string, fallbackEnv string) (string, error) {
+	ref, ok := cfg.Credentials[name]
+	if !ok {
+		return os.Getenv(fallbackEnv), nil
+	}
+
+	parts := strings.SplitN(ref, ":", 2)
+	source, found := credentialSources[parts[0]]
+	if len(parts) != 2 || !found {
+		return "", fmt.Errorf("invalid credential reference for %s, expected env:, file:, keychain: or cmd: in %s", name, configFile)
+	}
+
+	if parts[0] == "cmd" && !cfg.envCredentials[name] && !envBool(allowCommandCredentialsEnv) {
+		return "", fmt.Errorf("the %s credential runs a command, which %s may only do with %s=true, or set it as %s%s", name, configFile, allowCommandCredentialsEnv, credentialEnvPrefix, strings.ToUpper(name))
+	}
+
+	secret, err := source(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return "", fmt.Errorf("could not resolve the %s credential from %s: %s", name, parts[0], err)
+	}
+
+	return strings.TrimSpace(secret), nil
+}
+
+func credentialFromEnv(name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("%s is not set", name)
+	}
+
+	return value, nil
+}
+
+func credentialFromFile(path string) (string, error) {
+	if strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = home + path[1:]
+	}
+
+	body, err := ioutil.ReadFile(path)
+	return string(body), err
+}
+
+// credentialFromKeychain reads service or service/account from the macOS
+// keychain or the freedesktop secret service
+func credentialFromKeychain(ref string) (string, error) {
+	service, account := ref, ""
+	if i := strings.Index(ref, "/"); i > 0 {
+		service, account = ref[:i], ref[i+1:]
+	}
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		args := []string{"find-generic-password", "-s", service, "-w"}
+		if account != "" {
+			args = append(args, "-a", account)
+		}
+		cmd = exec.Command("security", args...)
+	case "linux", "freebsd", "openbsd":
+		args := []string{"lookup", "service", service}
+		if account != "" {
+			args = append(args, "account", account)
+		}
+		cmd = exec.Command("secret-tool", args...)
+	default:
+		return "", fmt.Errorf("no keychain support on %s, use env:, file: or cmd:", runtime.GOOS)
+	}
+
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+// credentialFromCommand runs a command such as "gcloud auth print-access-token"
+// and uses its output, the command line is split on spaces without a shell
+func credentialFromCommand(command string) (string, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return "", fmt.Errorf("empty command")
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	return string(out), err
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCommandCredentials(t *testing.T) {
+	defer func(c *Config) { cfg = c }(cfg)
+
+	cfg = defaultConfig()
+	cfg.Credentials = map[string]string{"github": "cmd:echo from-config"}
+	_, err := resolveCredential("github", "GITHUB_TOKEN")
+	if err == nil || !strings.Contains(err.Error(), allowCommandCredentialsEnv) {
+		t.Errorf("cmd: of the config file ran without the opt-in, error %v", err)
+	}
+
+	os.Setenv(allowCommandCredentialsEnv, "true")
+	secret, err := resolveCredential("github", "GITHUB_TOKEN")
+	os.Unsetenv(allowCommandCredentialsEnv)
+	if err != nil || secret != "from-config" {
+		t.Errorf("opted in = %q, %v, want from-config", secret, err)
+	}
+
+	os.Setenv(credentialEnvPrefix+"GITHUB", "cmd:echo from-env")
+	defer os.Unsetenv(credentialEnvPrefix + "GITHUB")
+	p := Profile{}
+	err = applyEnv(cfg, &p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret, err = resolveCredential("github", "GITHUB_TOKEN")
+	if err != nil || secret != "from-env" {
+		t.Errorf("from the environment = %q, %v, want from-env", secret, err)
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type sitemap struct {
+	URLs []struct {
+		Loc string `xml:"loc"`
+	} `xml:"url"`
+}
+
+type pageCheck struct {
+	ADR    *ADR
+	URL    string
+	Status int
+	Err    error
+}
+
+func runVerifyDeployment(args []string) error {
+	fs := flag.NewFlagSet("verify-deployment", flag.ExitOnError)
+	dir := fs.String("dir", settings.Dir, "directory containing ADR files")
+	site := fs.String("site", cfg.SiteURL, "root URL of the published site")
+	sitemapURL := fs.String("sitemap", "", "sitemap listing the published pages, defaults to <site>/sitemap.xml")
+	concurrency := fs.Int("concurrency", 8, "number of pages checked in parallel")
+	timeout := fs.Duration("timeout", 10*time.Second, "timeout per request")
+	fs.Parse(args)
+
+	if *site == "" {
+		return fmt.Errorf("-site is required when no siteURL is configured")
+	}
+	if !networkAllowed("verify-deployment of " + *site) {
+		return nil
+	}
+	if *sitemapURL == "" {
+		*sitemapURL = strings.TrimSuffix(*site, "/") + "/sitemap.xml"
+	}
+
+	adrs, err := loadADRs(*dir)
+	if err != nil {
+		return err
+	}
+	adrs = searchADRs(settings.Filter.apply(adrs), "")
+
+	client := newHTTPClient(*timeout)
+
+	publish := startSpan("publish.verify")
+	publish.set("adr.site", *site)
+	publish.set("adr.pages", len(adrs))
+
+	checks := make([]pageCheck, len(adrs))
+	sem := make(chan struct{}, *concurrency)
+	var wg sync.WaitGroup
+	for i, a := range adrs {
+		checks[i] = pageCheck{ADR: a, URL: pageURL(*site, a)}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(c *pageCheck) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			c.Status, c.Err = probe(client, c.URL)
+		}(&checks[i])
+	}
+	wg.Wait()
+
+	problems := 0
+	for _, c := range checks {
+		switch {
+		case c.Err != nil:
+			fmt.Printf("MISSING  %s %s: %s\n", recordLabel(c.ADR), c.URL, c.Err)
+			problems++
+		case c.Status != http.StatusOK:
+			fmt.Printf("MISSING  %s %s: HTTP %d\n", recordLabel(c.ADR), c.URL, c.Status)
+			problems++
+		}
+	}
+
+	stale, err := stalePages(client, *sitemapURL, *site, *dir, checks)
+	if err != nil {
+		fmt.Printf("WARNING  could not check for stale pages: %s\n", err)
+	}
+	for _, s := range stale {
+		fmt.Printf("STALE    %s\n", s)
+		problems++
+	}
+
+	fmt.Printf("%d pages checked, %d problems\n", len(checks), problems)
+	publish.set("adr.problems", problems)
+	publish.finish(nil)
+
+	if problems > 0 {
+		return fmt.Errorf("deployment at %s does not match the catalog", *site)
+	}
+
+	return nil
+}
+
+// probe tries HEAD first and falls back to GET for servers that do not support it
+func probe(client *http.Client, url string) (int, error) {
+	resp, err := client.Head(url)
+	if err == nil {
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusMethodNotAllowed {
+			return resp.StatusCode, nil
+		}
+	}
+
+	resp, err = client.Get(url)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+
+	return resp.StatusCode, nil
+}
+
+// stalePages lists sitemap entries below the ADR directory of the site that no
+// longer belong to a catalog entry
+func stalePages(client *http.Client, sitemapURL string, site string, dir string, checks []pageCheck) ([]string, error) {
+	resp, err := client.Get(sitemapURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned HTTP %d", sitemapURL, resp.StatusCode)
+	}
+
+	var sm sitemap
+	err = xml.NewDecoder(resp.Body).Decode(&sm)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sitemap %s: %s", sitemapURL, err)
+	}
+
+	expected := map[string]bool{}
+	for _, c := range checks {
+		expected[c.URL] = true
+	}
+
+	prefix := strings.TrimSuffix(site, "/") + "/" + strings.Trim(dir, "/") + "/"
+	stale := []string{}
+	for _, u := range sm.URLs {
+		loc := strings.TrimSpace(u.Loc)
+		if strings.HasPrefix(loc, prefix) && strings.HasSuffix(loc, ".html") && !expected[loc] {
+			stale = append(stale, loc)
+		}
+	}
+	sort.Strings(stale)
+
+	return stale, nil
+}
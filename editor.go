@@ -0,0 +1,156 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+func runEdit(args []string) error {
+	fs := flag.NewFlagSet("edit", flag.ExitOnError)
+	dir := fs.String("dir", settings.Dir, "directory containing ADR files")
+	meta := fs.Bool("meta", false, "place the cursor on the metadata table")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: edit [flags] <index>")
+	}
+
+	adr, err := resolveFromDir(*dir, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	line := 0
+	if *meta {
+		line, err = metadataLine(adr.Meta.Path)
+		if err != nil {
+			return err
+		}
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	cmdArgs := strings.Fields(editor)
+	cmdArgs = append(cmdArgs, editorTarget(cmdArgs[0], adr.Meta.Path, line)...)
+
+	cmd := exec.Command(cmdArgs[0], cmdArgs[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
+
+func runOpen(args []string) error {
+	fs := flag.NewFlagSet("open", flag.ExitOnError)
+	dir := fs.String("dir", settings.Dir, "directory containing ADR files")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: open [flags] <index>")
+	}
+
+	adr, err := resolveFromDir(*dir, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	target := siteLink(adr)
+	if target == "" {
+		rendered := renderedPath(adr)
+		_, err := os.Stat(rendered)
+		if err != nil {
+			return fmt.Errorf("no rendered page at %s and no siteURL configured in %s", rendered, configFile)
+		}
+		abs, err := filepath.Abs(rendered)
+		if err != nil {
+			return err
+		}
+		target = "file://" + filepath.ToSlash(abs)
+	}
+
+	return openBrowser(target)
+}
+
+func resolveFromDir(dir string, ref string) (*ADR, error) {
+	adrs, err := loadADRs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return resolveRecord(adrs, ref)
+}
+
+// metadataLine returns the 1 based line of the |Metadata header row or of the
+// first metadata attribute
+func metadataLine(file string) (int, error) {
+	body, err := ioutil.ReadFile(file)
+	if err != nil {
+		return 0, err
+	}
+
+	lines := strings.Split(string(body), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "|Metadata") {
+			return i + 1, nil
+		}
+	}
+
+	if b := findMetadata(lines); b.Attributes && b.Start >= 0 {
+		return b.Start + 1, nil
+	}
+
+	return 0, nil
+}
+
+// editorTarget builds the file arguments for the editor, most terminal editors
+// take +line while the VS Code and Sublime families take file:line
+func editorTarget(editor string, file string, line int) []string {
+	if line == 0 {
+		return []string{file}
+	}
+
+	switch filepath.Base(editor) {
+	case "code", "code-insiders", "codium":
+		return []string{"--goto", file + ":" + strconv.Itoa(line)}
+	case "subl", "zed":
+		return []string{file + ":" + strconv.Itoa(line)}
+	}
+
+	return []string{"+" + strconv.Itoa(line), file}
+}
+
+func openBrowser(target string) error {
+	var cmd *exec.Cmd
+
+	switch {
+	case os.Getenv("BROWSER") != "":
+		cmd = exec.Command(os.Getenv("BROWSER"), target)
+	case runtime.GOOS == "darwin":
+		cmd = exec.Command("open", target)
+	case runtime.GOOS == "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", target)
+	default:
+		cmd = exec.Command("xdg-open", target)
+	}
+
+	// terminal browsers set through $BROWSER need the terminal
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
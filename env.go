@@ -0,0 +1,255 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// envVar configures the tool without a config file, for CI containers, the
+// variables override the config file and are overridden by flags
+type envVar struct {
+	Name string
+	Help string
+	// set applies the value, the variables without one default global flags
+	set func(c *Config, p *Profile, value string) error
+}
+
+const credentialEnvPrefix = "ADR_CREDENTIAL_"
+
+// profileEnvVars default the global flags or set the profile, which takes
+// precedence over the config file
+var profileEnvVars = []envVar{
+	{Name: "ADR_CONFIG", Help: "default of --config"},
+	{Name: "ADR_PROFILE", Help: "default of --profile"},
+	{Name: "ADR_OFFLINE", Help: "default of --offline, true or false"},
+	{Name: "ADR_STRICT", Help: "default of --strict, true or false"},
+	{Name: "ADR_LENIENT", Help: "default of --lenient, true or false"},
+	{Name: "ADR_FORMAT", Help: "default of --format"},
+	{Name: "ADR_COLOR", Help: "default of --color"},
+	{Name: "ADR_DIR", Help: "directory containing ADR files", set: func(c *Config, p *Profile, v string) error {
+		p.Dir = v
+		return nil
+	}},
+	{Name: "ADR_TEMPLATE", Help: "index template", set: func(c *Config, p *Profile, v string) error {
+		p.Template = v
+		return nil
+	}},
+	{Name: "ADR_OUTPUT", Help: "file build writes the index to", set: func(c *Config, p *Profile, v string) error {
+		c.Output, p.Output = v, v
+		return nil
+	}},
+	{Name: "ADR_FILTER_TAGS", Help: "comma separated tags a published record must carry one of", set: func(c *Config, p *Profile, v string) error {
+		p.Filter.Tags = parseCommaList(v)
+		return nil
+	}},
+	{Name: "ADR_FILTER_STATUSES", Help: "comma separated statuses a published record must have one of", set: func(c *Config, p *Profile, v string) error {
+		p.Filter.Statuses = parseCommaList(v)
+		return nil
+	}},
+	{Name: "ADR_FILTER_EXCLUDE_TAGS", Help: "comma separated tags keeping a record from being published", set: func(c *Config, p *Profile, v string) error {
+		p.Filter.ExcludeTags = parseCommaList(v)
+		return nil
+	}},
+}
+
+// envVars are the variables of the global flags and the profile, one for every
+// key of the config file, see configEnvVars, and the credential references
+var envVars = append(append(profileEnvVars, configEnvVars(reflect.TypeOf(Config{}), nil, nil)...),
+	envVar{Name: credentialEnvPrefix + "<NAME>", Help: "credentials reference of an integration, e.g. ADR_CREDENTIAL_GITHUB=env:GH_TOKEN"},
+	envVar{Name: allowCommandCredentialsEnv, Help: "true lets cmd: credentials of the config file run, e.g. in CI on a trusted repository"})
+
+// configEnvVars derives a variable from the yaml keys of every config field,
+// http.caBundle is ADR_HTTP_CA_BUNDLE. Lists of strings are comma separated,
+// maps and lists of objects are given as yaml, e.g. ADR_SCOPES='{service:payments:
+// department:finance}'. The config keys of the profile variables are left out,
+// credentials have a variable per name and profiles none
+func configEnvVars(typ reflect.Type, keys []string, index []int) []envVar {
+	vars := []envVar{}
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		key := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if f.PkgPath != "" || key == "" || key == "-" {
+			continue
+		}
+		path := append(append([]string{}, keys...), key)
+		at := append(append([]int{}, index...), i)
+		if f.Type.Kind() == reflect.Struct && f.Type != reflect.TypeOf(time.Time{}) {
+			vars = append(vars, configEnvVars(f.Type, path, at)...)
+			continue
+		}
+
+		name := "ADR_" + envName(path)
+		// the profile is picked before the variables apply
+		if name == "ADR_CREDENTIALS" || name == "ADR_PROFILES" || profileEnvVar(name) {
+			continue
+		}
+		vars = append(vars, envVar{Name: name, Help: strings.Join(path, ".") + envHint(f.Type), set: func(c *Config, p *Profile, v string) error {
+			return setEnvValue(reflect.ValueOf(c).Elem().FieldByIndex(at), v)
+		}})
+	}
+
+	return vars
+}
+
+func profileEnvVar(name string) bool {
+	for _, e := range profileEnvVars {
+		if e.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+var envWordRegex = regexp.MustCompile(`[A-Z]+[a-z0-9]*|[a-z0-9]+`)
+
+// envName upper cases the yaml keys of a config path with an underscore
+// between their words, siteURL becomes SITE_URL and caBundle CA_BUNDLE
+func envName(keys []string) string {
+	words := []string{}
+	for _, k := range keys {
+		for _, w := range envWordRegex.FindAllString(k, -1) {
+			// an acronym running into the next word, URLPath is URL and Path
+			upper := strings.IndexFunc(w, func(r rune) bool { return r < 'A' || r > 'Z' })
+			if upper > 1 {
+				words = append(words, w[:upper-1])
+				w = w[upper-1:]
+			}
+			words = append(words, w)
+		}
+	}
+
+	return strings.ToUpper(strings.Join(words, "_"))
+}
+
+func envHint(typ reflect.Type) string {
+	switch {
+	case typ == reflect.TypeOf(time.Duration(0)):
+		return ", a duration such as 30s"
+	case typ.Kind() == reflect.Bool:
+		return ", true or false"
+	case typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.String:
+		return ", comma separated"
+	case typ.Kind() == reflect.Slice, typ.Kind() == reflect.Map:
+		return ", as yaml"
+	}
+
+	return ""
+}
+
+// setEnvValue parses v into a config field
+func setEnvValue(field reflect.Value, v string) error {
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(v)
+		field.SetInt(int64(d))
+		return err
+	}
+
+	switch {
+	case field.Kind() == reflect.String:
+		field.SetString(v)
+	case field.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case field.Kind() == reflect.Int, field.Kind() == reflect.Int64:
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String:
+		field.Set(reflect.ValueOf(parseCommaList(v)))
+	default:
+		value := reflect.New(field.Type())
+		err := yaml.Unmarshal([]byte(v), value.Interface())
+		if err != nil {
+			return err
+		}
+		field.Set(value.Elem())
+	}
+
+	return nil
+}
+
+// envString returns the value of an ADR_* variable or def when it is unset
+func envString(name string, def string) string {
+	if v, ok := os.LookupEnv(name); ok {
+		return v
+	}
+
+	return def
+}
+
+func envBool(name string) bool {
+	v, _ := strconv.ParseBool(os.Getenv(name))
+	return v
+}
+
+// applyEnv overrides the configuration and the profile settings with the
+// ADR_* variables that are set
+func applyEnv(c *Config, p *Profile) error {
+	configured := false
+	for _, e := range envVars {
+		v, ok := os.LookupEnv(e.Name)
+		if !ok || e.set == nil {
+			continue
+		}
+		err := e.set(c, p, v)
+		if err != nil {
+			return fmt.Errorf("invalid %s=%q: %s", e.Name, v, err)
+		}
+		configured = true
+	}
+	// types, metadata keys and SLOs may have been replaced
+	if configured {
+		err := c.compile()
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, kv := range os.Environ() {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 || !strings.HasPrefix(parts[0], credentialEnvPrefix) {
+			continue
+		}
+		if c.Credentials == nil {
+			c.Credentials = map[string]string{}
+		}
+		if c.envCredentials == nil {
+			c.envCredentials = map[string]bool{}
+		}
+		name := strings.ToLower(strings.TrimPrefix(parts[0], credentialEnvPrefix))
+		c.Credentials[name] = parts[1]
+		c.envCredentials[name] = true
+	}
+
+	return nil
+}
+
+// printEnv lists the variables the tool reads with their current values
+func printEnv() error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	defer w.Flush()
+
+	for _, e := range envVars {
+		v, ok := os.LookupEnv(e.Name)
+		if !ok {
+			v = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", e.Name, v, e.Help)
+	}
+
+	return nil
+}
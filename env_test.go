@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEnvName(t *testing.T) {
+	tests := map[string][]string{
+		"DIR":                   {"dir"},
+		"SITE_URL":              {"siteURL"},
+		"HTTP_CA_BUNDLE":        {"http", "caBundle"},
+		"TAG_INDEX_OUTPUT":      {"tagIndex", "output"},
+		"SLOS":                  {"slos"},
+		"UPDATE_URL_PATH":       {"update", "URLPath"},
+		"ANALYTICS_RETAIN_DAYS": {"analytics", "retainDays"},
+	}
+	for want, keys := range tests {
+		if got := envName(keys); got != want {
+			t.Errorf("envName(%v) = %s, want %s", keys, got, want)
+		}
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	env := map[string]string{
+		"ADR_DIR":               "decisions",
+		"ADR_STATUSES":          "Open, Closed",
+		"ADR_NOTES_DIR":         "meetings",
+		"ADR_METADATA_STRICT":   "true",
+		"ADR_SANDBOX_TIMEOUT":   "2s",
+		"ADR_HTTP_RETRIES":      "5",
+		"ADR_SCOPES":            "{service:payments: department:finance}",
+		"ADR_CREDENTIAL_GITHUB": "env:GH_TOKEN",
+	}
+	for k, v := range env {
+		os.Setenv(k, v)
+		defer os.Unsetenv(k)
+	}
+
+	c := defaultConfig()
+	p := Profile{}
+	err := applyEnv(c, &p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Dir != "decisions" {
+		t.Errorf("Dir = %q", p.Dir)
+	}
+	if !reflect.DeepEqual(c.Statuses, []string{"Open", "Closed"}) {
+		t.Errorf("Statuses = %q", c.Statuses)
+	}
+	if c.NotesDir != "meetings" || !c.Metadata.Strict || c.Sandbox.Timeout != 2*time.Second || c.HTTP.Retries != 5 {
+		t.Errorf("NotesDir %q, Metadata.Strict %v, Sandbox.Timeout %s, HTTP.Retries %d", c.NotesDir, c.Metadata.Strict, c.Sandbox.Timeout, c.HTTP.Retries)
+	}
+	if c.Scopes["service:payments"] != "department:finance" {
+		t.Errorf("Scopes = %v", c.Scopes)
+	}
+	if c.Credentials["github"] != "env:GH_TOKEN" {
+		t.Errorf("Credentials = %v", c.Credentials)
+	}
+
+	os.Setenv("ADR_HTTP_RETRIES", "many")
+	err = applyEnv(defaultConfig(), &Profile{})
+	if err == nil {
+		t.Error("ADR_HTTP_RETRIES=many was accepted")
+	}
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// exit codes of adr-index
+const (
+	// exitFailure is a command that failed or found invalid records
+	exitFailure = 1
+	// exitUsage is a command line or configuration that is wrong
+	exitUsage = 2
+)
+
+// The Err types tell the problems of a record apart for the hints, findings
+// and located error lines of the commands. adr-index is a command and not an
+// importable package, tools built around it branch on the rule, path and line
+// of the errors in the --format json envelope instead
+
+// ErrInvalidStatus is returned when the Status row of a record is missing or
+// not one of the statuses of its record type
+type ErrInvalidStatus struct {
+	Path    string
+	Line    int
+	Status  string
+	Allowed []string
+}
+
+func (e *ErrInvalidStatus) Error() string {
+	return fmt.Sprintf("invalid status %q, must be one of: %s in %s", e.Status, strings.Join(e.Allowed, ", "), e.Path)
+}
+
+// ErrMissingMetadata is returned when a metadata row required by the record type
+// is absent or empty, Line points at the metadata table header when there is one
+type ErrMissingMetadata struct {
+	Path       string
+	Line       int
+	Key        string
+	RecordType string
+}
+
+func (e *ErrMissingMetadata) Error() string {
+	if e.RecordType != "" {
+		return fmt.Sprintf("%s is required for %s in %s", strings.ToLower(e.Key), e.RecordType, e.Path)
+	}
+
+	return fmt.Sprintf("%s is required in %s", strings.ToLower(e.Key), e.Path)
+}
+
+// ErrInvalidMetadata is returned when a metadata row has a value that cannot be
+// parsed, Err holds the underlying parse error if any
+type ErrInvalidMetadata struct {
+	Path   string
+	Line   int
+	Key    string
+	Value  string
+	Reason string
+	Err    error
+}
+
+func (e *ErrInvalidMetadata) Error() string {
+	return fmt.Sprintf("%s in %s", e.Reason, e.Path)
+}
+
+func (e *ErrInvalidMetadata) Unwrap() error {
+	return e.Err
+}
+
+// ErrMissingSection is reported by lint when a record lacks a required body
+// section, or has it without text when Empty is set, Line is its heading
+type ErrMissingSection struct {
+	Path    string
+	Line    int
+	Section string
+	Empty   bool
+}
+
+func (e *ErrMissingSection) Error() string {
+	if e.Empty {
+		return fmt.Sprintf("section %s is empty in %s", e.Section, e.Path)
+	}
+
+	return fmt.Sprintf("section %s is missing in %s", e.Section, e.Path)
+}
+
+// ErrDuplicateIndex is returned when two records of the same numbering share an index
+type ErrDuplicateIndex struct {
+	Index int
+	Path  string
+	Other string
+}
+
+func (e *ErrDuplicateIndex) Error() string {
+	return fmt.Sprintf("duplicate index %d, conflict between %s and %s", e.Index, e.Path, e.Other)
+}
+
+// ErrInvalidReference is returned when a Supersedes or Superseded by row names a
+// record that does not exist or does not name the record back, Target is then
+// the referenced record, Back the row it lacks and Label the record at Path
+type ErrInvalidReference struct {
+	Path   string
+	Key    string
+	Ref    string
+	Reason string
+	Target string
+	Back   string
+	Label  string
+}
+
+func (e *ErrInvalidReference) Error() string {
+	return fmt.Sprintf("%s in %s", e.Reason, e.Path)
+}
+
+// ErrConflictingDecision is returned when two active records contradict each
+// other through a Conflicts with row or a shared Decides value, Other is the
+// record at odds with the one at Path
+type ErrConflictingDecision struct {
+	Path   string
+	Other  string
+	Label  string
+	Reason string
+}
+
+func (e *ErrConflictingDecision) Error() string {
+	return fmt.Sprintf("%s, supersede one of them in %s", e.Reason, e.Path)
+}
+
+const (
+	severityError   = "error"
+	severityWarning = "warning"
+)
+
+// Finding is a problem of a record in a form tools can consume, Rule names the
+// kind of problem, see violationKind
+type Finding struct {
+	Severity string `json:"severity"`
+	Rule     string `json:"rule"`
+	Message  string `json:"message"`
+	Line     int    `json:"line,omitempty"`
+	Key      string `json:"key,omitempty"`
+	Fix      *Fix   `json:"fix,omitempty"`
+}
+
+func newFinding(severity string, err error) Finding {
+	f := Finding{Severity: severity, Rule: violationKind(err), Message: err.Error(), Fix: suggestFix(err)}
+
+	switch e := err.(type) {
+	case *ErrInvalidStatus:
+		f.Line, f.Key = e.Line, "Status"
+	case *ErrMissingMetadata:
+		f.Line, f.Key = e.Line, e.Key
+	case *ErrInvalidMetadata:
+		f.Line, f.Key = e.Line, e.Key
+	case *ErrMissingSection:
+		f.Line = e.Line
+	}
+
+	return f
+}
+
+// ErrInvalidCatalog is every problem found loading a catalog, each record is
+// read before it is returned
+type ErrInvalidCatalog struct {
+	Dir  string
+	Errs []error
+}
+
+func (e *ErrInvalidCatalog) Error() string {
+	if len(e.Errs) == 1 {
+		return e.Errs[0].Error()
+	}
+
+	lines := []string{fmt.Sprintf("%d problems in %s:", len(e.Errs), e.Dir)}
+	for _, err := range e.Errs {
+		lines = append(lines, "  "+err.Error())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Unwrap is the first problem, errors.As finds the typed errors of a catalog
+// with a single problem
+func (e *ErrInvalidCatalog) Unwrap() error {
+	return e.Errs[0]
+}
+
+// InvalidRecord is a record left out of the index because it does not parse
+type InvalidRecord struct {
+	Path  string `json:"path,omitempty"`
+	Line  int    `json:"line,omitempty"`
+	Rule  string `json:"rule"`
+	Error string `json:"error"`
+	Fix   *Fix   `json:"fix,omitempty"`
+}
+
+var errorPathRegex = regexp.MustCompile(` in (\S+)$`)
+
+// invalidRecords pairs errors with the file they were found in, the typed
+// errors carry it and the others end in "in <path>"
+func invalidRecords(errs []error) []InvalidRecord {
+	invalid := []InvalidRecord{}
+	for _, err := range errs {
+		if c, ok := err.(*ErrInvalidCatalog); ok {
+			invalid = append(invalid, invalidRecords(c.Errs)...)
+			continue
+		}
+		r := InvalidRecord{Rule: violationKind(err), Error: err.Error(), Fix: suggestFix(err)}
+		switch e := err.(type) {
+		case *ErrInvalidStatus:
+			r.Path, r.Line = e.Path, e.Line
+		case *ErrMissingMetadata:
+			r.Path, r.Line = e.Path, e.Line
+		case *ErrInvalidMetadata:
+			r.Path, r.Line = e.Path, e.Line
+		case *ErrDuplicateIndex:
+			r.Path = e.Path
+		case *ErrInvalidReference:
+			r.Path = e.Path
+		case *ErrMissingSection:
+			r.Path, r.Line = e.Path, e.Line
+		default:
+			if m := errorPathRegex.FindStringSubmatch(r.Error); m != nil {
+				r.Path = m[1]
+			}
+		}
+		invalid = append(invalid, r)
+	}
+
+	return invalid
+}
+
+// reportError writes every problem of err to w a line each, located by file
+// and line where known, and returns the exit code, usage errors exit with
+// exitUsage whatever code says
+func reportError(w io.Writer, err error, code int) int {
+	p := newPainter(w)
+	for _, r := range invalidRecords([]error{err}) {
+		location := r.Path
+		if location != "" && r.Line > 0 {
+			location = fmt.Sprintf("%s:%d", location, r.Line)
+		}
+		if location != "" {
+			location += ": "
+		}
+		rule := ""
+		if r.Rule != "other" {
+			rule = r.Rule + ": "
+		}
+		fmt.Fprintf(w, "%s %s%s%s\n", p.error("error:"), location, rule, r.Error)
+		if r.Fix != nil {
+			fmt.Fprintf(w, "  %s %s\n", p.paint(colorBlue, "fix:"), r.Fix.Description)
+		}
+	}
+
+	if strings.HasPrefix(err.Error(), "usage:") {
+		return exitUsage
+	}
+
+	return code
+}
@@ -0,0 +1,474 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+)
+
+// explainTopic documents a rule of the findings or a metadata field, Doc is a
+// text/template executed with explainData so examples follow the config
+type explainTopic struct {
+	Summary string
+	Doc     string
+	// Field links a rule to the metadata field it checks
+	Field string
+}
+
+type explainData struct {
+	Key         string
+	DateFormat  string
+	Today       string
+	Statuses    string
+	Pending     string
+	Outcomes    string
+	Types       string
+	Placeholder string
+	ConfigFile  string
+}
+
+// ruleTopics are keyed by the rule names of violationKind, missing-<key> rules
+// share missing-metadata
+var ruleTopics = map[string]explainTopic{
+	"invalid-status": {
+		Summary: "the Status row is missing or not a status of the record type",
+		Field:   "Status",
+		Doc: `Every ADR needs a Status row whose value is one of the statuses of its
+record type. For ADRs these are: {{.Statuses}}.
+
+Wrong:
+  |Status |accepted
+  |Status |Done
+Right:
+  |Status |Approved
+  |Status |Implemented
+
+The comparison is exact, "approved" is reported and adr-index migrate
+rewrites it along with aliases used by other ADR tools such as accepted or
+draft. Other record types define their statuses in {{.ConfigFile}}, the
+statuses list there or --statuses replaces the ADR statuses.`,
+	},
+	"missing-metadata": {
+		Summary: "a row required by the record type is missing or empty",
+		Doc: `The record type requires a {{.Key}} row in the metadata table.
+
+ADRs require Date, Author, Status and Tags, other record types list their
+rows under required in {{.ConfigFile}}:
+
+  types:
+    rfc:
+      required: [Date, Author, Status, Reviewers]
+
+Add the row with a value, an empty row counts as missing:
+  |{{.Key}} |...`,
+	},
+	"invalid-date": {
+		Summary: "a Date or Reviewed value is not in the configured date layout",
+		Field:   "Date",
+		Doc: `Dates are written as {{.DateFormat}}, e.g. today is {{.Today}}.
+
+Wrong:
+  |Date |2024/03/05
+  |Date |5 March 2024
+Right:
+  |Date |{{.Today}}
+
+dateLayout in {{.ConfigFile}} changes the layout for all records, e.g.
+dateLayout: YYYY-MM-DD, adr-index migrate -only date then rewrites the
+existing dates.`,
+	},
+	"invalid-outcome": {
+		Summary: "the Outcome row is not one of the review verdicts",
+		Field:   "Outcome",
+		Doc: `Outcome records the verdict of the outcome review and must be one of:
+{{.Outcomes}}.
+
+  |Outcome |Confirmed
+
+adr-index outcomes lists the decisions due for a review.`,
+	},
+	"invalid-cost": {
+		Summary: "a Cost entry cannot be read as an amount",
+		Field:   "Cost",
+		Doc: `Cost lists one-off and recurring estimates separated by commas, recurring
+costs need a /month or /year period and one-off costs have none.
+
+  |Cost |one-off 12000 EUR, recurring 800 EUR/month
+  |Cost |one-off 15k USD
+
+Amounts may use k and m suffixes and underscores, 12_000.`,
+	},
+	"invalid-type": {
+		Summary: "the Type row names no configured record type",
+		Field:   "Type",
+		Doc: `Type is one of the configured record types: {{.Types}}.
+
+The type is normally taken from the file name, the row is only needed when a
+record is named like another type, e.g. a design note in a NNNN-title.adoc
+file:
+  |Type |Design Note`,
+	},
+	"unexpected-key": {
+		Summary: "the metadata table has a row the record type does not know",
+		Doc: `Rows other than the known metadata keys and the required rows of the
+record type are reported, they are usually typos:
+
+  |Tgs |messaging      should be  |Tags |messaging
+
+A row you mean to keep is declared under metadata.keys in {{.ConfigFile}},
+optionally with the values it may take, or made required by a type.
+This is a warning, --strict or metadata.strict makes it an error.`,
+	},
+	"repeated-key": {
+		Summary: "a metadata row appears twice",
+		Doc: `The later row replaces the earlier one, merge them:
+
+  |Tags |messaging
+  |Tags |infra          becomes  |Tags |messaging, infra
+
+This is a warning, --strict makes it an error.`,
+	},
+	"duplicate-index": {
+		Summary: "two records of the same numbering share an index",
+		Doc: `Indexes come from the file names and must be unique per numbering, types
+sharing the NNNN-title file names share the ADR numbers. This happens when two
+branches add a record at the same time, rename the later one to the next free
+index:
+
+  adr/0012-use-kafka.adoc
+  adr/0012-use-nats.adoc    rename to  adr/0013-use-nats.adoc
+
+References to the renamed record, e.g. ADR-12, must follow.`,
+	},
+	"missing-title": {
+		Summary: "the record has no = Title heading",
+		Doc: `The first heading is the title of the record in the index:
+
+  = Use Kafka for event streaming
+
+Markdown records use # Use Kafka for event streaming.`,
+	},
+	"invalid-filename": {
+		Summary: "the file name does not carry an index in the naming of its type",
+		Doc: `ADR files are named NNNN-title.adoc, e.g. 0012-use-kafka.adoc, other types
+use the filename pattern of their type in {{.ConfigFile}}.
+
+adr-index migrate renames legacy names such as 12_use_kafka.adoc.`,
+	},
+	"invalid-reference": {
+		Summary: "a Supersedes or Superseded by row is one sided or names no record",
+		Field:   "Supersedes",
+		Doc: `Both sides of a supersession name each other and the named records must
+exist. When ADR-12 replaces ADR-7:
+
+  0012-...adoc  |Supersedes |ADR-7
+  0007-...adoc  |Status |Superseded
+                |Superseded by |ADR-12
+
+adr-index supersede 7 "New title" creates the new record and updates ADR-7.
+References into inherited catalogs, org:ADR-3, are not checked.`,
+	},
+	"conflicting-decision": {
+		Summary: "two active records contradict each other",
+		Field:   "Conflicts with",
+		Doc: `Records conflict when one names the other in its Conflicts with row or both
+answer the same question in their Decides row:
+
+  0004-...adoc  |Status |Approved
+                |Decides |message-broker
+  0009-...adoc  |Status |Approved
+                |Decides |message-broker
+
+Only one of them may be active, supersede the older one, e.g.
+adr-index supersede 4 "Use NATS", or give one a terminal status.`,
+	},
+	"missing-section": {
+		Summary: "a section required in the body is missing",
+		Doc: `adr-index lint expects ADRs to have Context, Decision and Consequences
+sections. A heading starting with the name counts, e.g. "Context and Problem
+Statement". The list is set in {{.ConfigFile}}, other record types list theirs
+under sections:
+
+  lint:
+    sections: [Context, Decision, Consequences, Alternatives]
+  types:
+    rfc:
+      sections: [Summary, Motivation]`,
+	},
+	"empty-section": {
+		Summary: "a required section has no text",
+		Doc: `A required section holds nothing but blank lines or the [bracketed] hints
+of the skeleton:
+
+  == Consequences
+
+  [Any consequences of this design]
+
+Replace the hint with the text of the section, adr-index lint lists the
+required sections under missing-section.`,
+	},
+}
+
+// fieldTopics are keyed by the metadata keys
+var fieldTopics = map[string]explainTopic{
+	"Date": {
+		Summary: "the date the decision was made",
+		Doc: `Written as {{.DateFormat}}:
+  |Date |{{.Today}}
+
+Without a Date row the date of the last commit of the file is used and the
+record is reported, see adr-index explain invalid-date.`,
+	},
+	"Author": {
+		Summary: "who wrote the record, comma separated",
+		Doc: `Authors are names, git handles or a name with email and team:
+  |Author |@alice, @bob
+  |Author |Alice Smith <alice@example.com> (Platform)
+
+adr-index new fills in your git user.`,
+	},
+	"Status": {
+		Summary: "where the decision stands",
+		Doc: `One of: {{.Statuses}}.
+
+Statuses have a lifecycle, {{.Pending}} await a verdict, terminal ones such as
+Rejected or Superseded no longer apply and the others are in force, only
+records in force are pinned by adr-index checksums. lifecycle in
+{{.ConfigFile}} gives configured statuses their lifecycle:
+
+  lifecycle:
+    Draft: pending`,
+	},
+	"Tags": {
+		Summary: "the topics of the decision, comma separated",
+		Doc: `  |Tags |messaging, infra
+
+The index groups records by tag. {{.Placeholder}} marks records still to be
+tagged, adr-index new sets it when no -tags are given.`,
+	},
+	"Impact": {
+		Summary: "a free form impact rating",
+		Doc: `  |Impact |High
+
+The value is shown in the index and not checked.`,
+	},
+	"Cost": {
+		Summary: "one-off and recurring cost estimates",
+		Doc: `  |Cost |one-off 12000 EUR, recurring 800 EUR/month
+
+adr-index costs sums the estimates of the catalog, see adr-index explain
+invalid-cost for the syntax.`,
+	},
+	"Outcome": {
+		Summary: "the verdict of the outcome review",
+		Doc: `One of: {{.Outcomes}}.
+  |Outcome |Confirmed`,
+	},
+	"Reviewed": {
+		Summary: "the date the decision was last confirmed to still hold",
+		Doc: `Written as {{.DateFormat}}:
+  |Reviewed |{{.Today}}
+
+The freshness of a record counts from the later of Date and Reviewed.`,
+	},
+	"Incidents": {
+		Summary: "incident IDs or URLs that led to the decision",
+		Doc: `  |Incidents |INC-2041, https://example.pagerduty.com/incidents/Q1W2E3
+
+adr-index incidents matches them with an export of your incident tracker.`,
+	},
+	"Supersedes": {
+		Summary: "the records this decision replaces",
+		Doc: `  |Supersedes |ADR-7
+
+The superseded record must name this one in its Superseded by row, see
+adr-index explain invalid-reference.`,
+	},
+	"Superseded by": {
+		Summary: "the records that replace this decision",
+		Doc: `  |Status |Superseded
+  |Superseded by |ADR-12
+
+The named record must list this one in its Supersedes row.`,
+	},
+	"Conflicts with": {
+		Summary: "the records that contradict this decision",
+		Doc: `  |Conflicts with |ADR-4
+
+At most one of the records may be active, see adr-index explain
+conflicting-decision.`,
+	},
+	"Approvers": {
+		Summary: "who has to approve the record, comma separated",
+		Doc: `Names, emails or teams:
+  |Approvers |Jane Doe, bob@example.com, platform
+
+serve lists pending records on the page of their approvers at /me.`,
+	},
+	"Decides": {
+		Summary: "the question the decision answers",
+		Doc: `  |Decides |message-broker
+
+Active records must not decide the same question, the newer one supersedes
+the older one instead.`,
+	},
+	"Scope": {
+		Summary: "where the decision applies, comma separated",
+		Doc: `org or one of department:, repo: and service: with a name:
+  |Scope |service:payments, service:billing
+
+Records without a Scope apply everywhere. Decisions of a scope apply to the
+scopes below it, the parents come from scopes in {{.ConfigFile}}:
+
+  scopes:
+    service:payments: department:finance
+
+adr-index effective -scope service:payments lists what applies to a scope,
+a narrower decision superseding a broader one overrides it there only when
+the broader one keeps its status.`,
+	},
+	"Type": {
+		Summary: "the record type when the file name does not tell it",
+		Doc: `One of: {{.Types}}.
+  |Type |Design Note`,
+	},
+}
+
+// runExplain prints the documentation of a rule reported by validate or of a
+// metadata field, without an argument it lists the topics
+func runExplain(args []string) error {
+	fs := flag.NewFlagSet("explain", flag.ExitOnError)
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		listTopics()
+		return nil
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: explain <rule or field>")
+	}
+
+	name := strings.TrimSpace(fs.Arg(0))
+	topic, key, ok := findTopic(name)
+	if !ok {
+		return fmt.Errorf("no rule or metadata field %q, run adr-index explain to list them", name)
+	}
+
+	doc, err := renderTopic(topic, key)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s: %s\n\n%s\n", name, topic.Summary, doc)
+	if topic.Field != "" && !strings.EqualFold(topic.Field, name) {
+		fmt.Printf("\nSee also adr-index explain %s\n", attributeName(topic.Field))
+	}
+
+	return nil
+}
+
+// ruleAliases map other names of the rules to those of violationKind, such as
+// the ones linters and CI checks elsewhere use
+var ruleAliases = map[string]string{
+	"status-allowed":   "invalid-status",
+	"unknown-status":   "invalid-status",
+	"invalid-reviewed": "invalid-date",
+	"date-format":      "invalid-date",
+	"required-key":     "missing-metadata",
+	"unknown-key":      "unexpected-key",
+	"duplicate-key":    "repeated-key",
+}
+
+// findTopic resolves a rule name or a metadata key in any case or attribute
+// spelling, superseded-by finds Superseded by, missing-<key> and
+// invalid-<key> rules without a topic of their own fall back to the field
+func findTopic(name string) (explainTopic, string, bool) {
+	lower := strings.ToLower(name)
+	if alias, ok := ruleAliases[lower]; ok {
+		lower = alias
+	}
+	if t, ok := ruleTopics[lower]; ok {
+		return t, "", true
+	}
+	for key, t := range fieldTopics {
+		if attributeName(key) == attributeName(name) {
+			return t, key, true
+		}
+	}
+
+	if strings.HasPrefix(lower, "missing-") {
+		key := metadataKeyNamed(strings.TrimPrefix(lower, "missing-"))
+		return ruleTopics["missing-metadata"], key, true
+	}
+	if strings.HasPrefix(lower, "invalid-") {
+		key := metadataKeyNamed(strings.TrimPrefix(lower, "invalid-"))
+		if t, ok := fieldTopics[key]; ok {
+			return t, key, true
+		}
+	}
+
+	return explainTopic{}, "", false
+}
+
+// metadataKeyNamed returns the metadata key or required row spelled name, or
+// name itself in title case
+func metadataKeyNamed(name string) string {
+	keys := append([]string{}, metadataKeys...)
+	for _, t := range cfg.types {
+		keys = append(keys, t.Required...)
+	}
+	for _, k := range keys {
+		if attributeName(k) == attributeName(name) {
+			return k
+		}
+	}
+
+	return strings.Title(strings.Replace(name, "-", " ", -1))
+}
+
+func renderTopic(topic explainTopic, key string) (string, error) {
+	tmpl, err := template.New("explain").Parse(topic.Doc)
+	if err != nil {
+		return "", err
+	}
+
+	b := &strings.Builder{}
+	err = tmpl.Execute(b, explainData{
+		Key:         key,
+		DateFormat:  dateFormat,
+		Today:       time.Now().Format(dateLayout),
+		Statuses:    strings.Join(validStatus, ", "),
+		Pending:     strings.Join(statusesIn(lifecyclePending), ", "),
+		Outcomes:    strings.Join(validOutcomes, ", "),
+		Types:       strings.Join(typeNames(), ", "),
+		Placeholder: placeholderTag,
+		ConfigFile:  configFile,
+	})
+
+	return b.String(), err
+}
+
+func listTopics() {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "Rules, as reported by adr-index validate:")
+	rules := []string{}
+	for name := range ruleTopics {
+		rules = append(rules, name)
+	}
+	sort.Strings(rules)
+	for _, name := range rules {
+		fmt.Fprintf(w, "  %s\t%s\n", name, ruleTopics[name].Summary)
+	}
+
+	fmt.Fprintln(w, "\nMetadata fields:")
+	for _, key := range metadataKeys {
+		if t, ok := fieldTopics[key]; ok {
+			fmt.Fprintf(w, "  %s\t%s\n", attributeName(key), t.Summary)
+		}
+	}
+	w.Flush()
+}
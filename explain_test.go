@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+func TestFindTopic(t *testing.T) {
+	tests := []struct {
+		name  string
+		topic string
+		key   string
+	}{
+		{"invalid-status", "invalid-status", ""},
+		{"INVALID-STATUS", "invalid-status", ""},
+		{"status-allowed", "invalid-status", ""},
+		{"invalid-reviewed", "invalid-date", ""},
+		{"unknown-key", "unexpected-key", ""},
+		{"missing-tags", "missing-metadata", "Tags"},
+		{"superseded-by", "", "Superseded by"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			topic, key, ok := findTopic(test.name)
+			if !ok {
+				t.Fatalf("no topic for %s", test.name)
+			}
+			if key != test.key {
+				t.Errorf("key %q, want %q", key, test.key)
+			}
+			if test.topic != "" && topic.Summary != ruleTopics[test.topic].Summary {
+				t.Errorf("topic %q, want the one of %s", topic.Summary, test.topic)
+			}
+		})
+	}
+
+	if _, _, ok := findTopic("no-such-rule"); ok {
+		t.Error("found a topic for no-such-rule")
+	}
+}
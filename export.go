@@ -0,0 +1,313 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"adr-index/analysis/adrref"
+)
+
+type contextChunk struct {
+	ID      string   `json:"id"`
+	ADR     int      `json:"adr"`
+	Title   string   `json:"title"`
+	Section string   `json:"section"`
+	Chunk   int      `json:"chunk"`
+	Status  string   `json:"status"`
+	Date    string   `json:"date"`
+	Authors []string `json:"authors"`
+	Tags    []string `json:"tags"`
+	Path    string   `json:"path"`
+	Text    string   `json:"text"`
+}
+
+var exporters = map[string]func(adrs []*ADR, opts exportOptions, w io.Writer) error{
+	"catalog":             exportCatalog,
+	"catalog-yaml":        exportCatalogYAML,
+	"context-bundle":      exportContextBundle,
+	"context-bundle-yaml": exportContextBundleYAML,
+	"risk-register":       exportRiskRegister,
+	"risk-register-csv":   exportRiskRegisterCSV,
+}
+
+type exportOptions struct {
+	MaxChunk int
+}
+
+// exportExtensions name the files written by exports of several formats at once
+var exportExtensions = map[string]string{
+	"catalog":             ".json",
+	"catalog-yaml":        ".yaml",
+	"context-bundle":      ".jsonl",
+	"context-bundle-yaml": ".yaml",
+	"risk-register":       ".adoc",
+	"risk-register-csv":   ".csv",
+}
+
+type exportResult struct {
+	Format   string
+	Output   string
+	Duration time.Duration
+	Err      error
+}
+
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	dir := fs.String("dir", settings.Dir, "directory containing ADR files")
+	format := fs.String("format", "", "comma separated export formats: catalog, catalog-yaml, context-bundle, context-bundle-yaml, risk-register, risk-register-csv")
+	output := fs.String("output", "", "file to write a single format to, defaults to stdout")
+	outputDir := fs.String("output-dir", ".", "directory the files of several formats are written to, named after the format")
+	maxChunk := fs.Int("max-chunk", 1500, "maximum characters per context-bundle chunk")
+	manifest := fs.String("manifest", "", "file to write the manifest of the exported files with their hashes to, several formats write "+manifestFile+" to -output-dir unless set")
+	fs.Parse(args)
+
+	formats := parseCommaList(*format)
+	for _, f := range formats {
+		if _, ok := exporters[f]; !ok {
+			return fmt.Errorf("unsupported export format %q", f)
+		}
+	}
+	if len(formats) == 0 {
+		return fmt.Errorf("unsupported export format %q", *format)
+	}
+
+	adrs, err := loadADRs(*dir)
+	if err != nil {
+		return err
+	}
+
+	records := searchADRs(settings.Filter.apply(adrs), "")
+	opts := exportOptions{MaxChunk: *maxChunk}
+
+	if len(formats) == 1 {
+		if *manifest != "" && *output == "" {
+			return fmt.Errorf("-manifest needs the -output file it lists")
+		}
+		err := runExporter(formats[0], records, opts, *output).Err
+		if err != nil || *manifest == "" {
+			return err
+		}
+		return writeManifest(*manifest, records, map[string]string{*output: formats[0]})
+	}
+
+	results := make([]exportResult, len(formats))
+	var wg sync.WaitGroup
+	for i, f := range formats {
+		wg.Add(1)
+		go func(i int, f string) {
+			defer wg.Done()
+			results[i] = runExporter(f, records, opts, filepath.Join(*outputDir, f+exportExtensions[f]))
+		}(i, f)
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+			log.Printf("FAILED %s: %s", r.Format, r.Err)
+			continue
+		}
+		log.Printf("OK     %s -> %s (%s)", r.Format, r.Output, r.Duration.Round(time.Millisecond))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d exports failed, %s is not written", failed, len(results), manifestFile)
+	}
+
+	if *manifest == "" {
+		*manifest = filepath.Join(*outputDir, manifestFile)
+	}
+	artifacts := map[string]string{}
+	for _, r := range results {
+		artifacts[r.Output] = r.Format
+	}
+	err = writeManifest(*manifest, records, artifacts)
+	if err != nil {
+		return err
+	}
+	log.Printf("OK     manifest -> %s", *manifest)
+
+	return nil
+}
+
+// runExporter isolates one export, a failing or panicking exporter leaves the
+// others and any previous output file untouched
+func runExporter(format string, records []*ADR, opts exportOptions, output string) (result exportResult) {
+	result = exportResult{Format: format, Output: output}
+	start := time.Now()
+
+	export := startSpan("export")
+	export.set("adr.format", format)
+	export.set("adr.records", len(records))
+	defer func() {
+		if r := recover(); r != nil {
+			result.Err = fmt.Errorf("exporter panicked: %v", r)
+		}
+		result.Duration = time.Since(start)
+		export.finish(result.Err)
+	}()
+
+	result.Err = writeOutput(output, func(w io.Writer) error {
+		return exporters[format](records, opts, w)
+	})
+
+	return result
+}
+
+// exportCatalog writes the records checked by the adrref analyzer of go vet
+func exportCatalog(adrs []*ADR, opts exportOptions, w io.Writer) error {
+	return encodeValue(w, "json", catalogRecords(adrs))
+}
+
+// exportCatalogYAML writes the catalog with the keys and order of the json one
+func exportCatalogYAML(adrs []*ADR, opts exportOptions, w io.Writer) error {
+	return encodeValue(w, "yaml", catalogRecords(adrs))
+}
+
+func catalogRecords(adrs []*ADR) []adrref.Record {
+	records := []adrref.Record{}
+	for _, a := range adrs {
+		records = append(records, catalogRecord(a))
+	}
+
+	return records
+}
+
+// catalogRecord is the summary of a record shared by the catalog export and the
+// structured output of commands
+func catalogRecord(a *ADR) adrref.Record {
+	return adrref.Record{
+		Label:     typeByName(a.Meta.Type).Label,
+		Index:     a.Meta.Index,
+		Title:     a.Heading,
+		Status:    a.Meta.Status,
+		Lifecycle: lifecycleOf(a.Meta.Status),
+		Path:      a.Meta.Path,
+		URL:       siteLink(a),
+	}
+}
+
+// exportContextBundle writes one JSON object per line, each holding a slice of a
+// single ADR section small enough to embed for retrieval augmented generation
+func exportContextBundle(adrs []*ADR, opts exportOptions, w io.Writer) error {
+	enc := json.NewEncoder(w)
+
+	return contextChunks(adrs, opts, func(c contextChunk) error {
+		return enc.Encode(c)
+	})
+}
+
+// exportContextBundleYAML writes the chunks of the context bundle as a stream
+// of yaml documents
+func exportContextBundleYAML(adrs []*ADR, opts exportOptions, w io.Writer) error {
+	return contextChunks(adrs, opts, func(c contextChunk) error {
+		_, err := io.WriteString(w, "---\n")
+		if err != nil {
+			return err
+		}
+		return encodeValue(w, "yaml", c)
+	})
+}
+
+func contextChunks(adrs []*ADR, opts exportOptions, emit func(contextChunk) error) error {
+	for _, adr := range adrs {
+		body, err := ioutil.ReadFile(adr.Meta.Path)
+		if err != nil {
+			return err
+		}
+
+		// the ordinal tells sections with the same title apart
+		for n, section := range splitSections(string(body)) {
+			if section.Body == "" {
+				continue
+			}
+
+			title := section.Title
+			if title == "" {
+				title = adr.Heading
+			}
+
+			for i, text := range chunkText(section.Body, opts.MaxChunk) {
+				err := emit(contextChunk{
+					ID:      fmt.Sprintf("%s/%d-%s/%d", recordLabel(adr), n, slugify(title), i),
+					ADR:     adr.Meta.Index,
+					Title:   adr.Heading,
+					Section: title,
+					Chunk:   i,
+					Status:  adr.Meta.Status,
+					Date:    adr.Meta.Date.Format("2006-01-02"),
+					Authors: adr.Meta.Authors,
+					Tags:    adr.Meta.Tags,
+					Path:    adr.Meta.Path,
+					Text:    text,
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// chunkText packs whole paragraphs into chunks of at most max characters, only
+// paragraphs that are larger than max on their own are split between words
+func chunkText(text string, max int) []string {
+	chunks := []string{}
+	current := ""
+
+	add := func(part, sep string) {
+		if current != "" && len(current)+len(sep)+len(part) > max {
+			chunks = append(chunks, current)
+			current = ""
+		}
+		if current == "" {
+			current = part
+		} else {
+			current += sep + part
+		}
+	}
+
+	for _, para := range strings.Split(text, "\n\n") {
+		para = strings.TrimSpace(para)
+		if para == "" {
+			continue
+		}
+
+		if len(para) <= max {
+			add(para, "\n\n")
+			continue
+		}
+
+		if current != "" {
+			chunks = append(chunks, current)
+			current = ""
+		}
+		for _, word := range strings.Fields(para) {
+			add(word, " ")
+		}
+	}
+
+	if current != "" {
+		chunks = append(chunks, current)
+	}
+
+	return chunks
+}
+
+var slugRegex = regexp.MustCompile(`[^a-z0-9]+`)
+
+func slugify(s string) string {
+	return strings.Trim(slugRegex.ReplaceAllString(strings.ToLower(s), "-"), "-")
+}
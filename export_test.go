@@ -0,0 +1,47 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestContextChunkIDs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "adr-index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "0003-retry-policy.adoc")
+	body := "= Retry policy\n\n|===\n|Metadata |Value\n|Date |01-02-2024\n|Author |@alice\n|Type |Design Note\n|===\n\n" +
+		"== Option\n\nRetry three times.\n\n== Option\n\nRetry forever.\n\n== Decision\n\nThree times.\n"
+	err = ioutil.WriteFile(file, []byte(body), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := parseADR(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := []string{}
+	err = contextChunks([]*ADR{a}, exportOptions{MaxChunk: 1500}, func(c contextChunk) error {
+		ids = append(ids, c.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"Note-3/1-option/0", "Note-3/2-option/0", "Note-3/3-decision/0"}
+	if len(ids) != len(want) {
+		t.Fatalf("ids %v, want %v", ids, want)
+	}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Errorf("ids %v, want %v", ids, want)
+			break
+		}
+	}
+}
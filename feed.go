@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+)
+
+// feedFile is the Atom feed of the records, the site publishes it next to the
+// index
+const feedFile = "adrs.xml"
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Links   []atomLink  `xml:"link"`
+	Updated string      `xml:"updated"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Links      []atomLink     `xml:"link"`
+	Published  string         `xml:"published,omitempty"`
+	Updated    string         `xml:"updated"`
+	Authors    []atomAuthor   `xml:"author"`
+	Categories []atomCategory `xml:"category"`
+	Summary    string         `xml:"summary,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// runFeed writes the Atom feed of the most recently added or changed records
+func runFeed(args []string) error {
+	fs := flag.NewFlagSet("feed", flag.ExitOnError)
+	dir := fs.String("dir", settings.Dir, "directory containing ADR files")
+	output := fs.String("output", feedFile, "file to write the feed to, - for stdout")
+	title := fs.String("title", "Architecture Decision Records", "title of the feed")
+	limit := fs.Int("limit", 20, "number of records in the feed")
+	dates := fs.String("dates", "git", "when records changed, git for their last commit or metadata for Date and Reviewed")
+	fs.Parse(args)
+
+	if *dates != "git" && *dates != "metadata" {
+		return fmt.Errorf("invalid -dates %q, expected git or metadata", *dates)
+	}
+	adrs, err := loadADRs(*dir)
+	if err != nil {
+		return err
+	}
+
+	if *output == "-" {
+		*output = ""
+	}
+	return writeOutput(*output, func(w io.Writer) error {
+		return writeFeed(w, searchADRs(settings.Filter.apply(adrs), ""), *title, *limit, *dates == "git")
+	})
+}
+
+// writeFeed writes the limit records changed last as an Atom feed, entries
+// link to the pages below siteURL when one is configured
+func writeFeed(w io.Writer, adrs []*ADR, title string, limit int, useGit bool) error {
+	updated := map[*ADR]time.Time{}
+	for _, a := range adrs {
+		updated[a] = recordUpdated(a, useGit)
+	}
+	recent := append([]*ADR{}, adrs...)
+	sort.SliceStable(recent, func(i, j int) bool {
+		return updated[recent[i]].After(updated[recent[j]])
+	})
+	if limit > 0 && len(recent) > limit {
+		recent = recent[:limit]
+	}
+
+	feed := atomFeed{Title: title, ID: "urn:adr-index:feed", Updated: feedTime(time.Now())}
+	if cfg.SiteURL != "" {
+		site := strings.TrimSuffix(cfg.SiteURL, "/")
+		feed.ID = site + "/"
+		feed.Links = []atomLink{{Href: site + "/"}, {Href: site + "/" + feedFile, Rel: "self"}}
+	}
+	if len(recent) > 0 {
+		feed.Updated = feedTime(updated[recent[0]])
+	}
+
+	for _, a := range recent {
+		e := atomEntry{
+			Title:   recordLabel(a) + " " + a.Heading,
+			ID:      "urn:adr-index:" + recordLabel(a),
+			Updated: feedTime(updated[a]),
+			Summary: feedSummary(a),
+		}
+		if link := siteLink(a); link != "" {
+			e.ID = link
+			e.Links = []atomLink{{Href: link}}
+		}
+		if !a.Meta.Date.IsZero() {
+			e.Published = feedTime(a.Meta.Date)
+		}
+		for _, author := range a.Meta.Authors {
+			e.Authors = append(e.Authors, atomAuthor{Name: author})
+		}
+		// a feed without an author of its own needs one on every entry
+		if len(e.Authors) == 0 {
+			e.Authors = []atomAuthor{{Name: title}}
+		}
+		if a.Meta.Status != "" {
+			e.Categories = append(e.Categories, atomCategory{Term: a.Meta.Status})
+		}
+		for _, t := range a.Meta.Tags {
+			e.Categories = append(e.Categories, atomCategory{Term: t})
+		}
+		feed.Entries = append(feed.Entries, e)
+	}
+
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	err = enc.Encode(feed)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// recordUpdated is when a last changed, its last commit with useGit and the
+// later of its Date and Reviewed otherwise or outside git
+func recordUpdated(a *ADR, useGit bool) time.Time {
+	t := a.Meta.Date
+	if a.Meta.Reviewed.After(t) {
+		t = a.Meta.Reviewed
+	}
+	if !useGit {
+		return t
+	}
+	if changed := lastChanged(a.Meta.Path); !changed.IsZero() {
+		return changed
+	}
+
+	return t
+}
+
+func feedTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// feedSummary is the status and the Decision section of a, or its first
+// section with text when it has no Decision
+func feedSummary(a *ADR) string {
+	body, err := ioutil.ReadFile(a.Meta.Path)
+	if err != nil {
+		return a.Meta.Status
+	}
+
+	text := ""
+	for _, s := range splitSections(string(body)) {
+		if text == "" && s.Body != "" {
+			text = s.Body
+		}
+		if strings.EqualFold(s.Title, "Decision") && s.Body != "" {
+			text = s.Body
+			break
+		}
+	}
+	text = strings.Join(strings.Fields(text), " ")
+	if len(text) > 500 {
+		cut := strings.LastIndex(text[:500], " ")
+		if cut < 0 {
+			cut = 500
+		}
+		text = text[:cut] + " ..."
+	}
+	if a.Meta.Status == "" {
+		return text
+	}
+
+	return strings.TrimSpace(a.Meta.Status + ". " + text)
+}
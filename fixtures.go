@@ -0,0 +1,309 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const fixtureManifest = "corpus.yaml"
+
+// fixture is the expected outcome of parsing one generated record, the
+// manifest of a corpus lists one per file
+type fixture struct {
+	File   string   `yaml:"file"`
+	Kind   string   `yaml:"kind"`
+	Valid  bool     `yaml:"valid"`
+	Status string   `yaml:"status,omitempty"`
+	Tags   []string `yaml:"tags,omitempty"`
+}
+
+type fixtureCorpus struct {
+	Seed    int64     `yaml:"seed"`
+	Records []fixture `yaml:"records"`
+}
+
+// fixtureKinds are the edge cases mixed into a corpus, the invalid ones must
+// be rejected by the parser in its default mode
+var fixtureKinds = []struct {
+	Kind  string
+	Valid bool
+}{
+	{"markdown", true},
+	{"front-matter", true},
+	{"crlf", true},
+	{"unicode", true},
+	{"long-title", true},
+	{"missing-status", false},
+	{"missing-tags", false},
+	{"bad-date", false},
+	{"unknown-status", false},
+}
+
+var (
+	fixtureVerbs    = []string{"Use", "Adopt", "Replace", "Introduce", "Retire", "Standardise on", "Move to", "Evaluate"}
+	fixtureSubjects = []string{"PostgreSQL", "Kafka", "gRPC", "OpenTelemetry", "Terraform", "a service mesh", "feature flags", "event sourcing", "JWT sessions", "Redis caching", "GraphQL", "blue green deployments"}
+	fixturePurposes = []string{"for event streaming", "as the primary store", "for service calls", "for tracing", "for infrastructure", "in the API gateway", "for rollouts", "for billing", "for authentication", ""}
+	fixtureTags     = []string{"messaging", "storage", "security", "infra", "api", "observability", "frontend", "data", "process", "cost"}
+	fixtureAuthors  = []string{"@alice", "@bob", "@carol", "@dave", "@erin", "@frank"}
+	// fixtureStatuses repeat statuses by their weight, Superseded is only
+	// given to records a later one supersedes
+	fixtureStatuses = []string{"Proposed", "Proposed", "Proposed", "Approved", "Approved", "Approved", "Approved", "Approved", "Approved",
+		"Partially Implemented", "Partially Implemented", "Implemented", "Implemented", "Implemented", "Implemented", "Implemented", "Rejected", "Rejected", "Deprecated"}
+)
+
+// genRecord is a synthetic ADR before it is rendered in the format of its kind
+type genRecord struct {
+	Index        int
+	Kind         string
+	Valid        bool
+	Title        string
+	Date         time.Time
+	Authors      []string
+	Status       string
+	Tags         []string
+	Impact       string
+	Supersedes   int
+	SupersededBy int
+	Mentions     []int
+}
+
+// runGenFixtures writes a synthetic ADR corpus with its manifest, or with
+// -check parses a corpus and compares every record with the manifest
+func runGenFixtures(args []string) error {
+	fs := flag.NewFlagSet("gen-fixtures", flag.ExitOnError)
+	dir := fs.String("dir", "testdata/corpus", "directory to write the corpus to, it must be empty or not exist")
+	count := fs.Int("count", 100, "number of records to generate")
+	seed := fs.Int64("seed", 1, "seed of the generator, the same seed and count give the same corpus")
+	edgeCases := fs.Float64("edge-cases", 0.15, "share of records that are edge cases such as Markdown, CRLF or invalid metadata")
+	check := fs.Bool("check", false, "parse the corpus in -dir and report records deviating from its manifest")
+	fs.Parse(args)
+
+	if *check {
+		return checkFixtures(*dir)
+	}
+
+	entries, err := ioutil.ReadDir(*dir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(entries) > 0 {
+		return fmt.Errorf("%s is not empty, remove it or pick another -dir", *dir)
+	}
+	err = os.MkdirAll(*dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	corpus := fixtureCorpus{Seed: *seed, Records: []fixture{}}
+	for _, g := range generateRecords(rand.New(rand.NewSource(*seed)), *count, *edgeCases) {
+		file, content := g.render()
+		err = ioutil.WriteFile(path.Join(*dir, file), []byte(content), 0644)
+		if err != nil {
+			return err
+		}
+
+		f := fixture{File: file, Kind: g.Kind, Valid: g.Valid}
+		if g.Valid {
+			f.Status, f.Tags = g.Status, g.Tags
+		}
+		corpus.Records = append(corpus.Records, f)
+	}
+
+	body, err := yaml.Marshal(corpus)
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(path.Join(*dir, fixtureManifest), body, 0644)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Generated %d records in %s\n", len(corpus.Records), *dir)
+
+	return nil
+}
+
+func generateRecords(r *rand.Rand, count int, edgeCases float64) []*genRecord {
+	pick := func(list []string) string {
+		return list[r.Intn(len(list))]
+	}
+	pickSome := func(list []string, max int) []string {
+		picked := []string{}
+		for _, i := range r.Perm(len(list))[:1+r.Intn(max)] {
+			picked = append(picked, list[i])
+		}
+		return picked
+	}
+
+	records := []*genRecord{}
+	date := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 1; i <= count; i++ {
+		date = date.AddDate(0, 0, 1+r.Intn(7))
+		g := &genRecord{
+			Index:   i,
+			Kind:    "plain",
+			Valid:   true,
+			Title:   strings.TrimSpace(fmt.Sprintf("%s %s %s", pick(fixtureVerbs), pick(fixtureSubjects), pick(fixturePurposes))),
+			Date:    date,
+			Authors: pickSome(fixtureAuthors, 2),
+			Status:  pick(fixtureStatuses),
+			Tags:    pickSome(fixtureTags, 3),
+		}
+		if r.Float64() < 0.2 {
+			g.Impact = pick([]string{"High", "Medium", "Low"})
+		}
+		if r.Float64() < edgeCases {
+			k := fixtureKinds[r.Intn(len(fixtureKinds))]
+			g.Kind, g.Valid = k.Kind, k.Valid
+		}
+		switch g.Kind {
+		case "unicode":
+			g.Title = fmt.Sprintf("Évaluer %s für Zürich — 日本語 ✓", pick(fixtureSubjects))
+		case "long-title":
+			g.Title = strings.TrimSpace(strings.Repeat(g.Title+" and ", 4) + pick(fixtureSubjects))
+		}
+
+		if i > 1 && r.Float64() < 0.3 {
+			g.Mentions = append(g.Mentions, 1+r.Intn(i-1))
+		}
+		// a valid active decision may supersede an earlier valid one
+		if i > 1 && g.Valid && r.Float64() < 0.1 && lifecycleOf(g.Status) == lifecycleActive {
+			older := records[r.Intn(len(records))]
+			if older.Valid && older.Status != "Superseded" {
+				older.Status, older.SupersededBy = "Superseded", i
+				g.Supersedes = older.Index
+			}
+		}
+		records = append(records, g)
+	}
+
+	return records
+}
+
+// rows are the metadata of the record in table order, the invalid kinds break
+// their row here
+func (g *genRecord) rows() []metaRow {
+	rows := []metaRow{
+		{Key: "Date", Value: g.Date.Format(dateLayout)},
+		{Key: "Author", Value: strings.Join(g.Authors, ", ")},
+		{Key: "Status", Value: g.Status},
+		{Key: "Tags", Value: strings.Join(g.Tags, ", ")},
+	}
+	if g.Impact != "" {
+		rows = append(rows, metaRow{Key: "Impact", Value: g.Impact})
+	}
+	if g.Supersedes > 0 {
+		rows = append(rows, metaRow{Key: "Supersedes", Value: fmt.Sprintf("ADR-%d", g.Supersedes)})
+	}
+	if g.SupersededBy > 0 {
+		rows = append(rows, metaRow{Key: "Superseded by", Value: fmt.Sprintf("ADR-%d", g.SupersededBy)})
+	}
+
+	kept := []metaRow{}
+	for _, row := range rows {
+		switch {
+		case g.Kind == "missing-status" && row.Key == "Status", g.Kind == "missing-tags" && row.Key == "Tags":
+			continue
+		case g.Kind == "bad-date" && row.Key == "Date":
+			row.Value = g.Date.Format("2006") + "/13/45"
+		case g.Kind == "unknown-status" && row.Key == "Status":
+			row.Value = "Maybe Later"
+		}
+		kept = append(kept, row)
+	}
+
+	return kept
+}
+
+// render returns the file name and content of the record in its kind's format
+func (g *genRecord) render() (string, string) {
+	b := &strings.Builder{}
+	heading := "=="
+	ext := ".adoc"
+
+	switch g.Kind {
+	case "markdown":
+		heading, ext = "##", ".md"
+		fmt.Fprintf(b, "# %s\n\n| Metadata | Value |\n|----------|-------|\n", g.Title)
+		for _, row := range g.rows() {
+			fmt.Fprintf(b, "| %s | %s |\n", row.Key, row.Value)
+		}
+	case "front-matter":
+		b.WriteString("---\n")
+		for _, row := range g.rows() {
+			b.WriteString(frontMatterLine(row.Key, row.Value) + "\n")
+		}
+		fmt.Fprintf(b, "---\n= %s\n", g.Title)
+	default:
+		fmt.Fprintf(b, "= %s\n\n|===\n|Metadata |Value\n\n", g.Title)
+		for _, row := range g.rows() {
+			fmt.Fprintf(b, "|%s |%s\n", row.Key, row.Value)
+		}
+		b.WriteString("|===\n")
+	}
+
+	fmt.Fprintf(b, "\n%s Context and Problem Statement\n\nThe teams working on %s need a shared answer, the current setup does not scale.\n", heading, strings.Join(g.Tags, " and "))
+	for _, m := range g.Mentions {
+		fmt.Fprintf(b, "It builds on ADR-%d.\n", m)
+	}
+	fmt.Fprintf(b, "\n%s Decision\n\n%s.\n", heading, g.Title)
+	if g.Supersedes > 0 {
+		fmt.Fprintf(b, "This replaces ADR-%d.\n", g.Supersedes)
+	}
+	fmt.Fprintf(b, "\n%s Consequences\n\nThe change is rolled out gradually and reviewed after a quarter.\n", heading)
+
+	content := b.String()
+	if g.Kind == "crlf" {
+		content = strings.Replace(content, "\n", "\r\n", -1)
+	}
+
+	return fmt.Sprintf("%04d-%s%s", g.Index, slugify(g.Title), ext), content
+}
+
+// checkFixtures parses every record of a corpus and compares the outcome with
+// the manifest, the corpus is generated for the default statuses and dates
+func checkFixtures(dir string) error {
+	body, err := ioutil.ReadFile(path.Join(dir, fixtureManifest))
+	if err != nil {
+		return err
+	}
+	var corpus fixtureCorpus
+	err = yaml.Unmarshal(body, &corpus)
+	if err != nil {
+		return fmt.Errorf("invalid %s in %s: %s", fixtureManifest, dir, err)
+	}
+
+	start := time.Now()
+	problems := []string{}
+	for _, f := range corpus.Records {
+		a, err := parseADR(path.Join(dir, f.File))
+		switch {
+		case err == nil && !f.Valid:
+			problems = append(problems, fmt.Sprintf("%s: %s record parsed without an error", f.File, f.Kind))
+		case err != nil && f.Valid:
+			problems = append(problems, fmt.Sprintf("%s: %s record failed to parse: %s", f.File, f.Kind, err))
+		case err == nil && a.Meta.Status != f.Status:
+			problems = append(problems, fmt.Sprintf("%s: status %q, expected %q", f.File, a.Meta.Status, f.Status))
+		case err == nil && strings.Join(a.Meta.Tags, ",") != strings.Join(f.Tags, ","):
+			problems = append(problems, fmt.Sprintf("%s: tags %s, expected %s", f.File, strings.Join(a.Meta.Tags, ", "), strings.Join(f.Tags, ", ")))
+		}
+	}
+	elapsed := time.Since(start)
+
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+	fmt.Printf("Checked %d records of %s in %s\n", len(corpus.Records), dir, elapsed.Round(time.Millisecond))
+	if len(problems) > 0 {
+		return fmt.Errorf("%d of %d records in %s deviate from %s", len(problems), len(corpus.Records), dir, fixtureManifest)
+	}
+
+	return nil
+}
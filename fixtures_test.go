@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"path"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of the tests")
+
+const (
+	corpusDir    = "testdata/corpus"
+	corpusGolden = "testdata/corpus.golden"
+)
+
+// corpusSummary lists what the parser makes of every record of dir, one line
+// per record followed by the errors of the catalog
+func corpusSummary(dir string) (string, error) {
+	adrs, errs, err := scanADRs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	b := &bytes.Buffer{}
+	for _, a := range adrs {
+		fmt.Fprintf(b, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", path.Base(a.Meta.Path), recordLabel(a), a.Meta.Date.Format("2006-01-02"),
+			a.Meta.Status, strings.Join(a.Meta.Tags, ","), strings.Join(a.Meta.Authors, ","), a.Heading)
+		if len(a.Meta.Supersedes) > 0 {
+			fmt.Fprintf(b, "\tsupersedes %s\n", strings.Join(a.Meta.Supersedes, ","))
+		}
+		if len(a.Meta.SupersededBy) > 0 {
+			fmt.Fprintf(b, "\tsuperseded by %s\n", strings.Join(a.Meta.SupersededBy, ","))
+		}
+	}
+	for _, err := range errs {
+		fmt.Fprintf(b, "error: %s\n", err)
+	}
+
+	return b.String(), nil
+}
+
+func TestCorpus(t *testing.T) {
+	got, err := corpusSummary(corpusDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *update {
+		err = ioutil.WriteFile(corpusGolden, []byte(got), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := ioutil.ReadFile(corpusGolden)
+	if err != nil {
+		t.Fatalf("%s, run go test -run TestCorpus -update to create it", err)
+	}
+
+	if got != string(want) {
+		gotLines, wantLines := strings.Split(got, "\n"), strings.Split(string(want), "\n")
+		for i := 0; i < len(gotLines) || i < len(wantLines); i++ {
+			var g, w string
+			if i < len(gotLines) {
+				g = gotLines[i]
+			}
+			if i < len(wantLines) {
+				w = wantLines[i]
+			}
+			if g != w {
+				t.Fatalf("%s differs from %s on line %d\ngot:  %s\nwant: %s\nrun go test -run TestCorpus -update if the change is intended", corpusDir, corpusGolden, i+1, g, w)
+			}
+		}
+	}
+}
+
+func TestCorpusManifest(t *testing.T) {
+	err := checkFixtures(corpusDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestGenerateCorpus regenerates the corpus with the defaults of gen-fixtures,
+// the same seed and count must give the same files
+func TestGenerateCorpus(t *testing.T) {
+	records := generateRecords(rand.New(rand.NewSource(1)), 100, 0.15)
+	for _, g := range records {
+		file, content := g.render()
+		body, err := ioutil.ReadFile(path.Join(corpusDir, file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(body) != content {
+			t.Errorf("%s differs from the generated record", file)
+		}
+	}
+}
+
+// corpusFiles reads the records of the corpus so the benchmarks parse from
+// memory
+func corpusFiles(b *testing.B) map[string][]byte {
+	files, err := ioutil.ReadDir(corpusDir)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	bodies := map[string][]byte{}
+	for _, f := range files {
+		if !isRecordFile(f.Name()) {
+			continue
+		}
+		file := path.Join(corpusDir, f.Name())
+		body, err := ioutil.ReadFile(file)
+		if err != nil {
+			b.Fatal(err)
+		}
+		bodies[file] = body
+	}
+
+	return bodies
+}
+
+func BenchmarkParseCorpus(b *testing.B) {
+	bodies := corpusFiles(b)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for file, body := range bodies {
+			parseADRContent(file, body)
+		}
+	}
+}
+
+func BenchmarkScanCorpus(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, _, err := scanADRs(corpusDir)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRenderCorpus(b *testing.B) {
+	adrs, _, err := scanADRs(corpusDir)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		err := renderIndexes(adrs, settings.Template, ioutil.Discard)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGenerateCorpus(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		for _, g := range generateRecords(rand.New(rand.NewSource(int64(i))), 500, 0.1) {
+			g.render()
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// outputFormat is set with --format, json and yaml wrap the outcome of a
+// command in an envelope instead of printing text, sarif writes its problems
+// as a SARIF log and github adds them to the text as workflow annotations
+var outputFormat = "text"
+
+// interactiveCommands talk to a terminal or serve requests, their output is
+// never wrapped
+var interactiveCommands = map[string]bool{"serve": true, "mcp": true, "bot": true, "edit": true, "open": true}
+
+// envelope is the machine readable outcome of a command, Results holds what the
+// command reported with setResults or otherwise the lines it printed
+type envelope struct {
+	Command   string          `json:"command"`
+	Timestamp time.Time       `json:"timestamp"`
+	Results   interface{}     `json:"results"`
+	Errors    []InvalidRecord `json:"errors"`
+}
+
+// commandResults is what the running command reported with setResults
+var commandResults interface{}
+
+func setOutputFormat(format string) error {
+	switch format {
+	case "text", "json", "yaml", "sarif", "github":
+		outputFormat = format
+		return nil
+	}
+
+	return fmt.Errorf("invalid --format %q, expected text, json, yaml, sarif or github", format)
+}
+
+// structuredOutput reports whether the command should leave its results to
+// the envelope instead of printing them
+func structuredOutput() bool {
+	return outputFormat != "text"
+}
+
+// setResults hands the structured results of a command to the envelope, text
+// output is still printed by the command and dropped
+func setResults(v interface{}) {
+	commandResults = v
+}
+
+// runEnveloped runs cmd with stdout captured and writes the envelope instead,
+// the error of the command is returned after the envelope is written, github
+// annotations follow the text of the command instead
+func runEnveloped(name string, cmd func() error) error {
+	if outputFormat == "github" {
+		err := cmd()
+		errs := []InvalidRecord{}
+		if err != nil {
+			errs = invalidRecords([]error{err})
+		}
+		writeAnnotations(os.Stdout, annotations(commandResults, errs))
+		return err
+	}
+	printed, err := captureStdout(cmd)
+
+	e := envelope{
+		Command:   name,
+		Timestamp: time.Now().UTC().Truncate(time.Second),
+		Results:   commandResults,
+		Errors:    []InvalidRecord{},
+	}
+	if e.Results == nil {
+		lines := []string{}
+		for _, l := range strings.Split(strings.TrimRight(printed, "\n"), "\n") {
+			if l != "" {
+				lines = append(lines, l)
+			}
+		}
+		e.Results = lines
+	}
+	if err != nil {
+		e.Errors = append(e.Errors, invalidRecords([]error{err})...)
+	}
+
+	if outputFormat == "sarif" {
+		if werr := writeSARIF(os.Stdout, annotations(e.Results, e.Errors)); werr != nil {
+			return werr
+		}
+		return err
+	}
+	if werr := encodeValue(os.Stdout, outputFormat, e); werr != nil {
+		return werr
+	}
+
+	return err
+}
+
+// encodeValue writes v as indented json or as yaml, yaml is converted from the
+// json so both use the same keys in the same order
+func encodeValue(w io.Writer, format string, v interface{}) error {
+	if format != "yaml" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var doc yaml.Node
+	err = yaml.Unmarshal(body, &doc)
+	if err != nil {
+		return err
+	}
+	plainStyle(&doc)
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	err = enc.Encode(&doc)
+	if err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
+
+// plainStyle drops the flow style and quoting yaml keeps from the json input
+func plainStyle(n *yaml.Node) {
+	n.Style &^= yaml.FlowStyle | yaml.DoubleQuotedStyle
+	for _, c := range n.Content {
+		plainStyle(c)
+	}
+}
+
+func captureStdout(run func() error) (string, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return "", err
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+	captured := make(chan string)
+	go func() {
+		body, _ := ioutil.ReadAll(r)
+		captured <- string(body)
+	}()
+
+	err = run()
+	os.Stdout = stdout
+	w.Close()
+
+	return <-captured, err
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Freshness estimates how likely a decision still reflects reality, it is
+// derived from the age since the decision or its last review, the status, the
+// outcome review and newer records in the same area
+type Freshness struct {
+	// Score runs from 0, no longer current, to 100
+	Score int
+	// Level is fresh, aging, stale or retired
+	Level   string
+	Reasons []string
+}
+
+func (f Freshness) String() string {
+	if len(f.Reasons) == 0 {
+		return fmt.Sprintf("%s (%d)", f.Level, f.Score)
+	}
+
+	return fmt.Sprintf("%s (%d): %s", f.Level, f.Score, strings.Join(f.Reasons, ", "))
+}
+
+// assessFreshness scores a against the rest of the catalog as of now, newer
+// records sharing a tag press on the decision, proposals more than others
+func assessFreshness(a *ADR, adrs []*ADR, now time.Time) Freshness {
+	if lifecycleOf(a.Meta.Status) == lifecycleTerminal {
+		return Freshness{Score: 0, Level: "retired", Reasons: []string{a.Meta.Status}}
+	}
+
+	f := Freshness{Score: 100}
+	penalize := func(points int, reason string) {
+		f.Score -= points
+		f.Reasons = append(f.Reasons, reason)
+	}
+
+	since := a.Meta.Date
+	what := "decided"
+	if a.Meta.Reviewed.After(since) {
+		since = a.Meta.Reviewed
+		what = "reviewed"
+	}
+	if !since.IsZero() {
+		years := now.Sub(since).Hours() / 24 / 365
+		if points := int(years * 15); points > 0 {
+			if points > 60 {
+				points = 60
+			}
+			penalize(points, fmt.Sprintf("%s %s", what, since.Format("2006-01-02")))
+		}
+	}
+
+	if lifecycleOf(a.Meta.Status) == lifecyclePending && now.Sub(a.Meta.Date) > 90*24*time.Hour {
+		penalize(15, "still proposed")
+	}
+
+	switch a.Meta.Outcome {
+	case "Refuted":
+		penalize(40, "outcome refuted")
+	case "Mixed":
+		penalize(15, "mixed outcome")
+	}
+
+	tags := map[string]bool{}
+	for _, t := range a.Meta.Tags {
+		tags[strings.ToLower(t)] = true
+	}
+	pressure, newer := 0, 0
+	for _, other := range adrs {
+		if other == a || !other.Meta.Date.After(since) || lifecycleOf(other.Meta.Status) == lifecycleTerminal {
+			continue
+		}
+		for _, t := range other.Meta.Tags {
+			if tags[strings.ToLower(t)] {
+				newer++
+				pressure += 5
+				if lifecycleOf(other.Meta.Status) == lifecyclePending {
+					pressure += 5
+				}
+				break
+			}
+		}
+	}
+	if pressure > 0 {
+		if pressure > 30 {
+			pressure = 30
+		}
+		penalize(pressure, fmt.Sprintf("%d newer records share its tags", newer))
+	}
+
+	if f.Score < 0 {
+		f.Score = 0
+	}
+	switch {
+	case f.Score >= 70:
+		f.Level = "fresh"
+	case f.Score >= 40:
+		f.Level = "aging"
+	default:
+		f.Level = "stale"
+	}
+
+	return f
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+func git(args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command("git", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %s: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+
+	return strings.TrimRight(stdout.String(), "\n"), nil
+}
+
+type gitRemote struct {
+	Host  string
+	Owner string
+	Repo  string
+}
+
+func (r gitRemote) Path() string {
+	return r.Owner + "/" + r.Repo
+}
+
+var remoteRegex = regexp.MustCompile(`^(?:[a-z+]+://)?(?:[^@/]+@)?([^:/]+)(?::\d+)?[:/](.+?)(?:\.git)?/?$`)
+
+// parseRemote understands both scp style (git@host:owner/repo.git) and URL style remotes,
+// the owner may contain slashes for GitLab sub groups
+func parseRemote(url string) (gitRemote, error) {
+	m := remoteRegex.FindStringSubmatch(strings.TrimSpace(url))
+	if m == nil {
+		return gitRemote{}, fmt.Errorf("unsupported remote url %q", url)
+	}
+
+	idx := strings.LastIndex(m[2], "/")
+	if idx < 0 {
+		return gitRemote{}, fmt.Errorf("unsupported remote url %q", url)
+	}
+
+	return gitRemote{Host: m[1], Owner: m[2][:idx], Repo: m[2][idx+1:]}, nil
+}
+
+func remoteFor(name string) (gitRemote, error) {
+	url, err := git("remote", "get-url", name)
+	if err != nil {
+		return gitRemote{}, err
+	}
+
+	return parseRemote(url)
+}
+
+func defaultBranch(remote string) string {
+	ref, err := git("symbolic-ref", "--short", "refs/remotes/"+remote+"/HEAD")
+	if err != nil {
+		return "main"
+	}
+
+	return strings.TrimPrefix(ref, remote+"/")
+}
+
+func gitAuthor() string {
+	for _, key := range []string{"github.user", "gitlab.user", "user.name"} {
+		v, err := git("config", key)
+		if err == nil && v != "" {
+			if key == "user.name" {
+				return v
+			}
+			return "@" + v
+		}
+	}
+
+	return "@<user>"
+}
+
+// resolveRevision accepts a git revision or a date, dates resolve to the last
+// commit of HEAD made before the end of that day
+func resolveRevision(at string) (string, error) {
+	for _, layout := range []string{"2006-01-02", time.RFC3339} {
+		t, err := time.Parse(layout, at)
+		if err != nil {
+			continue
+		}
+		if layout == "2006-01-02" {
+			t = t.Add(24 * time.Hour)
+		}
+
+		sha, err := git("rev-list", "-1", "--before="+t.Format(time.RFC3339), "HEAD")
+		if err != nil {
+			return "", err
+		}
+		if sha == "" {
+			return "", fmt.Errorf("no commit before %s", at)
+		}
+		return sha, nil
+	}
+
+	return git("rev-parse", "--verify", at+"^{commit}")
+}
+
+// scanADRsAt is scanADRs reading the directory from a git revision instead of
+// the working tree
+func scanADRsAt(rev string, dir string) ([]*ADR, []error, error) {
+	out, err := git("ls-tree", "--name-only", rev, "--", strings.TrimSuffix(dir, "/")+"/")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	adrs := []*ADR{}
+	errs := []error{}
+	for _, file := range strings.Split(out, "\n") {
+		if !isRecordFile(file) {
+			continue
+		}
+
+		adr, err := parseADRAt(rev, file)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		adrs = append(adrs, adr)
+	}
+
+	err = verifyUniqueIndexes(adrs)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	errs = append(errs, verifySupersessions(adrs)...)
+	errs = append(errs, verifyConflicts(adrs)...)
+
+	return adrs, errs, nil
+}
@@ -1,3 +1,9 @@
 module adr-index
 
 go 1.14
+
+require (
+	github.com/BurntSushi/toml v0.4.1
+	golang.org/x/tools v0.1.0
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// graphFillColors fill the nodes of the graph by status, other statuses take
+// the color of their lifecycle
+var graphFillColors = map[string]string{
+	"Proposed":              "#fff3bf",
+	"Approved":              "#d0ebff",
+	"Partially Implemented": "#d3f9d8",
+	"Implemented":           "#b2f2bb",
+	"Superseded":            "#e9ecef",
+	"Deprecated":            "#e9ecef",
+	"Rejected":              "#ffc9c9",
+}
+
+var graphLifecycleColors = map[string]string{
+	lifecyclePending:  "#fff3bf",
+	lifecycleActive:   "#d0ebff",
+	lifecycleTerminal: "#e9ecef",
+}
+
+func graphFillColor(status string) string {
+	if c, ok := graphFillColors[status]; ok {
+		return c
+	}
+	if c, ok := graphLifecycleColors[lifecycleOf(status)]; ok {
+		return c
+	}
+
+	return "#ffffff"
+}
+
+// runGraph writes the relationship graph of the records, supersessions are
+// solid edges, conflicts red ones and mentions of other records dashed ones
+func runGraph(args []string) error {
+	fs := flag.NewFlagSet("graph", flag.ExitOnError)
+	dir := fs.String("dir", settings.Dir, "directory containing ADR files")
+	format := fs.String("format", "dot", "graph format, dot for Graphviz or mermaid")
+	output := fs.String("output", "", "file to write the graph to, defaults to stdout")
+	fs.Parse(args)
+
+	var render func(w io.Writer, c *Catalog) error
+	switch *format {
+	case "dot":
+		render = renderDOT
+	case "mermaid":
+		render = renderMermaid
+	default:
+		return fmt.Errorf("invalid -format %q, expected dot or mermaid", *format)
+	}
+
+	adrs, err := loadADRs(*dir)
+	if err != nil {
+		return err
+	}
+	c := NewCatalog(adrs)
+
+	return writeOutput(*output, func(w io.Writer) error {
+		return render(w, c)
+	})
+}
+
+// renderDOT writes the catalog as a Graphviz digraph, e.g. for
+// adr-index graph | dot -Tsvg > decisions.svg
+func renderDOT(w io.Writer, c *Catalog) error {
+	b := &strings.Builder{}
+	b.WriteString("digraph decisions {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, style=\"rounded,filled\", fontname=\"Helvetica\"];\n")
+	b.WriteString("  edge [fontname=\"Helvetica\", fontsize=10];\n\n")
+
+	for _, a := range c.ADRs {
+		label := recordLabel(a) + "\n" + a.Heading
+		if a.Meta.Status != "" {
+			label += "\n" + a.Meta.Status
+		}
+		fmt.Fprintf(b, "  %s [label=%s, fillcolor=%s, tooltip=%s];\n",
+			dotQuote(recordLabel(a)), dotQuote(label), dotQuote(graphFillColor(a.Meta.Status)), dotQuote(a.Meta.Path))
+	}
+	if len(c.ADRs) > 0 {
+		b.WriteString("\n")
+	}
+
+	for _, e := range c.Graph() {
+		attrs := "label=\"supersedes\""
+		switch e.Kind {
+		case edgeConflicts:
+			attrs = "color=\"#e03131\", dir=both, label=\"conflicts with\""
+		case edgeRelates:
+			attrs = "style=dashed, label=\"relates to\""
+		}
+		fmt.Fprintf(b, "  %s -> %s [%s];\n", dotQuote(recordLabel(e.From)), dotQuote(recordLabel(e.To)), attrs)
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// dotQuote makes s a DOT string, newlines become \n line breaks of the label
+func dotQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\r", "", "\n", `\n`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// renderMermaid writes the catalog as a Mermaid flowchart
+func renderMermaid(w io.Writer, c *Catalog) error {
+	_, err := io.WriteString(w, mermaidGraph(c))
+	return err
+}
+
+// mermaidGraph is the graph TD diagram of the catalog, the index templates
+// embed it with the mermaid function
+func mermaidGraph(c *Catalog) string {
+	b := &strings.Builder{}
+	b.WriteString("graph TD\n")
+	for _, a := range c.ADRs {
+		label := mermaidText(recordLabel(a) + ": " + a.Heading)
+		if a.Meta.Status != "" {
+			label += "<br/>" + mermaidText(a.Meta.Status)
+		}
+		fmt.Fprintf(b, "  %s[\"%s\"]\n", mermaidID(a), label)
+	}
+	for _, e := range c.Graph() {
+		arrow := "-->|supersedes|"
+		switch e.Kind {
+		case edgeConflicts:
+			arrow = "x--x|conflicts with|"
+		case edgeRelates:
+			arrow = "-.->|relates to|"
+		}
+		fmt.Fprintf(b, "  %s %s %s\n", mermaidID(e.From), arrow, mermaidID(e.To))
+	}
+	for _, a := range c.ADRs {
+		fmt.Fprintf(b, "  style %s fill:%s\n", mermaidID(a), graphFillColor(a.Meta.Status))
+	}
+
+	return b.String()
+}
+
+var mermaidIDReplacer = strings.NewReplacer(":", "_", "-", "_", " ", "_")
+
+func mermaidID(a *ADR) string {
+	return mermaidIDReplacer.Replace(recordLabel(a))
+}
+
+// mermaidText escapes the text of a node label, a quote would end the label
+// and angle brackets read as HTML
+func mermaidText(s string) string {
+	r := strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;", "\r", "", "\n", " ")
+	return r.Replace(s)
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Fix is the suggested correction of a finding, editors apply it by setting the
+// Key row to Value, Replaces names a row the Key row takes the place of and
+// File the record to edit when it is not the one the finding is about
+type Fix struct {
+	Description string `json:"description"`
+	Key         string `json:"key,omitempty"`
+	Value       string `json:"value,omitempty"`
+	Replaces    string `json:"replaces,omitempty"`
+	File        string `json:"file,omitempty"`
+}
+
+// suggestFix returns a correction for the typed parse errors, nil when there is
+// nothing better to say than the error itself
+func suggestFix(err error) *Fix {
+	var status *ErrInvalidStatus
+	var missing *ErrMissingMetadata
+	var invalid *ErrInvalidMetadata
+	var duplicate *ErrDuplicateIndex
+	var reference *ErrInvalidReference
+	var conflict *ErrConflictingDecision
+
+	switch {
+	case errors.As(err, &status):
+		return statusHint(status)
+	case errors.As(err, &missing):
+		return missingHint(missing)
+	case errors.As(err, &invalid):
+		return invalidHint(invalid)
+	case errors.As(err, &duplicate):
+		return &Fix{Description: fmt.Sprintf("give %s an index no other record uses", duplicate.Path)}
+	case errors.As(err, &reference):
+		return referenceHint(reference)
+	case errors.As(err, &conflict):
+		return &Fix{Description: fmt.Sprintf("supersede one of them, e.g. adr-index supersede %s \"<new title>\", or give one of them a terminal status", conflict.Label)}
+	case strings.HasPrefix(err.Error(), "missing = Title"):
+		return &Fix{Description: "start the record with a = Title line"}
+	case strings.HasPrefix(err.Error(), "invalid filename"), strings.HasPrefix(err.Error(), "invalid file sequence"):
+		return &Fix{Description: "name the file like 0012-use-kafka.adoc, adr-index migrate renames legacy names"}
+	}
+
+	return nil
+}
+
+func statusHint(e *ErrInvalidStatus) *Fix {
+	if len(e.Allowed) == 0 {
+		return nil
+	}
+	if e.Status == "" {
+		status := e.Allowed[0]
+		for _, s := range e.Allowed {
+			if lifecycleOf(s) == lifecyclePending {
+				status = s
+				break
+			}
+		}
+		return &Fix{Description: fmt.Sprintf("add a Status row, new records start as %s", status), Key: "Status", Value: status}
+	}
+
+	status := canonicalStatus(e.Allowed, e.Status)
+	if status == "" {
+		status, _ = nearest(e.Status, e.Allowed, -1)
+	}
+
+	return &Fix{Description: fmt.Sprintf("did you mean %s?", status), Key: "Status", Value: status}
+}
+
+func missingHint(e *ErrMissingMetadata) *Fix {
+	switch e.Key {
+	case "Date":
+		date := lastChanged(e.Path).Format(dateLayout)
+		return &Fix{Description: fmt.Sprintf("add a Date row, the record last changed on %s", date), Key: "Date", Value: date}
+	case "Author":
+		if author := gitAuthor(); author != "" {
+			return &Fix{Description: fmt.Sprintf("add an Author row, e.g. %s", author), Key: "Author", Value: author}
+		}
+		return &Fix{Description: "add an Author row naming who wrote the record", Key: "Author"}
+	case "Tags":
+		return &Fix{Description: fmt.Sprintf("add a Tags row, %s marks records still to be tagged", placeholderTag), Key: "Tags", Value: placeholderTag}
+	}
+
+	return &Fix{Description: fmt.Sprintf("add a %s row", e.Key), Key: e.Key}
+}
+
+func invalidHint(e *ErrInvalidMetadata) *Fix {
+	switch {
+	case strings.HasPrefix(e.Reason, "unexpected metadata key"):
+		candidates := append(append([]string{}, metadataKeys...), cfg.Metadata.names()...)
+		for _, t := range cfg.types {
+			candidates = append(candidates, t.Required...)
+		}
+		if key, ok := nearest(e.Key, candidates, 2); ok {
+			return &Fix{Description: fmt.Sprintf("rename %s to %s", e.Key, key), Key: key, Value: e.Value, Replaces: e.Key}
+		}
+		return &Fix{Description: fmt.Sprintf("remove the %s row, declare it under metadata.keys or make it required by a type in %s", e.Key, configFile)}
+	case strings.HasPrefix(e.Reason, "repeated metadata key"):
+		return &Fix{Description: fmt.Sprintf("merge the %s rows into one", e.Key)}
+	}
+
+	switch e.Key {
+	case "Date", "Reviewed":
+		if date, ok := canonicalDate(e.Value); ok {
+			return &Fix{Description: fmt.Sprintf("write the date as %s: %s", dateFormat, date), Key: e.Key, Value: date}
+		}
+		return &Fix{Description: fmt.Sprintf("write the date as %s, e.g. %s", dateFormat, time.Now().Format(dateLayout)), Key: e.Key}
+	case "Outcome":
+		outcome, _ := nearest(e.Value, validOutcomes, -1)
+		return &Fix{Description: fmt.Sprintf("did you mean %s?", outcome), Key: e.Key, Value: outcome}
+	case "Type":
+		if name, ok := nearest(e.Value, typeNames(), 3); ok {
+			return &Fix{Description: fmt.Sprintf("did you mean %s?", name), Key: e.Key, Value: name}
+		}
+		return &Fix{Description: fmt.Sprintf("use one of: %s", strings.Join(typeNames(), ", ")), Key: e.Key}
+	case "Cost":
+		return &Fix{Description: "write costs like one-off 12000 EUR, recurring 800 EUR/month", Key: e.Key}
+	}
+
+	return nil
+}
+
+func referenceHint(e *ErrInvalidReference) *Fix {
+	if e.Target != "" {
+		return &Fix{
+			Description: fmt.Sprintf("add a %s row naming %s to %s", e.Back, e.Label, e.Target),
+			Key:         e.Back,
+			Value:       e.Label,
+			File:        e.Target,
+		}
+	}
+
+	return &Fix{Description: fmt.Sprintf("remove %s from the %s row or name an existing record", e.Ref, e.Key)}
+}
+
+// catalogHints refines the fixes of a validation that depend on the other
+// records, tags are taken from the ones the catalog already uses
+func catalogHints(v *validation, adrs []*ADR) {
+	for i := range v.Findings {
+		f := &v.Findings[i]
+		switch f.Rule {
+		case "missing-tags":
+			if tags := suggestTags(v.Record, adrs); len(tags) > 0 {
+				value := strings.Join(tags, ", ")
+				f.Fix = &Fix{Description: fmt.Sprintf("add a Tags row, the catalog uses %s", value), Key: "Tags", Value: value}
+			}
+		case "duplicate-index":
+			if v.Record == nil {
+				continue
+			}
+			t := typeByName(v.Record.Meta.Type)
+			name := t.fileName(nextIndex(adrs, t), v.Record.Heading)
+			if ext := path.Ext(v.File); ext != path.Ext(name) {
+				name = strings.TrimSuffix(name, path.Ext(name)) + ext
+			}
+			f.Fix = &Fix{Description: fmt.Sprintf("rename %s to %s, the next free index", v.File, name)}
+		}
+	}
+}
+
+var tagWordRegex = regexp.MustCompile(`[\pL\pN]+`)
+
+// suggestTags picks the catalog tags named in the title of a, or the most used
+// one when the title names none
+func suggestTags(a *ADR, adrs []*ADR) []string {
+	counts := map[string]int{}
+	for _, other := range adrs {
+		for _, t := range other.Meta.Tags {
+			if t != placeholderTag {
+				counts[t]++
+			}
+		}
+	}
+	if len(counts) == 0 {
+		return nil
+	}
+
+	tags := []string{}
+	for t := range counts {
+		tags = append(tags, t)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if counts[tags[i]] != counts[tags[j]] {
+			return counts[tags[i]] > counts[tags[j]]
+		}
+		return tags[i] < tags[j]
+	})
+
+	if a != nil {
+		words := map[string]bool{}
+		for _, w := range tagWordRegex.FindAllString(strings.ToLower(a.Heading), -1) {
+			words[w] = true
+		}
+		named := []string{}
+		for _, t := range tags {
+			if words[strings.ToLower(t)] {
+				named = append(named, t)
+			}
+		}
+		if len(named) > 0 {
+			return named
+		}
+	}
+
+	return tags[:1]
+}
+
+// canonicalDate rewrites a date in one of the legacy layouts to dateLayout
+func canonicalDate(value string) (string, bool) {
+	for _, layout := range legacyDateLayouts {
+		if t, err := time.Parse(layout, strings.TrimSpace(value)); err == nil {
+			return t.Format(dateLayout), true
+		}
+	}
+
+	return "", false
+}
+
+// canonicalStatus finds the allowed status value stands for when it differs
+// only in case or is the alias of one, the empty string otherwise
+func canonicalStatus(allowed []string, value string) string {
+	value = strings.TrimSpace(value)
+	for _, s := range allowed {
+		if strings.EqualFold(s, value) {
+			return s
+		}
+	}
+	if alias, ok := statusAliases[strings.ToLower(value)]; ok && containsFold(allowed, alias) {
+		return alias
+	}
+
+	return ""
+}
+
+// nearest returns the candidate with the smallest edit distance to value, it is
+// only accepted within max edits unless max is negative
+func nearest(value string, candidates []string, max int) (string, bool) {
+	best, distance := "", -1
+	for _, c := range candidates {
+		if d := editDistance(value, c); distance < 0 || d < distance {
+			best, distance = c, d
+		}
+	}
+
+	return best, distance >= 0 && (max < 0 || distance <= max)
+}
+
+// editDistance is the Levenshtein distance of a and b ignoring case
+func editDistance(a string, b string) int {
+	ra := []rune(strings.ToLower(a))
+	rb := []rune(strings.ToLower(b))
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := range ra {
+		cur := make([]int, len(prev))
+		cur[0] = i + 1
+		for j := range rb {
+			cost := 1
+			if ra[i] == rb[j] {
+				cost = 0
+			}
+			cur[j+1] = minInt(prev[j+1]+1, minInt(cur[j]+1, prev[j]+cost))
+		}
+		prev = cur
+	}
+
+	return prev[len(rb)]
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"html/template"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// mermaidScriptURL is the Mermaid release the HTML index draws its map with
+const mermaidScriptURL = "https://cdn.jsdelivr.net/npm/mermaid@10/dist/mermaid.min.js"
+
+// htmlIndexTemplate is the standalone page build writes for an .html output,
+// the listing matches the default index template and every table sorts by a
+// click on its column headers
+const htmlIndexTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Architecture Decision Records</title>
+<style>
+body { font-family: sans-serif; max-width: 60em; margin: 2em auto; padding: 0 1em; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+td, th { border: 1px solid #ccc; padding: .3em .6em; text-align: left; }
+th { cursor: pointer; user-select: none; background: #f4f4f4; }
+th[aria-sort=ascending]::after { content: " \25B2"; }
+th[aria-sort=descending]::after { content: " \25BC"; }
+</style>
+</head>
+<body>
+<h1>Architecture Decision Records</h1>
+{{- with .Graph}}
+<h2>Decision map</h2>
+<pre class="mermaid">
+{{.}}</pre>
+<script src="` + mermaidScriptURL + `"></script>
+<script>mermaid.initialize({ startOnLoad: true });</script>
+{{- end}}
+{{- range .Tags}}
+<h2>{{title .Tag}}</h2>
+{{template "table" .Adrs}}
+{{- end}}
+{{- range .Sections}}
+<h2>{{.Title}}</h2>
+{{template "table" .Records}}
+{{- end}}
+{{- with .Inherited}}
+<h2>Inherited decisions</h2>
+{{template "table" .}}
+{{- end}}
+{{- with .Invalid}}
+<h2>Invalid records</h2>
+<table>
+<thead><tr><th>File</th><th>Problem</th></tr></thead>
+<tbody>
+{{- range .}}
+<tr><td><a href="{{link .Path}}">{{.Path}}</a></td><td>{{.Error}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+<script>
+document.querySelectorAll("th").forEach(function (th) {
+  th.addEventListener("click", function () {
+    var table = th.closest("table"), body = table.tBodies[0];
+    var column = Array.prototype.indexOf.call(th.parentNode.children, th);
+    var ascending = th.getAttribute("aria-sort") !== "ascending";
+    table.querySelectorAll("th").forEach(function (h) { h.removeAttribute("aria-sort"); });
+    th.setAttribute("aria-sort", ascending ? "ascending" : "descending");
+    var key = function (row) {
+      var cell = row.children[column];
+      return cell.dataset.sort !== undefined ? cell.dataset.sort : cell.textContent.trim().toLowerCase();
+    };
+    var rows = Array.prototype.slice.call(body.rows).sort(function (a, b) {
+      var x = key(a), y = key(b);
+      var order = x.localeCompare(y, undefined, { numeric: true });
+      return ascending ? order : -order;
+    });
+    rows.forEach(function (row) { body.appendChild(row); });
+  });
+});
+</script>
+</body>
+</html>
+{{define "table"}}
+<table>
+<thead><tr><th>Index</th><th>Status</th><th>Date</th><th>Tags</th><th>Description</th></tr></thead>
+<tbody>
+{{- range .}}
+<tr><td data-sort="{{.Meta.Index}}"><a href="{{link .Meta.Path}}">{{label .}}</a></td><td>{{.Meta.Status}}</td><td>{{if not .Meta.Date.IsZero}}{{.Meta.Date.Format "2006-01-02"}}{{end}}</td><td>{{join .Meta.Tags}}</td><td>{{.Heading}}{{with .Meta.Supersedes}}, supersedes {{join .}}{{end}}{{with .Meta.SupersededBy}}, superseded by {{join .}}{{end}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{end}}`
+
+// isHTMLOutput reports whether build renders the standalone HTML page to output
+// instead of executing the index template
+func isHTMLOutput(output string) bool {
+	ext := strings.ToLower(filepath.Ext(output))
+	return ext == ".html" || ext == ".htm"
+}
+
+// renderHTMLIndex writes the standalone HTML index, links to the records are
+// relative to the directory of output so the page can be published with them
+func renderHTMLIndex(adrs []*ADR, output string, w io.Writer, opts renderOptions) error {
+	records, sections := typeSections(adrs)
+	tags := groupByTag(records)
+	opts.Order.apply(tags, sections)
+	base, err := filepath.Abs(filepath.Dir(output))
+	if err != nil {
+		return err
+	}
+
+	funcs := template.FuncMap{
+		"label": recordLabel,
+		"link": func(p string) string {
+			abs, err := filepath.Abs(p)
+			if err != nil {
+				return filepath.ToSlash(p)
+			}
+			rel, err := filepath.Rel(base, abs)
+			if err != nil {
+				return filepath.ToSlash(p)
+			}
+			return filepath.ToSlash(rel)
+		},
+	}
+	for k, v := range templateFuncs {
+		funcs[k] = v
+	}
+
+	page, err := template.New("index.html").Funcs(funcs).Parse(htmlIndexTemplate)
+	if err != nil {
+		return err
+	}
+
+	graph := ""
+	if opts.Graph {
+		graph = mermaidGraph(NewCatalog(adrs))
+	}
+
+	return page.Execute(w, struct {
+		Tags      []TagADRs
+		Sections  []TypeSection
+		Inherited []*ADR
+		Invalid   []InvalidRecord
+		Graph     string
+	}{tags, sections, opts.Inherited, opts.Invalid, graph})
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HTTPConfig tunes the client used for every outbound request, link checks,
+// webhooks and the GitHub, GitLab and Slack APIs alike
+type HTTPConfig struct {
+	// Retries is the number of additional attempts after a transient failure
+	Retries    int           `yaml:"retries"`
+	Backoff    time.Duration `yaml:"backoff"`
+	MaxBackoff time.Duration `yaml:"maxBackoff"`
+	// BreakerThreshold consecutive failures to a host make further requests to
+	// it fail immediately for BreakerCooldown
+	BreakerThreshold int           `yaml:"breakerThreshold"`
+	BreakerCooldown  time.Duration `yaml:"breakerCooldown"`
+	// Proxy overrides the HTTPS_PROXY and HTTP_PROXY environment variables,
+	// NoProxy the NO_PROXY one, both are honored when not configured
+	Proxy   string `yaml:"proxy"`
+	NoProxy string `yaml:"noProxy"`
+	// CABundle is a PEM file of certificates trusted next to the system roots,
+	// for endpoints behind an internal PKI
+	CABundle string `yaml:"caBundle"`
+	// ClientCert and ClientKey are PEM files presented for mutual TLS
+	ClientCert string `yaml:"clientCert"`
+	ClientKey  string `yaml:"clientKey"`
+}
+
+func defaultHTTPConfig() HTTPConfig {
+	return HTTPConfig{Retries: 3, Backoff: 500 * time.Millisecond, MaxBackoff: 10 * time.Second, BreakerThreshold: 5, BreakerCooldown: 30 * time.Second}
+}
+
+type breaker struct {
+	failures  int
+	openUntil time.Time
+}
+
+// retryTransport retries transient failures with exponential backoff and keeps
+// a circuit breaker per host
+type retryTransport struct {
+	conf     HTTPConfig
+	next     http.RoundTripper
+	err      error
+	mu       sync.Mutex
+	breakers map[string]*breaker
+}
+
+var (
+	sharedTransport     *retryTransport
+	sharedTransportOnce sync.Once
+)
+
+// newHTTPClient returns a client going through the shared retry transport,
+// timeout bounds a request including its retries
+func newHTTPClient(timeout time.Duration) *http.Client {
+	if offline {
+		return &http.Client{Timeout: timeout, Transport: offlineTransport{}}
+	}
+
+	sharedTransportOnce.Do(func() {
+		sharedTransport = newRetryTransport(cfg.HTTP)
+	})
+
+	return &http.Client{Timeout: timeout, Transport: sharedTransport}
+}
+
+// newRetryTransport sets up proxying and TLS from conf, configuration errors are
+// returned by every request so commands without network use keep working
+func newRetryTransport(conf HTTPConfig) *retryTransport {
+	t := &retryTransport{conf: conf, breakers: map[string]*breaker{}}
+
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	if conf.Proxy != "" {
+		proxy, err := url.Parse(conf.Proxy)
+		if err != nil {
+			t.err = fmt.Errorf("invalid http.proxy %q: %s", conf.Proxy, err)
+		}
+		noProxy := conf.NoProxy
+		if noProxy == "" {
+			noProxy = os.Getenv("NO_PROXY")
+			if noProxy == "" {
+				noProxy = os.Getenv("no_proxy")
+			}
+		}
+		base.Proxy = func(req *http.Request) (*url.URL, error) {
+			if bypassProxy(req.URL.Host, noProxy) {
+				return nil, nil
+			}
+			return proxy, nil
+		}
+	} else if conf.NoProxy != "" {
+		base.Proxy = func(req *http.Request) (*url.URL, error) {
+			if bypassProxy(req.URL.Host, conf.NoProxy) {
+				return nil, nil
+			}
+			return http.ProxyFromEnvironment(req)
+		}
+	}
+
+	tlsConfig, err := clientTLSConfig(conf)
+	if err != nil {
+		t.err = err
+	}
+	base.TLSClientConfig = tlsConfig
+	t.next = base
+
+	return t
+}
+
+func clientTLSConfig(conf HTTPConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{}
+
+	if conf.CABundle != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		pem, err := ioutil.ReadFile(conf.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("invalid http.caBundle: %s", err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("invalid http.caBundle: no PEM certificates in %s", conf.CABundle)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if conf.ClientCert != "" || conf.ClientKey != "" {
+		cert, err := tls.LoadX509KeyPair(conf.ClientCert, conf.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid http.clientCert or http.clientKey: %s", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// bypassProxy matches host against a NO_PROXY list, entries are host names
+// matching themselves and their subdomains, optionally with a port, or *
+func bypassProxy(host string, noProxy string) bool {
+	hostname := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		hostname = h
+	}
+
+	for _, entry := range strings.Split(noProxy, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+		case entry == "*":
+			return true
+		case entry == strings.ToLower(host):
+			return true
+		default:
+			entry = strings.TrimPrefix(entry, ".")
+			h := strings.ToLower(hostname)
+			if h == entry || strings.HasSuffix(h, "."+entry) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.err != nil {
+		return nil, t.err
+	}
+
+	host := req.URL.Host
+	if err := t.allow(host); err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if attempt > 0 {
+			attemptReq = req.Clone(req.Context())
+			if req.Body != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				attemptReq.Body = body
+			}
+		}
+
+		resp, err := t.next.RoundTrip(attemptReq)
+		retry, wait := t.shouldRetry(req, resp, err, attempt)
+		if !retry {
+			t.record(host, err == nil && resp.StatusCode < 500)
+			return resp, err
+		}
+
+		if resp != nil {
+			ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-req.Context().Done():
+			t.record(host, false)
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// shouldRetry retries 429 and gateway errors for every method, as the request
+// was not processed, and connection errors only for idempotent methods
+func (t *retryTransport) shouldRetry(req *http.Request, resp *http.Response, err error, attempt int) (bool, time.Duration) {
+	if attempt >= t.conf.Retries || (req.Body != nil && req.GetBody == nil) {
+		return false, 0
+	}
+	if req.Context().Err() != nil {
+		return false, 0
+	}
+
+	switch {
+	case err != nil:
+		if !idempotent(req.Method) {
+			return false, 0
+		}
+	case resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode == http.StatusBadGateway,
+		resp.StatusCode == http.StatusServiceUnavailable, resp.StatusCode == http.StatusGatewayTimeout:
+		if sec, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			return true, t.capBackoff(time.Duration(sec) * time.Second)
+		}
+	default:
+		return false, 0
+	}
+
+	backoff := t.conf.Backoff << uint(attempt)
+	if backoff > 0 {
+		backoff = backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+	}
+
+	return true, t.capBackoff(backoff)
+}
+
+func (t *retryTransport) capBackoff(d time.Duration) time.Duration {
+	if t.conf.MaxBackoff > 0 && d > t.conf.MaxBackoff {
+		return t.conf.MaxBackoff
+	}
+
+	return d
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS", "PUT", "DELETE":
+		return true
+	}
+
+	return false
+}
+
+func (t *retryTransport) allow(host string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b, ok := t.breakers[host]
+	if ok && time.Now().Before(b.openUntil) {
+		return fmt.Errorf("%s failed %d times in a row, not retrying before %s", host, b.failures, b.openUntil.Format("15:04:05"))
+	}
+
+	return nil
+}
+
+func (t *retryTransport) record(host string, ok bool) {
+	if t.conf.BreakerThreshold <= 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b, found := t.breakers[host]
+	if !found {
+		b = &breaker{}
+		t.breakers[host] = b
+	}
+
+	if ok {
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.failures >= t.conf.BreakerThreshold {
+		b.openUntil = time.Now().Add(t.conf.BreakerCooldown)
+	}
+}
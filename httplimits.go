@@ -0,0 +1,160 @@
+package main
+
+import (
+	"container/list"
+	"crypto/subtle"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// requestLimits protect serve mode when it is exposed without a proxy in front
+type requestLimits struct {
+	Rate    float64
+	Burst   int
+	MaxBody int64
+	// Tokens are the bearer tokens clients are rate limited by instead of
+	// their IP address, other tokens are not trusted to tell clients apart
+	Tokens []string
+}
+
+type tokenBucket struct {
+	client string
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter keeps a token bucket per client, a client is its bearer token when
+// it sends a known one and its IP address otherwise. The buckets are kept in
+// the order they were last used, at most maxRateBuckets of them
+type rateLimiter struct {
+	rate    float64
+	burst   float64
+	max     int
+	mu      sync.Mutex
+	buckets map[string]*list.Element
+	// recent holds the buckets, the most recently used first
+	recent *list.List
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	return &rateLimiter{rate: rate, burst: float64(burst), max: maxRateBuckets, buckets: map[string]*list.Element{}, recent: list.New()}
+}
+
+// allow takes a token from the bucket of client, it returns how long to wait
+// for the next token when the bucket is empty
+func (l *rateLimiter) allow(client string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e, ok := l.buckets[client]
+	if ok {
+		l.recent.MoveToFront(e)
+	} else {
+		// the least recently used client makes room, it starts over with a
+		// full bucket when it returns
+		if len(l.buckets) >= l.max {
+			oldest := l.recent.Back()
+			l.recent.Remove(oldest)
+			delete(l.buckets, oldest.Value.(*tokenBucket).client)
+		}
+		e = l.recent.PushFront(&tokenBucket{client: client, tokens: l.burst, last: now})
+		l.buckets[client] = e
+	}
+	b := e.Value.(*tokenBucket)
+
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+	b.tokens--
+
+	return true, 0
+}
+
+// prune forgets clients whose bucket has been full again for a while, they are
+// at the back of recent
+func (l *rateLimiter) prune(now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	idle := time.Duration(l.burst/l.rate*float64(time.Second)) + time.Minute
+	for e := l.recent.Back(); e != nil && now.Sub(e.Value.(*tokenBucket).last) > idle; e = l.recent.Back() {
+		l.recent.Remove(e)
+		delete(l.buckets, e.Value.(*tokenBucket).client)
+	}
+}
+
+// maxRateBuckets is the number of clients a limiter keeps buckets for
+const maxRateBuckets = 10000
+
+// clientKey is the known bearer token of the request or its IP address
+func clientKey(r *http.Request, tokens []string) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		token := strings.TrimPrefix(auth, "Bearer ")
+		for _, known := range tokens {
+			if subtle.ConstantTimeCompare([]byte(token), []byte(known)) == 1 {
+				return "token:" + known
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return "ip:" + r.RemoteAddr
+	}
+
+	return "ip:" + host
+}
+
+func setSecurityHeaders(h http.Header, tls bool) {
+	h.Set("X-Content-Type-Options", "nosniff")
+	h.Set("X-Frame-Options", "DENY")
+	h.Set("Referrer-Policy", "same-origin")
+	h.Set("Content-Security-Policy", "default-src 'self'; style-src 'self' 'unsafe-inline'; frame-ancestors 'none'")
+	if tls {
+		h.Set("Strict-Transport-Security", "max-age=31536000")
+	}
+}
+
+// limitRequests wraps next with the security headers, the body size limit and,
+// when a rate is configured, per client rate limiting
+func limitRequests(next http.Handler, limits requestLimits) http.Handler {
+	var limiter *rateLimiter
+	if limits.Rate > 0 {
+		limiter = newRateLimiter(limits.Rate, limits.Burst)
+		go func() {
+			for now := range time.Tick(time.Minute) {
+				limiter.prune(now)
+			}
+		}()
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		setSecurityHeaders(w.Header(), r.TLS != nil)
+
+		if limiter != nil {
+			ok, wait := limiter.allow(clientKey(r, limits.Tokens), time.Now())
+			if !ok {
+				w.Header().Set("Retry-After", fmt.Sprintf("%d", int(math.Ceil(wait.Seconds()))))
+				http.Error(w, "too many requests", http.StatusTooManyRequests)
+				return
+			}
+		}
+
+		if limits.MaxBody > 0 && r.Body != nil {
+			if r.ContentLength > limits.MaxBody {
+				http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, limits.MaxBody)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	l := newRateLimiter(1, 2)
+
+	for i, want := range []bool{true, true, false} {
+		if ok, _ := l.allow("ip:a", now); ok != want {
+			t.Errorf("request %d allowed %t, want %t", i+1, ok, want)
+		}
+	}
+	if ok, wait := l.allow("ip:a", now); ok || wait != time.Second {
+		t.Errorf("empty bucket allowed %t, wait %s, want false, 1s", ok, wait)
+	}
+	if ok, _ := l.allow("ip:a", now.Add(time.Second)); !ok {
+		t.Error("refilled bucket refused")
+	}
+}
+
+func TestRateLimiterCap(t *testing.T) {
+	now := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	l := newRateLimiter(1, 1)
+	l.max = 3
+
+	l.allow("ip:a", now)
+	l.allow("ip:b", now.Add(time.Second))
+	l.allow("ip:c", now.Add(2*time.Second))
+	// a is used again, b is now the least recently used
+	l.allow("ip:a", now.Add(3*time.Second))
+	for i := 0; i < 10; i++ {
+		l.allow(fmt.Sprintf("ip:new%d", i), now.Add(4*time.Second))
+		if len(l.buckets) > l.max || l.recent.Len() != len(l.buckets) {
+			t.Fatalf("%d buckets, %d recent, want at most %d", len(l.buckets), l.recent.Len(), l.max)
+		}
+	}
+	if _, ok := l.buckets["ip:new9"]; !ok {
+		t.Error("newest client has no bucket")
+	}
+
+	l = newRateLimiter(1, 1)
+	l.max = 3
+	l.allow("ip:a", now)
+	l.allow("ip:b", now.Add(time.Second))
+	l.allow("ip:c", now.Add(2*time.Second))
+	l.allow("ip:a", now.Add(3*time.Second))
+	l.allow("ip:d", now.Add(4*time.Second))
+	if _, ok := l.buckets["ip:b"]; ok {
+		t.Error("least recently used client b kept")
+	}
+	if _, ok := l.buckets["ip:a"]; !ok {
+		t.Error("recently used client a evicted")
+	}
+}
+
+func TestRateLimiterPrune(t *testing.T) {
+	now := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	l := newRateLimiter(1, 1)
+	l.allow("ip:a", now)
+	l.allow("ip:b", now.Add(time.Minute))
+
+	l.prune(now.Add(time.Minute + 30*time.Second))
+	if _, ok := l.buckets["ip:a"]; ok {
+		t.Error("idle client a kept")
+	}
+	if _, ok := l.buckets["ip:b"]; !ok || l.recent.Len() != 1 {
+		t.Error("active client b pruned")
+	}
+}
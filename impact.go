@@ -0,0 +1,151 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// impactItem is something pointing at a record that needs a look once the
+// record is superseded
+type impactItem struct {
+	// Kind is record, code or index
+	Kind   string `json:"kind"`
+	Source string `json:"source"`
+	Detail string `json:"detail"`
+}
+
+// analyzeImpact lists the records, source files and indexes pointing at old,
+// the records by their rows and mentions, the code below roots by the labels
+// it names and the indexes of the profiles and tags that publish old
+func analyzeImpact(old *ADR, adrs []*ADR, roots []string, adrDir string) ([]impactItem, error) {
+	items := []impactItem{}
+	label := recordLabel(old)
+
+	for _, e := range NewCatalog(adrs).Graph() {
+		if e.To.Meta.Path != old.Meta.Path {
+			continue
+		}
+		detail := fmt.Sprintf("%s %s %s", recordLabel(e.From), strings.Replace(e.Kind, "-", " ", -1), label)
+		items = append(items, impactItem{Kind: "record", Source: e.From.Meta.Path, Detail: detail})
+	}
+
+	if len(roots) > 0 {
+		refs, err := scanCodeRefs(roots, adrDir, adrs)
+		if err != nil {
+			return nil, err
+		}
+		// a file is one item however often it names old
+		lines := map[string][]string{}
+		files := []string{}
+		for _, r := range refs {
+			if r.ADR == nil || r.ADR.Meta.Path != old.Meta.Path {
+				continue
+			}
+			if _, ok := lines[r.File]; !ok {
+				files = append(files, r.File)
+			}
+			line := strconv.Itoa(r.Line)
+			if n := len(lines[r.File]); n == 0 || lines[r.File][n-1] != line {
+				lines[r.File] = append(lines[r.File], line)
+			}
+		}
+		for _, f := range files {
+			items = append(items, impactItem{Kind: "code", Source: f, Detail: fmt.Sprintf("references %s on line %s", label, strings.Join(lines[f], ", "))})
+		}
+	}
+
+	names := []string{}
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p := cfg.Profiles[name]
+		if p.Output != "" && p.Filter.match(old) {
+			items = append(items, impactItem{Kind: "index", Source: p.Output, Detail: fmt.Sprintf("profile %s publishes %s", name, label)})
+		}
+	}
+	if cfg.TagIndex.Output != "" {
+		indexes, err := tagIndexes([]*ADR{old}, cfg.TagIndex.Output)
+		if err != nil {
+			return nil, err
+		}
+		for _, ti := range indexes {
+			items = append(items, impactItem{Kind: "index", Source: ti.Path, Detail: fmt.Sprintf("lists %s under tag %s", label, ti.Tag)})
+		}
+	}
+
+	return items, nil
+}
+
+// sourceRoots splits the -src flag of impact and supersede, none when empty
+func sourceRoots(src string) []string {
+	if src == "" {
+		return nil
+	}
+
+	return parseCommaList(src)
+}
+
+// followUpSection is the checklist of items the successor of old starts with,
+// in the markup of file
+func followUpSection(file string, old *ADR, items []impactItem) string {
+	heading, bullet := "==", "*"
+	if path.Ext(file) == ".md" {
+		heading, bullet = "##", "-"
+	}
+
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "\n%s Follow-up updates\n\n", heading)
+	fmt.Fprintf(b, "These pointed at %s when it was superseded, tick them off once they point at this record or no longer need to.\n\n", recordLabel(old))
+	for _, i := range items {
+		fmt.Fprintf(b, "%s [ ] %s: %s\n", bullet, i.Source, i.Detail)
+	}
+
+	return b.String()
+}
+
+// runImpact lists what points at a record, supersede adds the same list to the
+// successor as a checklist
+func runImpact(args []string) error {
+	fs := flag.NewFlagSet("impact", flag.ExitOnError)
+	dir := fs.String("dir", settings.Dir, "directory containing ADR files")
+	src := fs.String("src", ".", "comma separated source trees to scan for references, empty to skip the scan")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: impact [flags] <index>")
+	}
+
+	adrs, err := loadADRs(*dir)
+	if err != nil {
+		return err
+	}
+	old, err := resolveRecord(adrs, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	items, err := analyzeImpact(old, adrs, sourceRoots(*src), *dir)
+	if err != nil {
+		return err
+	}
+	setResults(items)
+
+	if len(items) == 0 {
+		fmt.Printf("Nothing points at %s\n", recordLabel(old))
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, i := range items {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", i.Kind, i.Source, i.Detail)
+	}
+	w.Flush()
+
+	return nil
+}
@@ -0,0 +1,860 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// importBlock is a block of an exported document, a heading, paragraph, list
+// item, code listing or table
+type importBlock struct {
+	Kind    string
+	Level   int
+	Ordered bool
+	Text    string
+	Rows    [][]string
+}
+
+// importDoc is a Confluence or Google Docs export read into blocks, Dropped
+// counts what the conversion leaves out by kind
+type importDoc struct {
+	Title   string
+	Meta    map[string]string
+	Blocks  []importBlock
+	Dropped map[string]int
+	Err     error
+}
+
+// importedRecord is a converted document and the reasons to review it, a
+// record without reasons converted with confidence
+type importedRecord struct {
+	Source string   `json:"source"`
+	Path   string   `json:"path"`
+	Title  string   `json:"title"`
+	Review []string `json:"review"`
+}
+
+// importKeys map the names of metadata fields in exported decision pages to
+// ours, Confluence page properties and Key: value lines of Google Docs
+var importKeys = map[string]string{
+	"status":          "Status",
+	"state":           "Status",
+	"decision status": "Status",
+	"date":            "Date",
+	"decision date":   "Date",
+	"decided":         "Date",
+	"decided on":      "Date",
+	"created":         "Date",
+	"author":          "Author",
+	"authors":         "Author",
+	"owner":           "Author",
+	"owners":          "Author",
+	"driver":          "Author",
+	"decision maker":  "Author",
+	"decision makers": "Author",
+	"created by":      "Author",
+	"tags":            "Tags",
+	"labels":          "Tags",
+	"keywords":        "Tags",
+}
+
+var importKeyValueRegex = regexp.MustCompile(`^\*?([A-Za-z][A-Za-z ]{1,20}?)\*?\s*:\s*\*?\s*(.+)$`)
+
+// docBuilder collects blocks from the elements of an HTML or DOCX document,
+// inline markup is written as AsciiDoc while the text is collected
+type docBuilder struct {
+	doc     *importDoc
+	kind    string
+	level   int
+	ordered bool
+	text    strings.Builder
+	table   [][]string
+	row     []string
+	tables  int
+}
+
+func newDocBuilder() *docBuilder {
+	return &docBuilder{doc: &importDoc{Meta: map[string]string{}, Dropped: map[string]int{}}, kind: "paragraph"}
+}
+
+// start ends the current block and starts one of kind
+func (b *docBuilder) start(kind string, level int, ordered bool) {
+	if b.tables > 0 {
+		// the blocks of a cell are its text
+		return
+	}
+	b.flush()
+	b.kind, b.level, b.ordered = kind, level, ordered
+}
+
+func (b *docBuilder) flush() {
+	text := b.text.String()
+	b.text.Reset()
+	if b.kind == "code" {
+		text = strings.Trim(text, "\n")
+	} else {
+		text = collapseText(text)
+	}
+	if text != "" && b.tables == 0 {
+		b.doc.Blocks = append(b.doc.Blocks, importBlock{Kind: b.kind, Level: b.level, Ordered: b.ordered, Text: text})
+	}
+	if b.kind == "code" || b.kind == "heading" {
+		b.kind, b.level = "paragraph", 0
+	}
+}
+
+// mark writes an inline marker such as * for bold, a closing marker goes
+// before the spaces the text ended with
+func (b *docBuilder) mark(marker string, closing bool) {
+	if !closing {
+		b.text.WriteString(marker)
+		return
+	}
+	text := b.text.String()
+	trimmed := strings.TrimRight(text, " \t\n")
+	if strings.HasSuffix(trimmed, marker) && strings.Count(trimmed, marker)%2 == 1 {
+		// the marked text was empty
+		b.text.Reset()
+		b.text.WriteString(strings.TrimSuffix(trimmed, marker) + text[len(trimmed):])
+		return
+	}
+	b.text.Reset()
+	b.text.WriteString(trimmed + marker + text[len(trimmed):])
+}
+
+func (b *docBuilder) startTable() {
+	b.flush()
+	b.tables++
+	if b.tables > 1 {
+		b.doc.Dropped["nested table"]++
+		return
+	}
+	b.table = [][]string{}
+}
+
+func (b *docBuilder) endCell() {
+	if b.tables != 1 {
+		b.text.WriteString(" ")
+		return
+	}
+	b.row = append(b.row, strings.Replace(collapseText(b.text.String()), "|", "\\|", -1))
+	b.text.Reset()
+}
+
+func (b *docBuilder) endRow() {
+	if b.tables == 1 && len(b.row) > 0 {
+		b.table = append(b.table, b.row)
+	}
+	b.row = nil
+}
+
+func (b *docBuilder) endTable() {
+	b.tables--
+	if b.tables == 0 && len(b.table) > 0 {
+		b.doc.Blocks = append(b.doc.Blocks, importBlock{Kind: "table", Rows: b.table})
+		b.table = nil
+	}
+}
+
+// collapseText joins the whitespace of HTML text, \x01 marks a hard line break
+func collapseText(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	text = strings.Replace(text, " \x01", "\x01", -1)
+	text = strings.Replace(text, "\x01 ", "\x01", -1)
+	text = strings.Trim(text, "\x01")
+
+	return strings.Replace(text, "\x01", " +\n", -1)
+}
+
+// readHTML reads an HTML export, the decoder is lenient enough for the HTML
+// Confluence and Google Docs write, a document it cannot read to the end keeps
+// what was read with Err set
+func readHTML(body []byte) *importDoc {
+	b := newDocBuilder()
+	d := xml.NewDecoder(bytes.NewReader(body))
+	d.Strict = false
+	d.AutoClose = xml.HTMLAutoClose
+	d.Entity = xml.HTMLEntity
+
+	lists := []bool{}
+	links := []string{}
+	linkStart := []int{}
+	spans := []string{}
+	skip := 0
+	inTitle, inPre := false, false
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			b.doc.Err = err
+			break
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			name := strings.ToLower(t.Name.Local)
+			attr := func(key string) string {
+				for _, a := range t.Attr {
+					if strings.EqualFold(a.Name.Local, key) {
+						return a.Value
+					}
+				}
+				return ""
+			}
+			switch name {
+			case "script", "style", "head":
+				skip++
+			case "title":
+				inTitle = true
+			case "h1", "h2", "h3", "h4", "h5", "h6":
+				b.start("heading", int(name[1]-'0'), false)
+			case "p", "div", "blockquote", "section":
+				if b.kind != "item" && b.tables == 0 {
+					b.start("paragraph", 0, false)
+				}
+			case "ul", "ol":
+				b.flush()
+				lists = append(lists, name == "ol")
+			case "li":
+				ordered := len(lists) > 0 && lists[len(lists)-1]
+				b.start("item", maxInt(len(lists), 1), ordered)
+			case "pre":
+				b.start("code", 0, false)
+				inPre = true
+			case "table":
+				b.startTable()
+			case "br":
+				if inPre {
+					b.text.WriteString("\n")
+				} else {
+					b.text.WriteString("\x01")
+				}
+			case "b", "strong":
+				b.mark("*", false)
+			case "i", "em":
+				b.mark("_", false)
+			case "code", "tt":
+				if !inPre {
+					b.mark("`", false)
+				}
+			case "span":
+				// Google Docs marks bold and italic text by style
+				style := strings.Replace(strings.ToLower(attr("style")), " ", "", -1)
+				marker := ""
+				switch {
+				case strings.Contains(style, "font-weight:700") || strings.Contains(style, "font-weight:bold"):
+					marker = "*"
+				case strings.Contains(style, "font-style:italic"):
+					marker = "_"
+				}
+				if marker != "" {
+					b.mark(marker, false)
+				}
+				spans = append(spans, marker)
+			case "a":
+				links = append(links, attr("href"))
+				linkStart = append(linkStart, b.text.Len())
+			case "img":
+				b.doc.Dropped["image"]++
+			}
+
+		case xml.EndElement:
+			name := strings.ToLower(t.Name.Local)
+			switch name {
+			case "script", "style", "head":
+				if skip > 0 {
+					skip--
+				}
+			case "title":
+				inTitle = false
+			case "h1", "h2", "h3", "h4", "h5", "h6", "p", "div", "blockquote", "section":
+				if b.kind != "item" && b.tables == 0 {
+					b.flush()
+				}
+			case "li":
+				b.flush()
+				b.kind = "paragraph"
+			case "ul", "ol":
+				b.flush()
+				if len(lists) > 0 {
+					lists = lists[:len(lists)-1]
+				}
+				b.kind, b.level = "paragraph", 0
+				if len(lists) > 0 {
+					b.kind, b.level, b.ordered = "item", len(lists), lists[len(lists)-1]
+				}
+			case "pre":
+				b.flush()
+				inPre = false
+			case "td", "th":
+				b.endCell()
+			case "tr":
+				b.endRow()
+			case "table":
+				b.endTable()
+			case "b", "strong":
+				b.mark("*", true)
+			case "i", "em":
+				b.mark("_", true)
+			case "code", "tt":
+				if !inPre {
+					b.mark("`", true)
+				}
+			case "span":
+				if len(spans) > 0 {
+					if marker := spans[len(spans)-1]; marker != "" {
+						b.mark(marker, true)
+					}
+					spans = spans[:len(spans)-1]
+				}
+			case "a":
+				if len(links) == 0 {
+					break
+				}
+				href, at := links[len(links)-1], linkStart[len(linkStart)-1]
+				links, linkStart = links[:len(links)-1], linkStart[:len(linkStart)-1]
+				if !markupSafeScheme.MatchString(href) || at > b.text.Len() {
+					break
+				}
+				text := b.text.String()
+				label := strings.TrimSpace(text[at:])
+				b.text.Reset()
+				b.text.WriteString(text[:at] + href + "[" + strings.Replace(label, "]", "\\]", -1) + "]")
+			}
+
+		case xml.CharData:
+			switch {
+			case inTitle:
+				b.doc.Title += string(t)
+			case skip > 0:
+			default:
+				b.text.Write(t)
+			}
+		}
+	}
+	b.flush()
+
+	return b.doc
+}
+
+// readDOCX reads the body of a Word document from word/document.xml, the
+// title, author and creation date of docProps/core.xml fill in metadata the
+// body does not give
+func readDOCX(body []byte) (*importDoc, error) {
+	z, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return nil, err
+	}
+	files := map[string][]byte{}
+	for _, f := range z.File {
+		switch f.Name {
+		case "word/document.xml", "word/_rels/document.xml.rels", "docProps/core.xml":
+			r, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			files[f.Name], err = ioutil.ReadAll(r)
+			r.Close()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if files["word/document.xml"] == nil {
+		return nil, fmt.Errorf("not a Word document, word/document.xml is missing")
+	}
+
+	rels := map[string]string{}
+	var relations struct {
+		Relationship []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		}
+	}
+	if xml.Unmarshal(files["word/_rels/document.xml.rels"], &relations) == nil {
+		for _, r := range relations.Relationship {
+			rels[r.ID] = r.Target
+		}
+	}
+
+	b := newDocBuilder()
+	var core struct {
+		Title   string `xml:"title"`
+		Creator string `xml:"creator"`
+		Created string `xml:"created"`
+	}
+	if xml.Unmarshal(files["docProps/core.xml"], &core) == nil {
+		b.doc.Title = core.Title
+		if core.Creator != "" {
+			b.doc.Meta["Author"] = core.Creator
+		}
+		if len(core.Created) >= 10 {
+			b.doc.Meta["Date"] = core.Created[:10]
+		}
+	}
+
+	d := xml.NewDecoder(bytes.NewReader(files["word/document.xml"]))
+	marks := []string{}
+	links := []string{}
+	linkStart := []int{}
+	inText := false
+	style, listLevel := "", -1
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			b.doc.Err = err
+			break
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			val := ""
+			for _, a := range t.Attr {
+				if a.Name.Local == "val" || a.Name.Local == "id" {
+					val = a.Value
+				}
+			}
+			switch t.Name.Local {
+			case "p":
+				style, listLevel = "", -1
+				if b.tables == 0 {
+					b.start("paragraph", 0, false)
+				}
+			case "pStyle":
+				style = val
+			case "ilvl":
+				fmt.Sscanf(val, "%d", &listLevel)
+			case "r":
+				marks = marks[:0]
+			case "b", "i":
+				if val != "0" && val != "false" {
+					marker := "*"
+					if t.Name.Local == "i" {
+						marker = "_"
+					}
+					marks = append(marks, marker)
+				}
+			case "t":
+				inText = true
+				if b.tables == 0 && b.text.Len() == 0 {
+					b.styleParagraph(style, listLevel)
+				}
+				for _, m := range marks {
+					b.mark(m, false)
+				}
+			case "tab":
+				b.text.WriteString(" ")
+			case "br":
+				b.text.WriteString("\x01")
+			case "hyperlink":
+				links = append(links, rels[val])
+				linkStart = append(linkStart, b.text.Len())
+			case "drawing", "pict":
+				b.doc.Dropped["image"]++
+			case "tbl":
+				b.startTable()
+			}
+
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+				for i := len(marks) - 1; i >= 0; i-- {
+					b.mark(marks[i], true)
+				}
+			case "p":
+				if b.tables == 0 {
+					b.flush()
+				} else {
+					b.text.WriteString(" ")
+				}
+			case "tc":
+				b.endCell()
+			case "tr":
+				b.endRow()
+			case "tbl":
+				b.endTable()
+			case "hyperlink":
+				if len(links) == 0 {
+					break
+				}
+				href, at := links[len(links)-1], linkStart[len(linkStart)-1]
+				links, linkStart = links[:len(links)-1], linkStart[:len(linkStart)-1]
+				if !markupSafeScheme.MatchString(href) || at > b.text.Len() {
+					break
+				}
+				text := b.text.String()
+				b.text.Reset()
+				b.text.WriteString(text[:at] + href + "[" + strings.TrimSpace(text[at:]) + "]")
+			}
+
+		case xml.CharData:
+			if inText {
+				b.text.Write(t)
+			}
+		}
+	}
+	b.flush()
+
+	return b.doc, nil
+}
+
+// styleParagraph sets the kind of the current paragraph from its Word style,
+// Title and Heading1 to Heading6 are headings and numbered paragraphs items
+func (b *docBuilder) styleParagraph(style string, listLevel int) {
+	lower := strings.ToLower(style)
+	switch {
+	case lower == "title":
+		b.kind, b.level = "heading", 1
+	case strings.HasPrefix(lower, "heading") && len(lower) == len("heading")+1:
+		b.kind, b.level = "heading", int(lower[len(lower)-1]-'0')+1
+	case listLevel >= 0 || strings.HasPrefix(lower, "listparagraph") || strings.HasPrefix(lower, "listbullet"):
+		b.kind, b.level = "item", maxInt(listLevel, 0)+1
+	}
+}
+
+// readExport reads an HTML or DOCX export by its extension
+func readExport(file string) (*importDoc, error) {
+	body, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(path.Ext(file)) {
+	case ".docx":
+		return readDOCX(body)
+	case ".html", ".htm":
+		return readHTML(body), nil
+	}
+
+	return nil, fmt.Errorf("unsupported export %s, import reads .html, .htm and .docx files", file)
+}
+
+// extractMetadata takes the metadata of the page properties table and the Key:
+// value lines near the top out of the blocks, values of the body win over
+// the document properties
+func (doc *importDoc) extractMetadata() {
+	blocks := []importBlock{}
+	for i, block := range doc.Blocks {
+		switch {
+		case block.Kind == "table" && isImportMetaTable(block.Rows):
+			for _, row := range block.Rows {
+				if key, ok := importKeys[importKey(row[0])]; ok && len(row) > 1 && strings.TrimSpace(row[1]) != "" {
+					doc.Meta[key] = row[1]
+				}
+			}
+			continue
+		case block.Kind == "paragraph" && i < 12:
+			if m := importKeyValueRegex.FindStringSubmatch(block.Text); m != nil {
+				if key, ok := importKeys[importKey(m[1])]; ok {
+					doc.Meta[key] = m[2]
+					continue
+				}
+			}
+		}
+		blocks = append(blocks, block)
+	}
+	doc.Blocks = blocks
+
+	doc.Title = strings.TrimSpace(doc.Title)
+	// Confluence exports titles as Space : Page title
+	if i := strings.Index(doc.Title, " : "); i >= 0 {
+		doc.Title = strings.TrimSpace(doc.Title[i+3:])
+	}
+	// Google Docs repeat the title as the first paragraph
+	if len(doc.Blocks) > 0 && doc.Blocks[0].Kind == "paragraph" && stripInlineMarks(doc.Blocks[0].Text) == doc.Title {
+		doc.Blocks = doc.Blocks[1:]
+	}
+	for i, block := range doc.Blocks {
+		if block.Kind != "heading" {
+			continue
+		}
+		text := stripInlineMarks(block.Text)
+		if doc.Title == "" || strings.HasSuffix(text, doc.Title) {
+			if doc.Title == "" {
+				doc.Title = text
+			}
+			doc.Blocks = append(doc.Blocks[:i], doc.Blocks[i+1:]...)
+		}
+		break
+	}
+}
+
+func importKey(cell string) string {
+	return strings.ToLower(strings.Trim(stripInlineMarks(cell), " :"))
+}
+
+func stripInlineMarks(text string) string {
+	return strings.Trim(strings.TrimSpace(text), "*_`")
+}
+
+// isImportMetaTable tells whether most rows of a two column table name a
+// metadata field, the table is the page properties then
+func isImportMetaTable(rows [][]string) bool {
+	known := 0
+	for _, row := range rows {
+		if len(row) != 2 {
+			return false
+		}
+		if _, ok := importKeys[importKey(row[0])]; ok {
+			known++
+		}
+	}
+
+	return known > 0 && known*2 >= len(rows)
+}
+
+// asciidoc renders the blocks as the body of a record, the shallowest heading
+// becomes a section
+func (doc *importDoc) asciidoc() string {
+	top := 0
+	for _, block := range doc.Blocks {
+		if block.Kind == "heading" && (top == 0 || block.Level < top) {
+			top = block.Level
+		}
+	}
+
+	b := &strings.Builder{}
+	for i, block := range doc.Blocks {
+		switch block.Kind {
+		case "heading":
+			fmt.Fprintf(b, "%s %s\n\n", strings.Repeat("=", block.Level-top+2), stripInlineMarks(block.Text))
+		case "item":
+			bullet := "*"
+			if block.Ordered {
+				bullet = "."
+			}
+			fmt.Fprintf(b, "%s %s\n", strings.Repeat(bullet, minInt(block.Level, 5)), block.Text)
+			if i+1 == len(doc.Blocks) || doc.Blocks[i+1].Kind != "item" {
+				b.WriteString("\n")
+			}
+		case "code":
+			fmt.Fprintf(b, "----\n%s\n----\n\n", block.Text)
+		case "table":
+			b.WriteString("|===\n")
+			for r, row := range block.Rows {
+				fmt.Fprintf(b, "|%s\n", strings.Join(row, " |"))
+				if r == 0 && len(block.Rows) > 1 {
+					b.WriteString("\n")
+				}
+			}
+			b.WriteString("|===\n\n")
+		default:
+			fmt.Fprintf(b, "%s\n\n", block.Text)
+		}
+	}
+
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+// importRecord converts doc to the content of a record of type t, the reasons
+// to review it are the metadata it had to guess and what it left out
+func importRecord(doc *importDoc, t *RecordType, authors []string, status string, tags []string, now time.Time) (string, []string, error) {
+	review := []string{}
+	doc.extractMetadata()
+	if doc.Title == "" {
+		doc.Title = "Untitled"
+		review = append(review, "no title found")
+	}
+
+	date := now
+	if v, ok := doc.Meta["Date"]; ok {
+		if len(v) >= 10 {
+			if t, err := time.Parse("2006-01-02", v[:10]); err == nil {
+				v = t.Format(dateLayout)
+			}
+		}
+		if d, err := time.Parse(dateLayout, v); err == nil {
+			date = d
+		} else if canonical, ok := canonicalDate(v); ok {
+			date, _ = time.Parse(dateLayout, canonical)
+		} else {
+			review = append(review, fmt.Sprintf("date %q not understood, dated today", v))
+		}
+	} else {
+		review = append(review, "no date found, dated today")
+	}
+
+	if v, ok := doc.Meta["Author"]; ok {
+		authors = []string{}
+		for _, a := range regexp.MustCompile(`\s*(?:,|;|\band\b)\s*`).Split(stripInlineMarks(v), -1) {
+			if a = strings.TrimSpace(a); a != "" {
+				authors = append(authors, a)
+			}
+		}
+	} else {
+		review = append(review, fmt.Sprintf("no author found, attributed to %s", strings.Join(authors, ", ")))
+	}
+
+	if v, ok := doc.Meta["Status"]; ok {
+		if s := canonicalStatus(t.statuses(), stripInlineMarks(v)); s != "" {
+			status = s
+		} else {
+			review = append(review, fmt.Sprintf("status %q not understood, %s assumed", v, status))
+		}
+	} else {
+		review = append(review, fmt.Sprintf("no status found, %s assumed", status))
+	}
+
+	if v, ok := doc.Meta["Tags"]; ok {
+		tags = parseCommaList(strings.ToLower(stripInlineMarks(v)))
+	} else {
+		review = append(review, fmt.Sprintf("no tags found, tagged %s", strings.Join(tags, ", ")))
+	}
+
+	for kind, n := range doc.Dropped {
+		review = append(review, fmt.Sprintf("%d %s left out", n, pluralize(kind, n)))
+	}
+	if doc.Err != nil {
+		review = append(review, fmt.Sprintf("the export could not be read to the end: %s", doc.Err))
+	}
+
+	body := doc.asciidoc()
+	missing := []string{}
+	for _, err := range lintSections("", "\n"+body, requiredSections(t)) {
+		if e, ok := err.(*ErrMissingSection); ok {
+			missing = append(missing, e.Section)
+		}
+	}
+	if len(missing) > 0 {
+		review = append(review, "no or empty "+strings.Join(missing, ", ")+" section")
+	}
+
+	skeleton := "= Title\n\n|===\n|Metadata |Value\n\n|Date |\n|Author |\n|Status |\n|Tags |\n|===\n\n" + body
+	content, err := scaffold{Type: t, Title: doc.Title, Authors: authors, Tags: tags, Status: status, Date: date, Skeleton: skeleton}.render()
+	if err != nil {
+		return "", nil, err
+	}
+
+	return content, review, nil
+}
+
+func pluralize(word string, n int) string {
+	if n == 1 {
+		return word
+	}
+
+	return word + "s"
+}
+
+// importSources expands directories to the exports they hold
+func importSources(args []string) ([]string, error) {
+	files := []string{}
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, arg)
+			continue
+		}
+		err = filepath.Walk(arg, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			switch strings.ToLower(filepath.Ext(p)) {
+			case ".html", ".htm", ".docx":
+				if !info.IsDir() {
+					files = append(files, p)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+// runImport converts HTML and DOCX exports of Confluence pages and Google Docs
+// to records with the next free indexes, records that needed guessing are
+// listed for review
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	dir := fs.String("dir", settings.Dir, "directory containing ADR files")
+	recordType := fs.String("type", adrTypeKey, "record type of the imported records")
+	author := fs.String("author", gitAuthor(), "comma separated authors of documents that name none")
+	status := fs.String("status", "Proposed", "status of documents that give none")
+	tags := fs.String("tags", placeholderTag, "comma separated tags of documents that give none")
+	dryRun := fs.Bool("dry-run", false, "print the records that would be written without writing them")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		return fmt.Errorf("usage: import [flags] <export files or directories>")
+	}
+	t := typeByName(*recordType)
+	if t == nil {
+		return fmt.Errorf("unknown record type %q", *recordType)
+	}
+	sources, err := importSources(fs.Args())
+	if err != nil {
+		return err
+	}
+	adrs, err := loadADRs(*dir)
+	if err != nil {
+		return err
+	}
+
+	next := nextIndex(adrs, t)
+	results := []importedRecord{}
+	p := newPainter(os.Stdout)
+	for _, source := range sources {
+		doc, err := readExport(source)
+		if err != nil {
+			return fmt.Errorf("%s in %s", err, source)
+		}
+		content, review, err := importRecord(doc, t, parseCommaList(*author), *status, parseCommaList(*tags), time.Now())
+		if err != nil {
+			return fmt.Errorf("%s in %s", err, source)
+		}
+
+		target := path.Join(*dir, t.fileName(next, doc.Title))
+		if _, err := parseADRContent(target, []byte(content)); err != nil {
+			return fmt.Errorf("converted record does not validate, %s in %s", err, source)
+		}
+		if len(review) > 0 {
+			content += "\n// Imported from " + path.Base(source) + ", review:\n"
+			for _, r := range review {
+				content += "// - " + r + "\n"
+			}
+		}
+		if !*dryRun {
+			err = writeNewFile(target, content)
+			if err != nil {
+				return err
+			}
+		}
+		next++
+		results = append(results, importedRecord{Source: source, Path: target, Title: doc.Title, Review: review})
+
+		if len(review) == 0 {
+			fmt.Printf("%s: %s %s\n", source, target, p.ok("ok"))
+			continue
+		}
+		fmt.Printf("%s: %s %s\n", source, target, p.paint(colorYellow, "review"))
+		for _, r := range review {
+			fmt.Printf("  %s\n", r)
+		}
+	}
+	setResults(results)
+
+	return nil
+}
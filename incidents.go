@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+type Incident struct {
+	ID    string
+	Title string
+	URL   string
+}
+
+// incidentResolvers read incident exports of the supported trackers, add an
+// entry here to support another tracker
+var incidentResolvers = map[string]func(body []byte) ([]Incident, error){
+	"pagerduty": parsePagerDutyExport,
+	"jira":      parseJiraExport,
+	"csv":       parseCSVExport,
+}
+
+func runIncidents(args []string) error {
+	fs := flag.NewFlagSet("incidents", flag.ExitOnError)
+	dir := fs.String("dir", settings.Dir, "directory containing ADR files")
+	export := fs.String("export", "", "incident export to reconcile against")
+	source := fs.String("source", "pagerduty", "format of the export: pagerduty, jira or csv")
+	fs.Parse(args)
+
+	adrs, err := loadADRs(*dir)
+	if err != nil {
+		return err
+	}
+
+	var incidents []Incident
+	if *export != "" {
+		resolve, ok := incidentResolvers[*source]
+		if !ok {
+			return fmt.Errorf("unknown incident source %q", *source)
+		}
+
+		body, err := ioutil.ReadFile(*export)
+		if err != nil {
+			return err
+		}
+
+		incidents, err = resolve(body)
+		if err != nil {
+			return fmt.Errorf("invalid %s export %s: %s", *source, *export, err)
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintln(w, "Decisions linked to incidents")
+	linked := map[string]bool{}
+	for _, a := range searchADRs(adrs, "") {
+		if len(a.Meta.Incidents) == 0 {
+			continue
+		}
+
+		names := []string{}
+		for _, ref := range a.Meta.Incidents {
+			name := ref
+			for _, inc := range incidents {
+				if incidentMatches(inc, ref) {
+					linked[inc.ID] = true
+					if inc.Title != "" {
+						name = fmt.Sprintf("%s (%s)", inc.ID, inc.Title)
+					}
+				}
+			}
+			names = append(names, name)
+		}
+
+		fmt.Fprintf(w, "  %s\t%s\t%s\n", recordLabel(a), a.Heading, strings.Join(names, ", "))
+	}
+
+	if *export == "" {
+		return nil
+	}
+
+	unlinked := []Incident{}
+	for _, inc := range incidents {
+		if !linked[inc.ID] {
+			unlinked = append(unlinked, inc)
+		}
+	}
+	sort.Slice(unlinked, func(i, j int) bool {
+		return unlinked[i].ID < unlinked[j].ID
+	})
+
+	fmt.Fprintf(w, "\nIncidents without a decision record (%d of %d)\n", len(unlinked), len(incidents))
+	for _, inc := range unlinked {
+		fmt.Fprintf(w, "  %s\t%s\t%s\n", inc.ID, inc.Title, inc.URL)
+	}
+
+	return nil
+}
+
+// incidentMatches compares an Incidents metadata entry, which may be an ID or a
+// URL, with an exported incident
+func incidentMatches(inc Incident, ref string) bool {
+	ref = strings.TrimSpace(ref)
+	switch {
+	case strings.EqualFold(ref, inc.ID):
+		return true
+	case inc.URL != "" && strings.TrimSuffix(ref, "/") == strings.TrimSuffix(inc.URL, "/"):
+		return true
+	case strings.Contains(ref, "/") && strings.HasSuffix(strings.TrimSuffix(ref, "/"), "/"+inc.ID):
+		return true
+	}
+
+	return false
+}
+
+func parsePagerDutyExport(body []byte) ([]Incident, error) {
+	var export struct {
+		Incidents []struct {
+			ID             string `json:"id"`
+			IncidentNumber int    `json:"incident_number"`
+			Title          string `json:"title"`
+			HTMLURL        string `json:"html_url"`
+		} `json:"incidents"`
+	}
+	err := json.Unmarshal(body, &export)
+	if err != nil {
+		return nil, err
+	}
+
+	incidents := []Incident{}
+	for _, i := range export.Incidents {
+		incidents = append(incidents, Incident{ID: i.ID, Title: i.Title, URL: i.HTMLURL})
+	}
+
+	return incidents, nil
+}
+
+func parseJiraExport(body []byte) ([]Incident, error) {
+	var export struct {
+		Issues []struct {
+			Key    string `json:"key"`
+			Self   string `json:"self"`
+			Fields struct {
+				Summary string `json:"summary"`
+			} `json:"fields"`
+		} `json:"issues"`
+	}
+	err := json.Unmarshal(body, &export)
+	if err != nil {
+		return nil, err
+	}
+
+	incidents := []Incident{}
+	for _, i := range export.Issues {
+		url := ""
+		if idx := strings.Index(i.Self, "/rest/"); idx > 0 {
+			url = i.Self[:idx] + "/browse/" + i.Key
+		}
+		incidents = append(incidents, Incident{ID: i.Key, Title: i.Fields.Summary, URL: url})
+	}
+
+	return incidents, nil
+}
+
+// parseCSVExport reads id,title,url rows, a header row starting with id is skipped
+func parseCSVExport(body []byte) ([]Incident, error) {
+	records, err := csv.NewReader(strings.NewReader(string(body))).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	incidents := []Incident{}
+	for i, r := range records {
+		if i == 0 && strings.EqualFold(strings.TrimSpace(r[0]), "id") {
+			continue
+		}
+
+		inc := Incident{ID: strings.TrimSpace(r[0])}
+		if len(r) > 1 {
+			inc.Title = strings.TrimSpace(r[1])
+		}
+		if len(r) > 2 {
+			inc.URL = strings.TrimSpace(r[2])
+		}
+		incidents = append(incidents, inc)
+	}
+
+	return incidents, nil
+}
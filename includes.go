@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+)
+
+// Include mirrors the records of another repository into the index, from a git
+// submodule or a vendored copy
+type Include struct {
+	// Name prefixes the labels of the included records, platform:ADR-3
+	Name string `yaml:"name"`
+	Dir  string `yaml:"dir"`
+	// Repo and Ref record where a vendored copy was taken from, for submodules
+	// they are read from git when not set
+	Repo string `yaml:"repo"`
+	Ref  string `yaml:"ref"`
+}
+
+// Provenance tells where an included record comes from
+type Provenance struct {
+	Name string `json:"name"`
+	Repo string `json:"repo,omitempty"`
+	Ref  string `json:"ref,omitempty"`
+	// Vendored is set for copies that are not a git checkout of their own
+	Vendored bool `json:"vendored,omitempty"`
+	// Inherited is set for decisions of an inherited catalog, see InheritConfig
+	Inherited bool `json:"inherited,omitempty"`
+}
+
+func (p *Provenance) String() string {
+	ref := p.Ref
+	if ref == "" {
+		ref = "unknown ref"
+	}
+	if p.Repo == "" {
+		return fmt.Sprintf("%s (%s)", p.Name, ref)
+	}
+
+	return fmt.Sprintf("%s (%s @ %s)", p.Name, p.Repo, ref)
+}
+
+// scanIncludes reads the records of every configured include, invalid records
+// are returned as errors like those of scanADRs
+func scanIncludes() ([]*ADR, []error, error) {
+	adrs := []*ADR{}
+	errs := []error{}
+
+	for _, inc := range cfg.Includes {
+		source, err := includeProvenance(inc)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		included, incErrs, err := scanADRs(inc.Dir)
+		if err != nil {
+			return nil, nil, fmt.Errorf("include %s: %s", inc.Name, err)
+		}
+		for _, a := range included {
+			a.Meta.Source = source
+		}
+
+		adrs = append(adrs, included...)
+		errs = append(errs, incErrs...)
+	}
+
+	return adrs, errs, nil
+}
+
+// includeProvenance prefers the configured repo and ref, a directory that is a
+// checkout of its own, such as a submodule, reports its origin and HEAD
+func includeProvenance(inc Include) (*Provenance, error) {
+	if inc.Name == "" || inc.Dir == "" {
+		return nil, fmt.Errorf("includes need a name and a dir, got %q and %q", inc.Name, inc.Dir)
+	}
+
+	p := &Provenance{Name: inc.Name, Repo: inc.Repo, Ref: inc.Ref, Vendored: true}
+
+	own, err := git("-C", inc.Dir, "rev-parse", "--show-toplevel")
+	top, _ := git("rev-parse", "--show-toplevel")
+	if err == nil && filepath.Clean(own) != filepath.Clean(top) {
+		p.Vendored = false
+		if p.Repo == "" {
+			p.Repo, _ = git("-C", inc.Dir, "config", "--get", "remote.origin.url")
+		}
+		if p.Ref == "" {
+			p.Ref, _ = git("-C", inc.Dir, "describe", "--tags", "--always")
+		}
+	}
+
+	if p.Vendored && (p.Repo == "" || p.Ref == "") {
+		log.Printf("Warning: include %s is a vendored copy without repo and ref, its provenance is unknown", inc.Name)
+	}
+
+	return p, nil
+}
+
+// withIncludes adds the included records to a scanned catalog
+func withIncludes(adrs []*ADR, errs []error) ([]*ADR, []error, error) {
+	if len(cfg.Includes) == 0 {
+		return adrs, errs, nil
+	}
+
+	included, incErrs, err := scanIncludes()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return append(adrs, included...), append(errs, incErrs...), nil
+}
+
+// includeDirs are the directories a catalog is built from, for watching
+func includeDirs(dir string) []string {
+	dirs := []string{dir}
+	for _, inc := range cfg.Includes {
+		dirs = append(dirs, strings.TrimSuffix(inc.Dir, "/"))
+	}
+
+	return dirs
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var indexLinkRegex = regexp.MustCompile(`link:([^\[\s]+)\[`)
+
+// indexRows splits a rendered index into the table rows of linked records keyed
+// by the link target resolved from the directory base of the index, a row ends
+// at the next record link, table or heading
+func indexRows(content string, dir string, base string) map[string]string {
+	prefix := strings.Trim(filepath.ToSlash(dir), "/") + "/"
+	rows := map[string]string{}
+	current := ""
+
+	for _, line := range strings.Split(content, "\n") {
+		m := indexLinkRegex.FindStringSubmatch(line)
+		if m != nil {
+			m[1] = path.Join(filepath.ToSlash(base), m[1])
+		}
+		if m != nil && strings.HasPrefix(m[1], prefix) {
+			current = m[1]
+			rows[current] = ""
+		} else if strings.HasPrefix(line, "|===") || strings.HasPrefix(line, "=") {
+			current = ""
+		}
+
+		if current != "" {
+			rows[current] += strings.TrimSpace(line) + "\n"
+		}
+	}
+
+	return rows
+}
+
+// verifyIndex compares the committed index with the index the current files
+// would render to in order, reporting hand edits and files deleted without
+// regenerating
+func verifyIndex(adrs []*ADR, dir string, templatePath string, output string, opts renderOptions) error {
+	committed, err := ioutil.ReadFile(output)
+	if err != nil {
+		return err
+	}
+
+	var expected bytes.Buffer
+	if isHTMLOutput(output) {
+		err = renderHTMLIndex(adrs, output, &expected, opts)
+		if err != nil {
+			return err
+		}
+		// the page has no rows to point at, it either matches or it does not
+		if !bytes.Equal(committed, expected.Bytes()) {
+			return fmt.Errorf("%s does not match %s, run build to regenerate it", output, dir)
+		}
+		return nil
+	}
+	err = renderIndexesWith(adrs, templatePath, &expected, opts)
+	if err != nil {
+		return err
+	}
+
+	byPath := map[string]*ADR{}
+	for _, a := range adrs {
+		byPath[filepath.ToSlash(a.Meta.Path)] = a
+	}
+
+	have := indexRows(string(committed), dir, filepath.Dir(output))
+	want := indexRows(expected.String(), dir, filepath.Dir(output))
+
+	problems := []string{}
+	for _, a := range searchADRs(adrs, "") {
+		p := filepath.ToSlash(a.Meta.Path)
+		row, ok := have[p]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("%s %s is missing from %s", recordLabel(a), p, output))
+		case row != want[p]:
+			problems = append(problems, fmt.Sprintf("%s %s is out of date in %s, the file has title %q and status %q", recordLabel(a), p, output, a.Heading, a.Meta.Status))
+		}
+	}
+	for _, p := range sortedRowKeys(have) {
+		if _, ok := byPath[p]; ok {
+			continue
+		}
+		if _, err := os.Stat(filepath.FromSlash(p)); os.IsNotExist(err) {
+			problems = append(problems, fmt.Sprintf("%s links %s which does not exist", output, p))
+		} else {
+			problems = append(problems, fmt.Sprintf("%s links %s which is not part of the catalog", output, p))
+		}
+	}
+
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%s does not match %s, run build to regenerate it", output, dir)
+	}
+
+	return nil
+}
+
+func sortedRowKeys(m map[string]string) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
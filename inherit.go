@@ -0,0 +1,201 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"adr-index/analysis/adrref"
+)
+
+// InheritConfig names an upstream catalog, published with export -format
+// catalog, whose decisions apply to this repository too, local decisions
+// override them with a Supersedes row such as org:ADR-3
+type InheritConfig struct {
+	Name string `yaml:"name"`
+	// URL of the catalog.json, a local path is read directly
+	URL string `yaml:"url"`
+}
+
+// inheritedRecords fetches every inherited catalog, the last fetched copy is
+// used offline or when the upstream cannot be reached
+func inheritedRecords() ([]*ADR, error) {
+	adrs := []*ADR{}
+
+	for _, inh := range cfg.Inherit {
+		if inh.Name == "" || inh.URL == "" {
+			return nil, fmt.Errorf("inherit needs a name and a url, got %q and %q", inh.Name, inh.URL)
+		}
+
+		records, err := fetchCatalog(inh)
+		if err != nil {
+			return nil, fmt.Errorf("inherited catalog %s: %s", inh.Name, err)
+		}
+
+		for _, r := range records {
+			a := &ADR{Heading: r.Title, Meta: ADRMeta{
+				Index:  r.Index,
+				Status: r.Status,
+				Path:   r.Path,
+				Source: &Provenance{Name: inh.Name, Repo: inh.URL, Inherited: true},
+			}}
+			if r.URL != "" {
+				a.Meta.Path = r.URL
+			}
+			for _, t := range cfg.types {
+				if t.Label == r.Label {
+					a.Meta.Type = t.metaType()
+				}
+			}
+			adrs = append(adrs, a)
+		}
+	}
+
+	return adrs, nil
+}
+
+func fetchCatalog(inh InheritConfig) ([]adrref.Record, error) {
+	if !strings.HasPrefix(inh.URL, "http://") && !strings.HasPrefix(inh.URL, "https://") {
+		body, err := ioutil.ReadFile(inh.URL)
+		if err != nil {
+			return nil, err
+		}
+		return decodeCatalog(body)
+	}
+
+	cache := inheritCachePath(inh.URL)
+	if networkAllowed("fetching inherited catalog " + inh.Name) {
+		body, err := getCatalog(inh.URL)
+		var records []adrref.Record
+		if err == nil {
+			records, err = decodeCatalog(body)
+		}
+		if err == nil {
+			err = os.MkdirAll(filepath.Dir(cache), 0755)
+			if err == nil {
+				err = ioutil.WriteFile(cache, body, 0644)
+			}
+			if err != nil {
+				log.Printf("Warning: could not cache inherited catalog %s: %s", inh.Name, err)
+			}
+			return records, nil
+		}
+		log.Printf("Warning: could not fetch inherited catalog %s, using the cached copy: %s", inh.Name, err)
+	}
+
+	body, err := ioutil.ReadFile(cache)
+	if err != nil {
+		return nil, fmt.Errorf("no cached copy of %s", inh.URL)
+	}
+
+	return decodeCatalog(body)
+}
+
+// maxCatalogSize bounds the download of an inherited catalog
+const maxCatalogSize = 32 << 20
+
+func getCatalog(url string) ([]byte, error) {
+	resp, err := newHTTPClient(30 * time.Second).Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxCatalogSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxCatalogSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", url, maxCatalogSize)
+	}
+
+	return body, nil
+}
+
+func decodeCatalog(body []byte) ([]adrref.Record, error) {
+	records := []adrref.Record{}
+	err := json.Unmarshal(body, &records)
+	if err != nil {
+		return nil, fmt.Errorf("invalid catalog: %s", err)
+	}
+
+	return records, nil
+}
+
+func inheritCachePath(url string) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	sum := sha1.Sum([]byte(url))
+
+	return filepath.Join(dir, "adr-index", "inherit-"+hex.EncodeToString(sum[:6])+".json")
+}
+
+// withInherited fetches the inherited decisions and applies the overrides of
+// the local ones, invalid overrides are added to errs
+func withInherited(adrs []*ADR, errs []error) ([]*ADR, []error, error) {
+	if len(cfg.Inherit) == 0 {
+		return nil, errs, nil
+	}
+
+	inherited, err := inheritedRecords()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return inherited, append(errs, applyOverrides(adrs, inherited)...), nil
+}
+
+// applyOverrides checks the Supersedes rows of local records that point at an
+// inherited catalog, the inherited decision must exist, be overridden by one
+// local record only, and is marked as superseded by it
+func applyOverrides(local []*ADR, inherited []*ADR) []error {
+	errs := []error{}
+	names := map[string]bool{}
+	for _, inh := range cfg.Inherit {
+		names[inh.Name] = true
+	}
+	byLabel := map[string]*ADR{}
+	for _, a := range inherited {
+		byLabel[strings.ToLower(recordLabel(a))] = a
+	}
+
+	for _, a := range local {
+		for _, ref := range a.Meta.Supersedes {
+			parts := strings.SplitN(ref, ":", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			if !names[parts[0]] {
+				errs = append(errs, fmt.Errorf("supersedes %s of unknown inherited catalog %s in %s", ref, parts[0], a.Meta.Path))
+				continue
+			}
+
+			target, ok := byLabel[strings.ToLower(ref)]
+			if !ok {
+				errs = append(errs, fmt.Errorf("supersedes %s which does not exist in the inherited catalog in %s", ref, a.Meta.Path))
+				continue
+			}
+			if len(target.Meta.SupersededBy) > 0 {
+				errs = append(errs, fmt.Errorf("supersedes %s which %s already overrides in %s", ref, strings.Join(target.Meta.SupersededBy, ", "), a.Meta.Path))
+				continue
+			}
+			target.Meta.SupersededBy = append(target.Meta.SupersededBy, recordLabel(a))
+		}
+	}
+
+	return errs
+}
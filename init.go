@@ -0,0 +1,249 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const starterConfig = `# adr-index configuration, every setting is optional
+
+# dir: adr
+# template: .readme.templ
+output: README.adoc
+
+# statuses: [Proposed, Approved, Partially Implemented, Implemented, Rejected, Deprecated, Superseded]
+# lifecycle:
+#   Retired: terminal
+# dateLayout: DD-MM-YYYY
+
+# siteURL: https://adr.example.com
+
+# minVersion: 1.4.0
+
+# commit:
+#   requireRef:
+#     - infra/
+
+# profiles:
+#   public:
+#     output: PUBLIC.adoc
+#     filter:
+#       excludeTags: [internal]
+`
+
+const starterIndexTemplate = `= Architecture Decision Records
+
+This repository records the architecture decisions of the project, see ADR-1 for how and why.
+{{ range . }}
+== {{ .Tag | title }}
+|===
+|Index |Tags| Description
+{{- range .Adrs }}
+|link:{{.Meta.Path}}[{{with .Meta.Source}}{{.Name}}:{{end}}ADR-{{.Meta.Index}}]{{with .Meta.Source}} from {{.}}{{end}}
+|{{.Meta.Tags|join}}
+|{{.Heading}}{{with .Meta.Supersedes}}, supersedes {{join .}}{{end}}{{with .Meta.SupersededBy}}, superseded by {{join .}}{{end}}
+{{- end }}
+|===
+{{ end }}
+{{- range sections }}
+== {{ .Title }}
+{{- $label := .Label }}
+|===
+|Index |Tags| Description
+{{- range .Records }}
+|link:{{.Meta.Path}}[{{$label}}-{{.Meta.Index}}]
+|{{.Meta.Tags|join}}
+|{{.Heading}}{{with .Meta.Supersedes}}, supersedes {{join .}}{{end}}{{with .Meta.SupersededBy}}, superseded by {{join .}}{{end}}
+{{- end }}
+|===
+{{ end }}
+{{- with inherited }}
+== Inherited decisions
+|===
+|Index |Status| Description
+{{- range . }}
+|link:{{.Meta.Path}}[{{label .}}]
+|{{.Meta.Status}}{{with .Meta.SupersededBy}}, superseded here by {{join .}}{{end}}
+|{{.Heading}}
+{{- end }}
+|===
+{{ end }}
+{{- with invalid }}
+== Invalid records
+|===
+|File |Problem
+{{- range . }}
+|link:{{.Path}}[{{.Path}}]
+|{{.Error}}
+{{- end }}
+|===
+{{ end }}
+`
+
+const firstRecordSkeleton = `= Title
+
+|===
+|Metadata |Value
+
+|Date |YYYY-MM-DD
+|Author |@author
+|Status |Approved
+|Tags |process
+|===
+
+|===
+|Revision|Date|Author|Info
+|1 |YYYY-MM-DD|@author|Initial design
+|===
+
+== Context and Problem Statement
+
+We need to record the architectural decisions made on this project so that the reasons behind them stay available to everyone who joins later.
+
+== Decision
+
+We will use Architecture Decision Records, one AsciiDoc file per decision in this directory, created from adr-template.adoc. The index in README.adoc is generated with adr-index build.
+
+== Consequences
+
+Decisions are reviewed like code, superseded decisions are kept and marked as such. The index must be rebuilt when records change, adr-index build -verify fails in CI when it is stale.
+`
+
+var starterWorkflows = map[string]struct {
+	Path   string
+	Script string
+}{
+	"github": {".github/workflows/adr.yaml", `name: ADR
+on:
+  pull_request:
+  push:
+    branches: [main]
+jobs:
+  verify:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+        with:
+          fetch-depth: 0
+      # adr-index must be on the PATH, e.g. downloaded from your release artifacts
+      # problems are annotated on the lines of the pull request
+      - run: adr-index --format github validate -dir {{dir}}
+      - run: adr-index build -dir {{dir}} -verify
+      - run: adr-index checksums -dir {{dir}}
+`},
+	"gitlab": {".gitlab-ci.yml", `adr:
+  stage: test
+  variables:
+    GIT_DEPTH: 0
+  script:
+    # adr-index must be on the PATH, e.g. in the job image
+    - adr-index build -dir {{dir}} -verify
+    - adr-index checksums -dir {{dir}}
+`},
+}
+
+// runInit bootstraps a repository with the ADR directory, configuration, index
+// template, a first record and optionally CI and hooks, existing files are kept
+func runInit(args []string) error {
+	fs := flag.NewFlagSet("init", flag.ExitOnError)
+	dir := fs.String("dir", settings.Dir, "directory to hold the ADR files")
+	author := fs.String("author", gitAuthor(), "author of the first ADR")
+	ci := fs.String("ci", "", "also write a CI workflow verifying the index: github or gitlab")
+	hooks := fs.Bool("hooks", false, "also install the commit-msg git hook")
+	fs.Parse(args)
+
+	workflow, ok := starterWorkflows[*ci]
+	if *ci != "" && !ok {
+		return fmt.Errorf("unknown CI provider %q, expected github or gitlab", *ci)
+	}
+
+	err := os.MkdirAll(*dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	files := []struct {
+		Path    string
+		Content string
+	}{
+		{configFile, starterConfig},
+		{settings.Template, starterIndexTemplate},
+		{typeByName(adrTypeKey).Skeleton, defaultSkeleton},
+	}
+	if ok {
+		files = append(files, struct {
+			Path    string
+			Content string
+		}{workflow.Path, expandDir(workflow.Script, *dir)})
+	}
+	for _, f := range files {
+		err = writeStarterFile(f.Path, f.Content)
+		if err != nil {
+			return err
+		}
+	}
+
+	adrs, err := loadADRs(*dir)
+	if err != nil {
+		return err
+	}
+	if len(adrs) > 0 {
+		log.Printf("Keeping the %d records in %s, not adding a first ADR", len(adrs), *dir)
+	} else {
+		target, err := createADR(*dir, scaffold{
+			Title:    "Record architecture decisions",
+			Authors:  parseCommaList(*author),
+			Tags:     []string{"process"},
+			Status:   "Approved",
+			Date:     time.Now(),
+			Skeleton: firstRecordSkeleton,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Created %s\n", target)
+	}
+
+	err = runBuild([]string{"-dir", *dir, "-output", "README.adoc"})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Wrote README.adoc\n")
+
+	if *hooks {
+		return installCommitMsgHook()
+	}
+
+	return nil
+}
+
+// writeStarterFile creates file with content unless it already exists
+func writeStarterFile(file string, content string) error {
+	if _, err := os.Stat(file); err == nil {
+		log.Printf("Keeping existing %s", file)
+		return nil
+	}
+
+	err := os.MkdirAll(filepath.Dir(file), 0755)
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(file, []byte(content), 0644)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Created %s\n", file)
+
+	return nil
+}
+
+func expandDir(script string, dir string) string {
+	return strings.Replace(script, "{{dir}}", dir, -1)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStarterIndexTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "adr-index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	templatePath := filepath.Join(dir, ".readme.templ")
+	err = ioutil.WriteFile(templatePath, []byte(starterIndexTemplate), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	adrs := []*ADR{}
+	for file, body := range map[string]string{
+		"adr/0001-record-architecture-decisions.adoc": recordBody("|Date |01-02-2024", "|Author |@alice", "|Status |Approved", "|Tags |process"),
+		"adr/0002-use-kafka.adoc":                     recordBody("|Date |02-02-2024", "|Author |@alice", "|Status |Approved", "|Tags |messaging"),
+	} {
+		a, err := parseADRContent(file, []byte(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		adrs = append(adrs, a)
+	}
+
+	var b bytes.Buffer
+	err = renderIndexes(adrs, templatePath, &b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `= Architecture Decision Records
+
+This repository records the architecture decisions of the project, see ADR-1 for how and why.
+
+== Messaging
+|===
+|Index |Tags| Description
+|link:adr/0002-use-kafka.adoc[ADR-2]
+|messaging
+|Use Kafka
+|===
+
+== Process
+|===
+|Index |Tags| Description
+|link:adr/0001-record-architecture-decisions.adoc[ADR-1]
+|process
+|Use Kafka
+|===
+
+`
+	if b.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", b.String(), want)
+	}
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// repoProfile is an anonymized description of a repository, it holds counts
+// only, no titles, names, tags or paths, so it can be shared with the
+// maintainers of the template
+type repoProfile struct {
+	Generated string `json:"generated"`
+	Files     int    `json:"files"`
+	Valid     int    `json:"valid"`
+	Invalid   int    `json:"invalid"`
+	// Types and Statuses count the valid records, custom status names are
+	// reported as other
+	Types    map[string]int `json:"types"`
+	Statuses map[string]int `json:"statuses"`
+	Age      map[string]int `json:"age"`
+	Tags     int            `json:"distinctTags"`
+	Authors  int            `json:"distinctAuthors"`
+	// Formats counts the notations in use, e.g. metadata tables and attributes
+	Formats map[string]int `json:"formats"`
+	// Violations counts the first problem of every file under --strict rules
+	Violations map[string]int `json:"violations"`
+	// Metadata counts records using each optional metadata key
+	Metadata map[string]int `json:"metadata"`
+	// Sections counts records having each section of the skeleton, other
+	// sections are summed as custom
+	Sections map[string]int  `json:"sections"`
+	Features map[string]bool `json:"features"`
+}
+
+func runInspect(args []string) error {
+	fs := flag.NewFlagSet("inspect", flag.ExitOnError)
+	dir := fs.String("dir", settings.Dir, "directory containing ADR files")
+	output := fs.String("output", "", "file to write the profile to, as yaml when it ends in .yaml or .yml, defaults to stdout")
+	fs.Parse(args)
+
+	p := repoProfile{
+		Generated:  time.Now().Format("2006-01-02"),
+		Types:      map[string]int{},
+		Statuses:   map[string]int{},
+		Age:        map[string]int{},
+		Formats:    map[string]int{},
+		Violations: map[string]int{},
+		Metadata:   map[string]int{},
+		Sections:   map[string]int{},
+		Features:   map[string]bool{},
+	}
+
+	entries, err := ioutil.ReadDir(*dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.IsDir() || !isRecordFile(e.Name()) {
+			continue
+		}
+		p.Files++
+
+		body, err := ioutil.ReadFile(path.Join(*dir, e.Name()))
+		if err != nil {
+			return err
+		}
+		inspectFormats(&p, e.Name(), string(body))
+	}
+
+	// the strict scan finds lint findings too, the default one the records
+	previous := parsing
+	parsing = parseStrict
+	_, strictErrs, err := scanADRs(*dir)
+	parsing = previous
+	if err != nil {
+		return err
+	}
+	for _, e := range strictErrs {
+		p.Violations[violationKind(e)]++
+	}
+
+	adrs, _, err := scanADRs(*dir)
+	if err != nil {
+		return err
+	}
+	p.Valid = len(adrs)
+	p.Invalid = p.Files - p.Valid
+
+	skeleton := skeletonSections()
+	tags := map[string]bool{}
+	authors := map[string]bool{}
+	now := time.Now()
+	for _, a := range adrs {
+		p.Types[typeByName(a.Meta.Type).Key]++
+
+		status := a.Meta.Status
+		switch {
+		case status == "":
+			status = "none"
+		case isSuperseded(a):
+			status = "Superseded"
+		case !containsFold(typeByName(a.Meta.Type).statuses(), status):
+			status = "other"
+		}
+		p.Statuses[status]++
+
+		p.Age[ageBucket(now.Sub(a.Meta.Date))]++
+		for _, t := range a.Meta.Tags {
+			tags[strings.ToLower(t)] = true
+		}
+		for _, au := range a.Meta.Authors {
+			authors[strings.ToLower(au)] = true
+		}
+
+		for key, used := range map[string]bool{
+			"Cost":      len(a.Meta.Cost) > 0,
+			"Impact":    a.Meta.Impact != "",
+			"Incidents": len(a.Meta.Incidents) > 0,
+			"Outcome":   a.Meta.Outcome != "",
+			"Reviewed":  !a.Meta.Reviewed.IsZero(),
+		} {
+			if used {
+				p.Metadata[key]++
+			}
+		}
+
+		body, err := ioutil.ReadFile(a.Meta.Path)
+		if err != nil {
+			return err
+		}
+		for _, s := range splitSections(string(body)) {
+			if s.Title == "" {
+				continue
+			}
+			if skeleton[strings.ToLower(s.Title)] {
+				p.Sections[s.Title]++
+			} else {
+				p.Sections["custom"]++
+			}
+		}
+	}
+	p.Tags = len(tags)
+	p.Authors = len(authors)
+
+	inspectFeatures(&p, *dir)
+	setResults(p)
+
+	format := "json"
+	if ext := path.Ext(*output); ext == ".yaml" || ext == ".yml" {
+		format = "yaml"
+	}
+
+	return writeOutput(*output, func(w io.Writer) error {
+		return encodeValue(w, format, p)
+	})
+}
+
+func inspectFormats(p *repoProfile, name string, body string) {
+	if strings.Contains(body, "\r\n") {
+		p.Formats["crlf"]++
+	}
+	body = strings.Replace(body, "\r\n", "\n", -1)
+	if _, err := typeForFile(name).index(name); err != nil {
+		p.Formats["nonstandard-filename"]++
+	}
+
+	b := findMetadata(strings.Split(body, "\n"))
+	switch {
+	case b.Start < 0:
+		p.Formats["no-metadata"]++
+		return
+	case b.FrontMatter:
+		p.Formats["metadata-front-matter"]++
+	case b.Markdown:
+		p.Formats["metadata-markdown"]++
+	case b.Attributes:
+		p.Formats["metadata-attributes"]++
+	default:
+		p.Formats["metadata-table"]++
+	}
+
+	seen := map[string]bool{}
+	for _, r := range b.Rows {
+		if seen[r.Key] {
+			p.Formats["repeated-rows"]++
+		}
+		seen[r.Key] = true
+		// a value cell on the line after its key is not a continuation, nor
+		// are the lines of a front matter list
+		if !b.FrontMatter && strings.Contains(r.Value, "\n") {
+			p.Formats["multi-line-cells"]++
+		}
+		if r.Key == "Author" && structuredAuthors(r.Value) {
+			p.Formats["structured-authors"]++
+		}
+	}
+}
+
+// structuredAuthors tells whether an Author value gives an email or team, the
+// @<user> placeholder of new records does not count
+func structuredAuthors(value string) bool {
+	for _, v := range splitValues(value) {
+		if v == "@<user>" {
+			continue
+		}
+		if p := parsePerson(v); p.Email != "" || p.Team != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// violationKind names the kind of a parse error without the file or values
+func violationKind(err error) string {
+	var status *ErrInvalidStatus
+	var missing *ErrMissingMetadata
+	var invalid *ErrInvalidMetadata
+	var duplicate *ErrDuplicateIndex
+	var reference *ErrInvalidReference
+	var conflict *ErrConflictingDecision
+	var section *ErrMissingSection
+
+	switch {
+	case errors.As(err, &status):
+		return "invalid-status"
+	case errors.As(err, &missing):
+		return "missing-" + strings.ToLower(missing.Key)
+	case errors.As(err, &invalid) && strings.HasPrefix(invalid.Reason, "unexpected metadata key"):
+		return "unexpected-key"
+	case errors.As(err, &invalid) && strings.HasPrefix(invalid.Reason, "repeated metadata key"):
+		return "repeated-key"
+	case errors.As(err, &invalid):
+		return "invalid-" + strings.ToLower(attributeName(invalid.Key))
+	case errors.As(err, &duplicate):
+		return "duplicate-index"
+	case errors.As(err, &reference):
+		return "invalid-reference"
+	case errors.As(err, &conflict):
+		return "conflicting-decision"
+	case errors.As(err, &section) && section.Empty:
+		return "empty-section"
+	case errors.As(err, &section):
+		return "missing-section"
+	case strings.HasPrefix(err.Error(), "missing = Title"):
+		return "missing-title"
+	case strings.HasPrefix(err.Error(), "invalid filename"), strings.HasPrefix(err.Error(), "invalid file sequence"):
+		return "invalid-filename"
+	}
+
+	return "other"
+}
+
+func ageBucket(d time.Duration) string {
+	years := d.Hours() / 24 / 365
+	switch {
+	case years < 1:
+		return "under 1 year"
+	case years < 2:
+		return "1-2 years"
+	case years < 5:
+		return "2-5 years"
+	}
+
+	return "over 5 years"
+}
+
+// defaultSkeletonSections are the sections of the bundled skeleton, they count
+// as skeleton sections when the configured one cannot be read
+var defaultSkeletonSections = []string{"Context", "Context and Problem Statement", "Design", "Decision", "Consequences", "Outcome"}
+
+// skeletonSections returns the lower cased section titles of the ADR skeleton
+func skeletonSections() map[string]bool {
+	titles := map[string]bool{}
+
+	body, err := ioutil.ReadFile(typeByName("").Skeleton)
+	if err != nil {
+		for _, t := range defaultSkeletonSections {
+			titles[strings.ToLower(t)] = true
+		}
+		return titles
+	}
+	for _, s := range splitSections(string(body)) {
+		if s.Title != "" {
+			titles[strings.ToLower(s.Title)] = true
+		}
+	}
+
+	return titles
+}
+
+func inspectFeatures(p *repoProfile, dir string) {
+	exists := func(file string) bool {
+		_, err := os.Stat(file)
+		return err == nil
+	}
+
+	p.Features["customTypes"] = len(cfg.Types) > 0
+	p.Features["profiles"] = len(cfg.Profiles) > 0
+	p.Features["credentials"] = len(cfg.Credentials) > 0
+	p.Features["siteURL"] = cfg.SiteURL != ""
+	p.Features["commitRequireRef"] = len(cfg.Commit.RequireRef) > 0
+	p.Features["httpTuned"] = cfg.HTTP != defaultHTTPConfig()
+	p.Features["checksums"] = exists(path.Join(dir, checksumManifest))
+	p.Features["quarantine"] = exists(path.Join(dir, quarantineDir, quarantineManifest))
+	p.Features["readmeTemplate"] = exists(settings.Template)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInspectFormats(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		body string
+		want map[string]int
+	}{
+		{"table", "0001-a.adoc", recordBody("|Status |Approved", "|Author |@<user>"), map[string]int{"metadata-table": 1}},
+		{"cell on the next line", "0001-a.adoc", recordBody("|Status", "|Approved"), map[string]int{"metadata-table": 1}},
+		{"continued cell", "0001-a.adoc", recordBody("|Author |@alice", "@bob"), map[string]int{"metadata-table": 1, "multi-line-cells": 1}},
+		{"structured authors", "0001-a.adoc", recordBody("|Author |@alice <alice@example.com>, @bob"), map[string]int{"metadata-table": 1, "structured-authors": 1}},
+		{"repeated rows and crlf", "0001-a.adoc", "= A\r\n\r\n|===\r\n|Metadata |Value\r\n|Tags |a\r\n|Tags |b\r\n|===\r\n", map[string]int{"metadata-table": 1, "repeated-rows": 1, "crlf": 1}},
+		{"attributes", "0001-a.adoc", "= A\n:status: Approved\n", map[string]int{"metadata-attributes": 1}},
+		{"markdown", "0001-a.md", "# A\n\n| Metadata | Value |\n|---|---|\n| Status | Approved |\n", map[string]int{"metadata-markdown": 1}},
+		{"front matter", "0001-a.md", "---\nauthors:\n  - \"@alice\"\n  - \"@bob\"\n---\n# A\n", map[string]int{"metadata-front-matter": 1}},
+		{"no metadata", "a.adoc", "= A\n\nText\n", map[string]int{"no-metadata": 1, "nonstandard-filename": 1}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := repoProfile{Formats: map[string]int{}}
+			inspectFormats(&p, test.file, test.body)
+			if !reflect.DeepEqual(p.Formats, test.want) {
+				t.Errorf("Formats = %v, want %v", p.Formats, test.want)
+			}
+		})
+	}
+}
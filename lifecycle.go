@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// lifecycles give statuses their meaning, pending decisions await a verdict,
+// active ones are in force and terminal ones no longer apply
+const (
+	lifecyclePending  = "pending"
+	lifecycleActive   = "active"
+	lifecycleTerminal = "terminal"
+)
+
+// statusLifecycles map the statuses to their lifecycle, lifecycle in the config
+// adds to them, e.g. for the statuses of other record types
+var statusLifecycles = map[string]string{
+	"Proposed":              lifecyclePending,
+	"Approved":              lifecycleActive,
+	"Partially Implemented": lifecycleActive,
+	"Implemented":           lifecycleActive,
+	"Rejected":              lifecycleTerminal,
+	"Deprecated":            lifecycleTerminal,
+	"Superseded":            lifecycleTerminal,
+}
+
+// lifecycleOf returns the lifecycle of status, statuses such as "Superseded by
+// ADR-12" are terminal and statuses without a lifecycle, like the empty one of
+// notes, return the empty string
+func lifecycleOf(status string) string {
+	if l, ok := statusLifecycles[status]; ok {
+		return l
+	}
+	if strings.HasPrefix(status, "Superseded") {
+		return lifecycleTerminal
+	}
+
+	return ""
+}
+
+// statusesIn lists the valid statuses of a lifecycle in their configured order
+func statusesIn(lifecycle string) []string {
+	statuses := []string{}
+	for _, s := range validStatus {
+		if lifecycleOf(s) == lifecycle {
+			statuses = append(statuses, s)
+		}
+	}
+
+	return statuses
+}
+
+// ByLifecycle returns the records whose status is in lifecycle
+func (c *Catalog) ByLifecycle(lifecycle string) []*ADR {
+	return c.Filter(func(a *ADR) bool {
+		return lifecycleOf(a.Meta.Status) == lifecycle
+	}).ADRs
+}
+
+// applyLifecycles adds the lifecycle section of the config to the defaults
+func applyLifecycles(configured map[string]string) error {
+	statuses := []string{}
+	for s := range configured {
+		statuses = append(statuses, s)
+	}
+	sort.Strings(statuses)
+
+	for _, s := range statuses {
+		l := strings.ToLower(configured[s])
+		switch l {
+		case lifecyclePending, lifecycleActive, lifecycleTerminal:
+			statusLifecycles[s] = l
+		default:
+			return fmt.Errorf("invalid lifecycle %q of status %s in %s, expected pending, active or terminal", configured[s], s, configFile)
+		}
+	}
+
+	return nil
+}
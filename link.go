@@ -0,0 +1,154 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os/exec"
+	"path"
+	"runtime"
+	"strings"
+)
+
+func runLink(args []string) error {
+	fs := flag.NewFlagSet("link", flag.ExitOnError)
+	dir := fs.String("dir", settings.Dir, "directory containing ADR files")
+	form := fs.String("form", "all", "link form to print: path, blob, site or all")
+	remote := fs.String("remote", "origin", "git remote used to build the blob URL")
+	copyLink := fs.Bool("copy", false, "copy the link to the clipboard, requires a single -form")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: link [flags] <index>")
+	}
+
+	adr, err := resolveFromDir(*dir, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	links := []struct {
+		form string
+		link func() (string, error)
+	}{
+		{"path", func() (string, error) { return repoPath(adr.Meta.Path) }},
+		{"blob", func() (string, error) { return blobLink(*remote, adr.Meta.Path) }},
+		{"site", func() (string, error) {
+			if cfg.SiteURL == "" {
+				return "", fmt.Errorf("no siteURL configured in %s", configFile)
+			}
+			return siteLink(adr), nil
+		}},
+	}
+
+	if *copyLink && *form == "all" {
+		return fmt.Errorf("-copy needs a single -form")
+	}
+
+	found := false
+	for _, l := range links {
+		if *form != "all" && *form != l.form {
+			continue
+		}
+		found = true
+
+		link, err := l.link()
+		if err != nil {
+			if *form == "all" {
+				continue
+			}
+			return err
+		}
+
+		if *form == "all" {
+			fmt.Printf("%s\t%s\n", l.form, link)
+			continue
+		}
+
+		fmt.Println(link)
+		if *copyLink {
+			return copyToClipboard(link)
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("unknown link form %q", *form)
+	}
+
+	return nil
+}
+
+// repoPath returns file relative to the root of the git work tree
+func repoPath(file string) (string, error) {
+	prefix, err := git("rev-parse", "--show-prefix")
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(prefix, file), nil
+}
+
+// blobLink points at file in the commit currently checked out, so links stay
+// valid when the file later moves or changes
+func blobLink(remote string, file string) (string, error) {
+	r, err := remoteFor(remote)
+	if err != nil {
+		return "", err
+	}
+
+	sha, err := git("rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+
+	p, err := repoPath(file)
+	if err != nil {
+		return "", err
+	}
+
+	blob := "blob"
+	if strings.Contains(r.Host, "gitlab") {
+		blob = "-/blob"
+	}
+
+	return fmt.Sprintf("https://%s/%s/%s/%s/%s", r.Host, r.Path(), blob, sha, p), nil
+}
+
+func renderedPath(adr *ADR) string {
+	return strings.TrimSuffix(adr.Meta.Path, path.Ext(adr.Meta.Path)) + ".html"
+}
+
+// siteLink returns the URL of the published page or an empty string when no
+// site is configured
+func siteLink(adr *ADR) string {
+	if cfg.SiteURL == "" {
+		return ""
+	}
+
+	return pageURL(cfg.SiteURL, adr)
+}
+
+func pageURL(site string, adr *ADR) string {
+	return strings.TrimSuffix(site, "/") + "/" + renderedPath(adr)
+}
+
+func copyToClipboard(text string) error {
+	candidates := [][]string{{"wl-copy"}, {"xclip", "-selection", "clipboard"}, {"xsel", "--clipboard", "--input"}}
+	switch runtime.GOOS {
+	case "darwin":
+		candidates = [][]string{{"pbcopy"}}
+	case "windows":
+		candidates = [][]string{{"clip"}}
+	}
+
+	for _, c := range candidates {
+		if _, err := exec.LookPath(c[0]); err != nil {
+			continue
+		}
+
+		cmd := exec.Command(c[0], c[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		return cmd.Run()
+	}
+
+	return fmt.Errorf("no clipboard tool found, install one of wl-copy, xclip or xsel")
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"text/tabwriter"
+)
+
+// defaultSections are the body sections lint requires of ADRs unless
+// lint.sections says otherwise
+var defaultSections = []string{"Context", "Decision", "Consequences"}
+
+// LintConfig sets the sections lint requires in the body of ADRs, other
+// record types list theirs under their own sections
+type LintConfig struct {
+	Sections []string `yaml:"sections"`
+}
+
+// requiredSections are the body sections records of t must have
+func requiredSections(t *RecordType) []string {
+	if len(t.Sections) > 0 {
+		return t.Sections
+	}
+	if t.Key != adrTypeKey {
+		return nil
+	}
+	if len(cfg.Lint.Sections) > 0 {
+		return cfg.Lint.Sections
+	}
+
+	return defaultSections
+}
+
+// lintSections reports the required sections missing from body and those
+// holding nothing but skeleton hints, a heading starting with the name of a
+// section counts and the text of its subsections is its own
+func lintSections(file string, body string, required []string) []error {
+	sections := splitSections(body)
+	lines := strings.Split(body, "\n")
+	headingLine := func(title string) int {
+		for i, line := range lines {
+			if m := sectionHeadingRegex.FindStringSubmatch(line); m != nil && strings.TrimSpace(m[2]) == title {
+				return i + 1
+			}
+		}
+		return 0
+	}
+
+	errs := []error{}
+	for _, name := range required {
+		var found *Section
+		empty := true
+		for i := range sections {
+			s := sections[i]
+			if s.Title == "" || !strings.HasPrefix(strings.ToLower(s.Title), strings.ToLower(name)) {
+				continue
+			}
+			if found == nil {
+				found = &sections[i]
+			}
+			text := hasText(s.Body)
+			for _, sub := range sections[i+1:] {
+				if sub.Level <= s.Level {
+					break
+				}
+				text = text || hasText(sub.Body)
+			}
+			if text {
+				empty = false
+				break
+			}
+		}
+
+		switch {
+		case found == nil:
+			errs = append(errs, &ErrMissingSection{Path: file, Section: name})
+		case empty:
+			errs = append(errs, &ErrMissingSection{Path: file, Line: headingLine(found.Title), Section: found.Title, Empty: true})
+		}
+	}
+
+	return errs
+}
+
+// hasText tells whether a section body holds more than blank lines and the
+// [bracketed] hints of the skeleton
+func hasText(body string) bool {
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !(strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]")) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// runLint checks that every record has the body sections its type requires,
+// Context, Decision and Consequences for ADRs by default
+func runLint(args []string) error {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	dir := fs.String("dir", settings.Dir, "directory containing ADR files")
+	fs.Parse(args)
+
+	files := fs.Args()
+	if len(files) == 0 {
+		entries, err := ioutil.ReadDir(*dir)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if !e.IsDir() && isRecordFile(e.Name()) {
+				files = append(files, path.Join(*dir, e.Name()))
+			}
+		}
+	}
+
+	results := []validation{}
+	failed := 0
+	for _, file := range files {
+		body, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		v := validation{File: file, Valid: true, Findings: []Finding{}}
+		rp := &recordParser{mode: parseLenient}
+		adr, err := rp.parse(file, body)
+		if err != nil {
+			// validate reports why, the sections are unknown without a type
+			v.Findings = append(v.Findings, newFinding(severityError, err))
+		} else {
+			for _, err := range lintSections(file, string(body), requiredSections(typeByName(adr.Meta.Type))) {
+				v.Findings = append(v.Findings, newFinding(severityError, err))
+			}
+		}
+		if len(v.Findings) > 0 {
+			v.Valid = false
+			failed++
+		}
+		results = append(results, v)
+	}
+	setResults(results)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	p := newPainter(os.Stdout)
+	for _, v := range results {
+		if v.Valid {
+			fmt.Fprintf(w, "%s %s\n", v.File, p.ok("ok"))
+			continue
+		}
+		fmt.Fprintf(w, "%s\n", v.File)
+		for _, f := range v.Findings {
+			line := ""
+			if f.Line > 0 {
+				line = fmt.Sprintf("line %d", f.Line)
+			}
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", p.error(f.Severity), line, f.Rule, f.Message)
+		}
+	}
+	w.Flush()
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d records in %s lack required sections", failed, len(results), *dir)
+	}
+
+	return nil
+}
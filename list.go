@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+func runList(args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	dir := fs.String("dir", settings.Dir, "directory containing ADR files")
+	format := fs.String("format", "text", "output format: text, csv, json or yaml, json and yaml hold the parsed records as served by /api/records")
+	filter := filterFlags(fs)
+	fs.Parse(args)
+
+	f, err := filter()
+	if err != nil {
+		return err
+	}
+	adrs, err := loadADRs(*dir)
+	if err != nil {
+		return err
+	}
+	adrs = searchADRs(f.apply(adrs), "")
+	setResults(adrs)
+
+	switch *format {
+	case "json", "yaml":
+		return encodeValue(os.Stdout, *format, adrs)
+	case "csv":
+		return writeListCSV(os.Stdout, adrs)
+	case "text":
+	default:
+		return fmt.Errorf("unsupported list format %q, expected text, csv, json or yaml", *format)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	defer w.Flush()
+	p := newPainter(os.Stdout)
+
+	for _, a := range adrs {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", recordLabel(a), p.status(a.Meta.Status), a.Meta.Date.Format("2006-01-02"), strings.Join(a.Meta.Tags, ", "), a.Heading)
+	}
+
+	return nil
+}
+
+// writeListCSV writes the metadata of the records for spreadsheets, authors and
+// tags are comma separated within their cell
+func writeListCSV(w io.Writer, adrs []*ADR) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"index", "title", "date", "status", "authors", "tags", "path"})
+	for _, a := range adrs {
+		date := ""
+		if !a.Meta.Date.IsZero() {
+			date = a.Meta.Date.Format("2006-01-02")
+		}
+		cw.Write([]string{
+			strconv.Itoa(a.Meta.Index),
+			a.Heading,
+			date,
+			a.Meta.Status,
+			strings.Join(a.Meta.Authors, ", "),
+			strings.Join(a.Meta.Tags, ", "),
+			a.Meta.Path,
+		})
+	}
+	cw.Flush()
+
+	return cw.Error()
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const lockFile = ".adr.lock"
+
+// lockedCommands write files and are serialized with a repository wide lock, so
+// a watch, a pre-commit hook and a manual run never write the same output at
+// the same time and two commands never take the same index. Commands missing
+// here still take the lock for each write, see lockRepo
+var lockedCommands = map[string]bool{
+	"build":        true,
+	"rollup":       true,
+	"export":       true,
+	"init":         true,
+	"commit":       true,
+	"migrate":      true,
+	"new":          true,
+	"propose":      true,
+	"quarantine":   true,
+	"checksums":    true,
+	"supersede":    true,
+	"renumber":     true,
+	"site":         true,
+	"feed":         true,
+	"import":       true,
+	"mail":         true,
+	"notes":        true,
+	"trends":       true,
+	"gen-fixtures": true,
+	"config":       true,
+	"graph":        true,
+	"inspect":      true,
+}
+
+var repoLock struct {
+	sync.Mutex
+	held   int
+	unlock func()
+}
+
+// lockRepo takes the repository lock unless this process holds it already,
+// the returned function releases it once every holder did. The writing
+// helpers take it so new writers are serialized without being listed
+func lockRepo() (func(), error) {
+	repoLock.Lock()
+	defer repoLock.Unlock()
+
+	if repoLock.held == 0 {
+		unlock, err := acquireLock(repoLockPath())
+		if err != nil {
+			return nil, err
+		}
+		repoLock.unlock = unlock
+	}
+	repoLock.held++
+
+	return func() {
+		repoLock.Lock()
+		defer repoLock.Unlock()
+
+		repoLock.held--
+		if repoLock.held == 0 {
+			repoLock.unlock()
+			repoLock.unlock = nil
+		}
+	}, nil
+}
+
+// repoLockPath returns the lock file in the git directory, shared by the work
+// trees of a repository and never shown as untracked, or in the current
+// directory outside a repository
+func repoLockPath() string {
+	dir, err := git("rev-parse", "--git-common-dir")
+	if err != nil || dir == "" {
+		return lockFile
+	}
+
+	return filepath.Join(dir, "adr.lock")
+}
+
+// writeOutput renders to stdout when output is empty, otherwise to a temporary
+// file next to output that replaces it only once rendering succeeded
+func writeOutput(output string, render func(w io.Writer) error) error {
+	if output == "" {
+		return render(os.Stdout)
+	}
+	unlock, err := lockRepo()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	f, err := ioutil.TempFile(filepath.Dir(output), "."+filepath.Base(output)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	err = render(f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(output); err == nil {
+		mode = info.Mode()
+	}
+	err = os.Chmod(f.Name(), mode)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), output)
+}
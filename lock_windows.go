@@ -0,0 +1,33 @@
+//go:build windows
+// +build windows
+
+package main
+
+import (
+	"syscall"
+	"time"
+)
+
+// errSharingViolation is ERROR_SHARING_VIOLATION, which package syscall does not define
+const errSharingViolation syscall.Errno = 32
+
+// acquireLock opens the lock file without sharing, which windows refuses while
+// another process holds it open, and retries until it succeeds
+func acquireLock(path string) (func(), error) {
+	name, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		h, err := syscall.CreateFile(name, syscall.GENERIC_READ|syscall.GENERIC_WRITE, 0, nil, syscall.OPEN_ALWAYS, syscall.FILE_ATTRIBUTE_NORMAL, 0)
+		if err == nil {
+			return func() { syscall.CloseHandle(h) }, nil
+		}
+		if err != errSharingViolation {
+			return nil, err
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+}
@@ -0,0 +1,366 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// mailThread is a forwarded decision email, the addresses of every message of
+// the thread and its text with forwarded messages inlined
+type mailThread struct {
+	Subject      string
+	Date         time.Time
+	From         *mail.Address
+	Participants []*mail.Address
+	Text         string
+	// Attachments are the names of the files the draft leaves out
+	Attachments []string
+}
+
+// mailDraft is a record drafted from an email
+type mailDraft struct {
+	Source       string   `json:"source"`
+	Path         string   `json:"path"`
+	Title        string   `json:"title"`
+	Participants []string `json:"participants"`
+}
+
+var (
+	mailSubjectPrefix = regexp.MustCompile(`^(?i)((re|fwd?|aw|wg|tr|decision|adr)\s*:\s*|\[[^\]]*\]\s*)+`)
+	// mailQuotedHeader is a header line of a message forwarded inline, e.g.
+	// From: Jane <jane@example.com> below ---------- Forwarded message
+	mailQuotedHeader = regexp.MustCompile(`^>*\s*\*?(From|To|Cc|Von|An)\*?:\s*(.+)$`)
+)
+
+// mailHeader is the header of a message or of a part of one
+type mailHeader interface {
+	Get(key string) string
+}
+
+// readMail reads a raw RFC822 message, the participants are the senders and
+// recipients of the message, of messages attached to it and of those quoted in
+// its text
+func readMail(r io.Reader) (*mailThread, error) {
+	msg, err := mail.ReadMessage(bufio.NewReader(r))
+	if err != nil {
+		return nil, fmt.Errorf("not an email: %s", err)
+	}
+
+	t := &mailThread{}
+	dec := &mime.WordDecoder{}
+	t.Subject, err = dec.DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		t.Subject = msg.Header.Get("Subject")
+	}
+	t.Subject = strings.TrimSpace(mailSubjectPrefix.ReplaceAllString(strings.TrimSpace(t.Subject), ""))
+	if date, err := msg.Header.Date(); err == nil {
+		t.Date = date
+	}
+	if from, err := msg.Header.AddressList("From"); err == nil && len(from) > 0 {
+		t.From = from[0]
+	}
+
+	seen := map[string]bool{}
+	add := func(list []*mail.Address) {
+		for _, a := range list {
+			key := strings.ToLower(a.Address)
+			if !seen[key] {
+				seen[key] = true
+				t.Participants = append(t.Participants, a)
+			}
+		}
+	}
+	var walk func(h mail.Header)
+	walk = func(h mail.Header) {
+		for _, key := range []string{"From", "To", "Cc"} {
+			if list, err := h.AddressList(key); err == nil {
+				add(list)
+			}
+		}
+	}
+	walk(msg.Header)
+
+	text, err := t.part(msg.Header, msg.Body, walk)
+	if err != nil {
+		return nil, err
+	}
+	t.Text = strings.TrimSpace(text)
+
+	for _, line := range strings.Split(t.Text, "\n") {
+		if m := mailQuotedHeader.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			if list, err := mail.ParseAddressList(strings.Replace(m[2], "mailto:", "", -1)); err == nil {
+				add(list)
+			}
+		}
+	}
+
+	return t, nil
+}
+
+// part returns the text of a message part, the plain text of an alternative,
+// the text of every part of a mixed message and attached messages after their
+// headers, other attachments are only named
+func (t *mailThread) part(h mailHeader, body io.Reader, nested func(mail.Header)) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+	switch strings.ToLower(h.Get("Content-Transfer-Encoding")) {
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	}
+
+	switch {
+	case strings.HasPrefix(mediaType, "multipart/"):
+		mr := multipart.NewReader(body, params["boundary"])
+		texts := []string{}
+		plain, html := "", ""
+		for {
+			p, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return "", err
+			}
+			text, err := t.part(p.Header, p, nested)
+			if err != nil {
+				return "", err
+			}
+			partType, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+			switch {
+			case mediaType != "multipart/alternative":
+				if text != "" {
+					texts = append(texts, text)
+				}
+			case partType == "text/plain" || partType == "":
+				plain = text
+			default:
+				html = text
+			}
+		}
+		if mediaType == "multipart/alternative" {
+			if plain != "" {
+				return plain, nil
+			}
+			return html, nil
+		}
+		return strings.Join(texts, "\n\n"), nil
+
+	case mediaType == "message/rfc822":
+		msg, err := mail.ReadMessage(bufio.NewReader(body))
+		if err != nil {
+			return "", err
+		}
+		nested(msg.Header)
+		text, err := t.part(msg.Header, msg.Body, nested)
+		if err != nil {
+			return "", err
+		}
+		headers := []string{"---------- Attached message ----------"}
+		dec := &mime.WordDecoder{}
+		for _, key := range []string{"From", "Date", "Subject", "To", "Cc"} {
+			if v := msg.Header.Get(key); v != "" {
+				if decoded, err := dec.DecodeHeader(v); err == nil {
+					v = decoded
+				}
+				headers = append(headers, key+": "+v)
+			}
+		}
+		return strings.Join(headers, "\n") + "\n\n" + text, nil
+	}
+
+	if name := mailFileName(h); name != "" || !strings.HasPrefix(mediaType, "text/") {
+		if name == "" {
+			name = mediaType
+		}
+		t.Attachments = append(t.Attachments, name)
+		return "", nil
+	}
+
+	raw, err := ioutil.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+	switch strings.ToLower(params["charset"]) {
+	case "iso-8859-1", "latin1", "windows-1252":
+		runes := make([]rune, len(raw))
+		for i, b := range raw {
+			runes[i] = rune(b)
+		}
+		raw = []byte(string(runes))
+	}
+	text := strings.Replace(string(raw), "\r\n", "\n", -1)
+	if mediaType == "text/html" {
+		lines := []string{}
+		for _, block := range readHTML([]byte(text)).Blocks {
+			lines = append(lines, stripInlineMarks(block.Text))
+		}
+		text = strings.Join(lines, "\n\n")
+	}
+
+	return strings.TrimSpace(text), nil
+}
+
+func mailFileName(h mailHeader) string {
+	if _, params, err := mime.ParseMediaType(h.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+		return params["filename"]
+	}
+	if _, params, err := mime.ParseMediaType(h.Get("Content-Type")); err == nil {
+		return params["name"]
+	}
+
+	return ""
+}
+
+// threadSection attaches the thread to the draft as context, the text goes in
+// a literal block so the markup of the email is kept as it is
+func (t *mailThread) threadSection(inbox []string) string {
+	b := &strings.Builder{}
+	b.WriteString("\n== Email thread\n\n")
+	b.WriteString("Drafted from the email ")
+	if !t.Date.IsZero() {
+		fmt.Fprintf(b, "of %s ", t.Date.Format(dateLayout))
+	}
+	fmt.Fprintf(b, "%q, the decision was discussed by:\n\n", t.Subject)
+	for _, a := range t.participants(inbox) {
+		fmt.Fprintf(b, "* %s\n", mailPerson(a))
+	}
+	if len(t.Attachments) > 0 {
+		fmt.Fprintf(b, "\nAttachments left out: %s\n", strings.Join(t.Attachments, ", "))
+	}
+	fmt.Fprintf(b, "\n....\n%s\n....\n", strings.Replace(t.Text, "\n....\n", "\n. . . .\n", -1))
+
+	return b.String()
+}
+
+// participants leaves out the addresses of the inbox the email was sent to
+func (t *mailThread) participants(inbox []string) []*mail.Address {
+	people := []*mail.Address{}
+	for _, a := range t.Participants {
+		if !containsFold(inbox, a.Address) {
+			people = append(people, a)
+		}
+	}
+
+	return people
+}
+
+// mailPerson writes an address the way Author rows name people, Name <email>
+func mailPerson(a *mail.Address) string {
+	if a.Name == "" {
+		return a.Address
+	}
+
+	return fmt.Sprintf("%s <%s>", a.Name, a.Address)
+}
+
+// runMail drafts a Proposed record from every forwarded email given as a file,
+// or from the one on stdin, so a mail rule can pipe decisions into the catalog
+func runMail(args []string) error {
+	fs := flag.NewFlagSet("mail", flag.ExitOnError)
+	dir := fs.String("dir", settings.Dir, "directory containing ADR files")
+	recordType := fs.String("type", adrTypeKey, "record type of the drafted records")
+	template := fs.String("template", "", "skeleton used for the drafts, defaults to the skeleton of the record type")
+	inbox := fs.String("inbox", "", "comma separated addresses of the inbox decisions are forwarded to, left out of the participants")
+	tags := fs.String("tags", placeholderTag, "comma separated tags of the drafts")
+	dryRun := fs.Bool("dry-run", false, "print the drafts instead of writing them")
+	fs.Parse(args)
+
+	t := typeByName(*recordType)
+	if t == nil {
+		return fmt.Errorf("unknown record type %q", *recordType)
+	}
+	if *template == "" {
+		*template = t.Skeleton
+	}
+	inboxes := []string{}
+	if *inbox != "" {
+		inboxes = parseCommaList(*inbox)
+	}
+	sources := fs.Args()
+	if len(sources) == 0 {
+		sources = []string{"-"}
+	}
+
+	drafts := []mailDraft{}
+	for _, source := range sources {
+		var r io.Reader = os.Stdin
+		if source != "-" {
+			body, err := ioutil.ReadFile(source)
+			if err != nil {
+				return err
+			}
+			r = bytes.NewReader(body)
+		}
+		thread, err := readMail(r)
+		if err != nil {
+			return fmt.Errorf("%s in %s", err, source)
+		}
+		if thread.Subject == "" {
+			return fmt.Errorf("the email has no subject to title the record with in %s", source)
+		}
+
+		authors := []string{gitAuthor()}
+		if thread.From != nil {
+			authors = []string{mailPerson(thread.From)}
+		}
+		date := thread.Date
+		if date.IsZero() {
+			date = time.Now()
+		}
+		s := scaffold{
+			Type:     t,
+			Title:    thread.Subject,
+			Authors:  authors,
+			Tags:     parseCommaList(*tags),
+			Status:   "Proposed",
+			Date:     date,
+			Template: *template,
+			Appendix: thread.threadSection(inboxes),
+		}
+		draft := mailDraft{Source: source, Title: thread.Subject, Participants: []string{}}
+		for _, a := range thread.participants(inboxes) {
+			draft.Participants = append(draft.Participants, mailPerson(a))
+		}
+
+		if *dryRun {
+			content, err := s.render()
+			if err != nil {
+				return err
+			}
+			fmt.Print(content)
+			drafts = append(drafts, draft)
+			continue
+		}
+		draft.Path, err = createADR(*dir, s)
+		if err != nil {
+			return err
+		}
+		if _, err := parseADR(draft.Path); err != nil {
+			os.Remove(draft.Path)
+			return fmt.Errorf("drafted record does not validate, %s in %s", err, source)
+		}
+		fmt.Printf("%s: %s\n", path.Base(source), draft.Path)
+		drafts = append(drafts, draft)
+	}
+	setResults(drafts)
+
+	return nil
+}
@@ -1,36 +1,100 @@
 package main
 
 import (
-	"bufio"
+	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
 	"path"
 	"regexp"
 	"sort"
-	"strconv"
 	"strings"
 	"text/template"
 	"time"
 )
 
 type ADRMeta struct {
-	Index   int
-	Authors []string
-	Date    time.Time
-	Status  string
-	Tags    []string
-	Path    string
+	Index   int      `json:"index"`
+	Authors []string `json:"authors,omitempty"`
+	// People are the authors with the email and team given in Name <email>
+	// (Team) values, Authors holds their names
+	People []Person  `json:"people,omitempty"`
+	Date   time.Time `json:"date"`
+	Status string    `json:"status,omitempty"`
+	// Approvers are the people, by name or email, and teams whose approval a
+	// pending record waits for
+	Approvers []string `json:"approvers,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+	Path      string   `json:"path"`
+	// Impact is the free form impact rating of the decision, e.g. High
+	Impact string `json:"impact,omitempty"`
+	// Cost holds the one-off and recurring cost estimates of the decision
+	Cost []CostItem `json:"cost,omitempty"`
+	// Outcome is the verdict of the outcome review, one of validOutcomes
+	Outcome string `json:"outcome,omitempty"`
+	// Reviewed is the date the decision was last confirmed to still hold
+	Reviewed time.Time `json:"reviewed"`
+	// Incidents holds incident IDs or URLs that led to the decision
+	Incidents []string `json:"incidents,omitempty"`
+	// Type is the name of the record type, empty for full ADRs
+	Type string `json:"type,omitempty"`
+	// Component is the monorepo component the ADR belongs to, empty outside a rollup
+	Component string `json:"component,omitempty"`
+	// Supersedes and SupersededBy hold record labels, both sides of a local
+	// supersession name each other and a local decision may supersede an
+	// inherited one, org:ADR-3
+	Supersedes   []string `json:"supersedes,omitempty"`
+	SupersededBy []string `json:"supersededBy,omitempty"`
+	// ConflictsWith names records that contradict this one, at most one of a
+	// conflicting pair may be active
+	ConflictsWith []string `json:"conflictsWith,omitempty"`
+	// Decides is the question the record answers, e.g. message-broker, active
+	// records must not decide the same one
+	Decides string `json:"decides,omitempty"`
+	// Scope lists where the decision applies, e.g. service:payments, records
+	// without one apply everywhere
+	Scope []string `json:"scope,omitempty"`
+	// Source is set for records mirrored from another repository, see Include
+	Source *Provenance `json:"source,omitempty"`
+	// Extra holds the rows declared under metadata.keys and those required by
+	// the record type, keyed by row name, nil when the record has none
+	Extra map[string]string `json:"extra,omitempty"`
+}
+
+// MarshalJSON leaves out a zero Date or Reviewed, omitempty keeps struct values
+func (m ADRMeta) MarshalJSON() ([]byte, error) {
+	type meta ADRMeta
+	out := struct {
+		meta
+		Date     *time.Time `json:"date,omitempty"`
+		Reviewed *time.Time `json:"reviewed,omitempty"`
+	}{meta: meta(m)}
+	if !m.Date.IsZero() {
+		out.Date = &m.Date
+	}
+	if !m.Reviewed.IsZero() {
+		out.Reviewed = &m.Reviewed
+	}
+
+	return json.Marshal(out)
 }
 
 type ADR struct {
-	Heading string
-	Meta    ADRMeta
+	Heading string  `json:"title"`
+	Meta    ADRMeta `json:"meta"`
 }
 
+const designNoteType = "Design Note"
+
 var (
-	validStatus = []string{"Approved", "Partially Implemented", "Implemented"}
+	validStatus = []string{"Proposed", "Approved", "Partially Implemented", "Implemented", "Rejected", "Deprecated", "Superseded"}
+	// dateLayout is the time layout of Date and Reviewed values and dateFormat
+	// its spelling in messages, both follow dateLayout in the config
+	dateLayout = "02-01-2006"
+	dateFormat = "DD-MM-YYYY"
 )
 
 func parseCommaList(l string) []string {
@@ -46,77 +110,182 @@ func parseADR(adrPath string) (*ADR, error) {
 
 	body, err := ioutil.ReadFile(adrPath)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
+	return parseADRContent(adrPath, body)
+}
+
+func parseADRContent(adrPath string, body []byte) (*ADR, error) {
+	return (&recordParser{mode: parsing}).parse(adrPath, body)
+}
+
+func (rp *recordParser) parse(adrPath string, body []byte) (*ADR, error) {
 	adr := ADR{
 		Meta: ADRMeta{
 			Path: adrPath,
 		},
 	}
 
-	base := strings.TrimSuffix(path.Base(adrPath), path.Ext(adrPath))
-
-	parts := strings.Split(base, "-")
-	if len(parts) < 2 {
-		return nil, fmt.Errorf("invalid filename %s in %s", base, adrPath)
-	}
-
-	idx, err := strconv.Atoi(parts[0])
-	if err != nil {
-		return nil, fmt.Errorf("invalid file sequence %s in %s", parts[0], adrPath)
-	}
-
-	adr.Meta.Index = idx
-
 	adr.Heading = extractHeader(string(body))
 
-	scanner := bufio.NewScanner(strings.NewReader(string(body)))
-	isMetaDataStart := false
+	// the metadata is either a |Metadata table or document attributes such as
+	// :status: Approved below the title
+	block := findMetadata(strings.Split(string(body), "\n"))
 	metaMap := make(map[string]string)
-
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		if strings.HasPrefix(line, "|Metadata") {
-			isMetaDataStart = true
-			continue
+	metaLines := make(map[string]int)
+	tableLine := block.Start + 1
+	// list keys may be repeated, one Author row per author
+	metaLists := make(map[string][]string)
+	// metaKeys are the keys in the order of the file, so the first bad row is
+	// the one reported
+	metaKeys := []string{}
+	for _, r := range block.Rows {
+		if _, ok := metaMap[r.Key]; !ok {
+			metaKeys = append(metaKeys, r.Key)
 		}
-
-		if isMetaDataStart && strings.HasPrefix(line, "|===") {
-			isMetaDataStart = false
+		if prev, ok := metaMap[r.Key]; ok && isListKey(r.Key) {
+			metaMap[r.Key] = prev + "\n" + r.Value
+		} else {
+			if ok {
+				err := rp.lint(&ErrInvalidMetadata{Path: adrPath, Line: r.Line + 1, Key: r.Key, Value: r.Value, Reason: fmt.Sprintf("repeated metadata key %s replaces the earlier value", r.Key)})
+				if err != nil {
+					return nil, err
+				}
+			}
+			metaMap[r.Key] = r.Value
+			metaLines[r.Key] = r.Line + 1
 		}
+		metaLists[r.Key] = append(metaLists[r.Key], splitValues(r.Value)...)
+	}
+
+	invalid := func(key string, reason string, err error) error {
+		return &ErrInvalidMetadata{Path: adrPath, Line: metaLines[key], Key: key, Value: metaMap[key], Reason: reason, Err: err}
+	}
+	missing := func(key string, recordType string) error {
+		return &ErrMissingMetadata{Path: adrPath, Line: tableLine, Key: key, RecordType: recordType}
+	}
 
-		if isMetaDataStart && strings.Contains(line, "|") {
-			parts := strings.Split(strings.TrimSpace(line), "|")
-			key := strings.TrimSpace(parts[1])
-			value := strings.TrimSpace(parts[2])
-			metaMap[key] = value
-			//log.Printf("Key %s, Value %s", key, value)
+	recordType := typeForFile(adrPath)
+	if name, ok := metaMap["Type"]; ok {
+		if t := typeByName(name); t != nil {
+			recordType = t
+		} else if err := rp.tolerate(invalid("Type", fmt.Sprintf("invalid type %q, must be one of: %s", name, strings.Join(typeNames(), ", ")), nil)); err != nil {
+			return nil, err
 		}
 	}
+	adr.Meta.Type = recordType.metaType()
 
-	if err := scanner.Err(); err != nil {
-		log.Println("Error reading file ", err)
+	idx, err := recordType.index(adrPath)
+	if err != nil {
+		return nil, err
 	}
 
-	for key, value := range metaMap {
+	adr.Meta.Index = idx
+
+	for _, key := range metaKeys {
+		value := metaMap[key]
 		switch key {
 		case "Date":
-			layout := "02-01-2006"
-			t, err := time.Parse(layout, value)
+			t, err := time.Parse(dateLayout, value)
 			if err != nil {
-				return nil, fmt.Errorf("invalid date format, not DD-MM-YYYY: %s", err)
+				if err := rp.tolerate(invalid(key, fmt.Sprintf("invalid date format, not %s: %s", dateFormat, err), err)); err != nil {
+					return nil, err
+				}
+				t = lastChanged(adrPath)
 			}
 			adr.Meta.Date = t
 		case "Author":
-			adr.Meta.Authors = parseCommaList(value)
+			for _, v := range metaLists[key] {
+				p := parsePerson(v)
+				adr.Meta.Authors = append(adr.Meta.Authors, p.Name)
+				adr.Meta.People = append(adr.Meta.People, p)
+			}
 		case "Status":
 			adr.Meta.Status = value
 		case "Tags":
-			adr.Meta.Tags = parseCommaList(value)
+			adr.Meta.Tags = metaLists[key]
+		case "Impact":
+			adr.Meta.Impact = value
+		case "Cost":
+			cost, err := parseCost(strings.Join(metaLists[key], ", "))
+			if err != nil {
+				if err := rp.tolerate(invalid(key, err.Error(), err)); err != nil {
+					return nil, err
+				}
+			}
+			adr.Meta.Cost = cost
+		case "Outcome":
+			if !isValidOutcome(value) {
+				if err := rp.tolerate(invalid(key, fmt.Sprintf("invalid outcome %q, must be one of: %s", value, strings.Join(validOutcomes, ", ")), nil)); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			adr.Meta.Outcome = value
+		case "Reviewed":
+			t, err := time.Parse(dateLayout, value)
+			if err != nil {
+				if err := rp.tolerate(invalid(key, fmt.Sprintf("invalid date format, not %s: %s", dateFormat, err), err)); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			adr.Meta.Reviewed = t
+		case "Incidents":
+			adr.Meta.Incidents = metaLists[key]
+		case "Supersedes":
+			adr.Meta.Supersedes = metaLists[key]
+		case "Superseded by":
+			adr.Meta.SupersededBy = metaLists[key]
+		case "Conflicts with":
+			adr.Meta.ConflictsWith = metaLists[key]
+		case "Decides":
+			adr.Meta.Decides = value
+		case "Approvers":
+			adr.Meta.Approvers = metaLists[key]
+		case "Scope":
+			for _, v := range metaLists[key] {
+				scope, err := parseScope(v)
+				if err != nil {
+					if err := rp.tolerate(invalid(key, err.Error(), err)); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				adr.Meta.Scope = append(adr.Meta.Scope, scope)
+			}
+		case "Type":
+		case "Title":
+			// front matter may carry the title instead of a heading
+			if adr.Heading == "" {
+				adr.Heading = value
+			}
 		default:
-			log.Println("Unexpected meta key", key)
+			if declared := cfg.Metadata.key(key); declared != nil {
+				if reason := declared.check(value); reason != "" {
+					if err := rp.tolerate(invalid(key, reason, nil)); err != nil {
+						return nil, err
+					}
+					continue
+				}
+			}
+			if cfg.Metadata.key(key) != nil || recordType.requires(key) {
+				if adr.Meta.Extra == nil {
+					adr.Meta.Extra = map[string]string{}
+				}
+				adr.Meta.Extra[key] = value
+			} else {
+				err := invalid(key, fmt.Sprintf("unexpected metadata key %s", key), nil)
+				if cfg.Metadata.Strict {
+					err = rp.tolerate(err)
+				} else {
+					err = rp.lint(err)
+				}
+				if err != nil {
+					return nil, err
+				}
+			}
 		}
 
 		//log.Printf("Key %s, Value %s", key, value)
@@ -125,24 +294,56 @@ func parseADR(adrPath string) (*ADR, error) {
 	if adr.Meta.Index == 0 {
 		return nil, fmt.Errorf("invalid ADR Index in %s", adr.Meta.Path)
 	}
-	if adr.Meta.Date.IsZero() {
-		return nil, fmt.Errorf("date is required in %s", adr.Meta.Path)
+	if _, given := metaMap["Date"]; recordType.requires("Date") && adr.Meta.Date.IsZero() && !given {
+		if err := rp.tolerate(missing("Date", "")); err != nil {
+			return nil, err
+		}
+		adr.Meta.Date = lastChanged(adrPath)
 	}
-	if !isValidStatus(adr.Meta.Status) {
-		return nil, fmt.Errorf("invalid status %q, must be one of: %s in %s", adr.Meta.Status, strings.Join(validStatus, ", "), adr.Meta.Path)
+	if (recordType.requires("Status") || adr.Meta.Status != "") && !isValidStatusFor(recordType, adr.Meta.Status) {
+		err := &ErrInvalidStatus{Path: adrPath, Line: metaLines["Status"], Status: adr.Meta.Status, Allowed: recordType.statuses()}
+		if err := rp.tolerate(err); err != nil {
+			return nil, err
+		}
 	}
-	if len(adr.Meta.Authors) == 0 {
-		return nil, fmt.Errorf("authors is required in %s", adr.Meta.Path)
+	if recordType.requires("Author") && len(adr.Meta.Authors) == 0 {
+		if err := rp.tolerate(missing("Author", "")); err != nil {
+			return nil, err
+		}
+		adr.Meta.Authors = []string{"unknown"}
 	}
-	if len(adr.Meta.Tags) == 0 {
-		return nil, fmt.Errorf("tags is required in %s", adr.Meta.Path)
+	if recordType.requires("Tags") && len(adr.Meta.Tags) == 0 {
+		if err := rp.tolerate(missing("Tags", "")); err != nil {
+			return nil, err
+		}
+		adr.Meta.Tags = []string{"untagged"}
+	}
+	for _, key := range recordType.Required {
+		switch key {
+		case "Date", "Author", "Status", "Tags":
+		default:
+			if metaMap[key] == "" {
+				if err := rp.tolerate(missing(key, recordType.Name)); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	if adr.Heading == "" {
+		if err := rp.lint(fmt.Errorf("missing = Title heading in %s", adrPath)); err != nil {
+			return nil, err
+		}
 	}
 
 	return &adr, nil
 }
 
 func isValidStatus(status string) bool {
-	for _, s := range validStatus {
+	return isValidStatusFor(typeByName(""), status)
+}
+
+func isValidStatusFor(t *RecordType, status string) bool {
+	for _, s := range t.statuses() {
 		if status == s {
 			return true
 		}
@@ -151,124 +352,624 @@ func isValidStatus(status string) bool {
 	return false
 }
 
+func typeNames() []string {
+	names := []string{}
+	for _, t := range cfg.types {
+		names = append(names, t.Name)
+	}
+
+	return names
+}
+
+// verifyUniqueIndexes checks indexes per filename convention, types sharing the
+// ADR file names share the ADR numbering
 func verifyUniqueIndexes(adrs []*ADR) error {
-	indexes := map[int]string{}
+	indexes := map[string]string{}
 	for _, a := range adrs {
-		path, ok := indexes[a.Meta.Index]
+		key := fmt.Sprintf("%s/%d", typeByName(a.Meta.Type).Filename, a.Meta.Index)
+		path, ok := indexes[key]
 		if ok {
-			return fmt.Errorf("duplicate index %d, conflict between %s and %s", a.Meta.Index, a.Meta.Path, path)
+			return &ErrDuplicateIndex{Index: a.Meta.Index, Path: a.Meta.Path, Other: path}
 		}
-		indexes[a.Meta.Index] = a.Meta.Path
+		indexes[key] = a.Meta.Path
 	}
 
 	return nil
 }
 
-func renderIndexes(adrs []*ADR) error {
-	tags := map[string]int{}
-	for _, adr := range adrs {
-		for _, tag := range adr.Meta.Tags {
-			tags[tag] = 1
+func findADR(adrs []*ADR, index int) *ADR {
+	for _, a := range adrs {
+		if a.Meta.Index == index {
+			return a
 		}
 	}
 
-	tagsList := []string{}
-	for k := range tags {
-		tagsList = append(tagsList, k)
-	}
-	sort.Strings(tagsList)
-
-	type tagAdrs struct {
-		Tag  string
-		Adrs []*ADR
-	}
+	return nil
+}
 
-	renderList := []tagAdrs{}
+func searchADRs(adrs []*ADR, query string) []*ADR {
+	terms := strings.Fields(strings.ToLower(query))
+	matched := []*ADR{}
 
-	for _, tag := range tagsList {
-		matched := []*ADR{}
-		for _, adr := range adrs {
-			for _, mt := range adr.Meta.Tags {
-				if tag == mt {
-					matched = append(matched, adr)
-				}
+	for _, a := range adrs {
+		haystack := strings.ToLower(a.Heading + " " + strings.Join(a.Meta.Tags, " ") + " " + strings.Join(a.Meta.Authors, " "))
+		found := true
+		for _, t := range terms {
+			if !strings.Contains(haystack, t) {
+				found = false
+				break
 			}
 		}
+		if found {
+			matched = append(matched, a)
+		}
+	}
+
+	sort.Slice(matched, func(i, j int) bool {
+		return matched[i].Meta.Index < matched[j].Meta.Index
+	})
+
+	return matched
+}
+
+type TagADRs struct {
+	Tag  string
+	Adrs []*ADR
+}
 
-		sort.Slice(matched, func(i, j int) bool {
-			return matched[i].Meta.Index < matched[j].Meta.Index
-		})
+func groupByTag(adrs []*ADR) []TagADRs {
+	return NewCatalog(adrs).Tags()
+}
 
-		renderList = append(renderList, tagAdrs{Tag: tag, Adrs: matched})
+var templateFuncs = template.FuncMap{
+	"join": func(i []string) string {
+		return strings.Join(i, ", ")
+	},
+	"title": func(i string) string {
+		return strings.Title(i)
+	},
+	"lifecycle": lifecycleOf,
+}
+
+// renderIndexes executes the template with the ADRs grouped by tag, other record
+// types are reachable through the sections, records and notes template functions
+func renderIndexes(adrs []*ADR, templatePath string, w io.Writer) error {
+	return renderIndexesWith(adrs, templatePath, w, renderOptions{})
+}
+
+// renderOptions are the optional inputs of renderIndexesWith
+type renderOptions struct {
+	// Limits apply to templates that are not trusted, see templateLimits
+	Limits templateLimits
+	// Invalid lists the records left out by build -keep-going, available to the
+	// template through the invalid function
+	Invalid []InvalidRecord
+	// Inherited holds the decisions of inherited catalogs for the inherited
+	// function
+	Inherited []*ADR
+	// Graph adds the Mermaid map of the records to the HTML index, templates
+	// place it with the mermaid function
+	Graph bool
+	// Order sorts the records within each tag and section
+	Order recordOrder
+	// Tag limits the tags of the template to one, for the index of a tag
+	Tag string
+}
+
+// renderIndexesWith is renderIndexes with renderOptions
+func renderIndexesWith(adrs []*ADR, templatePath string, w io.Writer, opts renderOptions) (err error) {
+	limits := opts.Limits
+	render := startSpan("render")
+	render.set("adr.template", templatePath)
+	render.set("adr.records", len(adrs))
+	render.set("adr.sandboxed", limits.sandboxed())
+	defer func() { render.finish(err) }()
+
+	records, sections := typeSections(adrs)
+	tags := groupByTag(records)
+	if opts.Tag != "" {
+		only := []TagADRs{}
+		for _, t := range tags {
+			if t.Tag == opts.Tag {
+				only = append(only, t)
+			}
+		}
+		tags = only
 	}
+	opts.Order.apply(tags, sections)
+	now := time.Now()
 
-	funcMap := template.FuncMap{
-		"join": func(i []string) string {
-			return strings.Join(i, ", ")
+	funcs := template.FuncMap{
+		"sections": func() []TypeSection {
+			return sections
+		},
+		"records": func(key string) []*ADR {
+			for _, s := range sections {
+				if strings.EqualFold(s.Type.Key, key) {
+					return s.Records
+				}
+			}
+			return nil
 		},
-		"title": func(i string) string {
-			return strings.Title(i)
+		"invalid": func() []InvalidRecord {
+			return opts.Invalid
+		},
+		"freshness": func(a *ADR) Freshness {
+			return assessFreshness(a, adrs, now)
+		},
+		"inherited": func() []*ADR {
+			return opts.Inherited
+		},
+		"label": recordLabel,
+		"mermaid": func() string {
+			return mermaidGraph(NewCatalog(adrs))
+		},
+		"notes": func() []*ADR {
+			for _, s := range sections {
+				if s.Type.Key == noteTypeKey {
+					return s.Records
+				}
+			}
+			return nil
 		},
 	}
+	for k, v := range templateFuncs {
+		funcs[k] = v
+	}
+	if limits.sandboxed() {
+		for k, v := range sandboxFuncs {
+			funcs[k] = v
+		}
+	}
 
-	readme, err := template.New(".readme.templ").Funcs(funcMap).ParseFiles(".readme.templ")
+	readme, err := template.New(path.Base(templatePath)).Funcs(funcs).ParseFiles(templatePath)
 	if err != nil {
 		return err
 	}
-	err = readme.Execute(os.Stdout, renderList)
+
+	return executeLimited(readme, tags, w, limits)
+}
+
+func extractHeader(content string) string {
+	// the = or # title, possibly below front matter
+	lines := strings.Split(content, "\n")
+	if b := findMetadata(lines); b.Title >= 0 {
+		return strings.TrimSpace(lines[b.Title][2:])
+	}
+
+	// Return an empty string if no header is found
+	return ""
+}
+
+var sectionHeadingRegex = regexp.MustCompile(`^(={2,6}|#{2,6})\s+(.*)$`)
+
+type Section struct {
+	Title string
+	Level int
+	Body  string
+}
+
+// splitSections breaks an AsciiDoc document into its preamble (an untitled level 0
+// section) followed by one Section per heading, tables in the preamble are dropped
+// since they only hold metadata
+func splitSections(asciidocContent string) []Section {
+	sections := []Section{}
+	current := Section{}
+	body := []string{}
+	inTable := false
+
+	flush := func() {
+		current.Body = strings.TrimSpace(strings.Join(body, "\n"))
+		sections = append(sections, current)
+		body = []string{}
+	}
+
+	lines := strings.Split(asciidocContent, "\n")
+	front := frontMatter(lines)
+	meta := findMetadata(lines)
+	// the metadata is not part of the preamble, nor the [cols="1,2"] line
+	// styling its table
+	metaStart := meta.tableStart(lines)
+	for i, line := range lines {
+		if i == meta.Title || (front > 0 && i <= front) {
+			continue
+		}
+		if meta.Start >= 0 && i >= metaStart && i <= meta.End {
+			continue
+		}
+		// Markdown table rows of the metadata
+		if current.Level == 0 && strings.HasPrefix(line, "|") && strings.HasSuffix(strings.TrimSpace(line), "|") {
+			continue
+		}
+
+		if m := sectionHeadingRegex.FindStringSubmatch(line); m != nil {
+			flush()
+			current = Section{Title: strings.TrimSpace(m[2]), Level: len(m[1]) - 1}
+			continue
+		}
+
+		if current.Level == 0 && strings.HasPrefix(line, "|===") {
+			inTable = !inTable
+			continue
+		}
+		if inTable {
+			continue
+		}
+
+		body = append(body, line)
+	}
+	flush()
+
+	return sections
+}
+
+var commands = map[string]func(args []string) error{
+	"bot":               runBot,
+	"mcp":               runMCP,
+	"export":            runExport,
+	"propose":           runPropose,
+	"pr-summary":        runPRSummary,
+	"commit":            runCommit,
+	"rollup":            runRollup,
+	"build":             runBuild,
+	"status":            runStatus,
+	"edit":              runEdit,
+	"open":              runOpen,
+	"link":              runLink,
+	"verify-deployment": runVerifyDeployment,
+	"blame":             runBlame,
+	"incidents":         runIncidents,
+	"costs":             runCosts,
+	"outcomes":          runOutcomes,
+	"serve":             runServe,
+	"quarantine":        runQuarantine,
+	"checksums":         runChecksums,
+	"refs":              runRefs,
+	"commit-msg":        runCommitMsg,
+	"migrate":           runMigrate,
+	"inspect":           runInspect,
+	"init":              runInit,
+	"new":               runNew,
+	"validate":          runValidate,
+	"config":            runConfig,
+	"list":              runList,
+	"self-update":       runSelfUpdate,
+	"gen-fixtures":      runGenFixtures,
+	"supersede":         runSupersede,
+	"graph":             runGraph,
+	"explain":           runExplain,
+	"trends":            runTrends,
+	"site":              runSite,
+	"slo":               runSLO,
+	"feed":              runFeed,
+	"effective":         runEffective,
+	"views":             runViews,
+	"lint":              runLint,
+	"impact":            runImpact,
+	"renumber":          runRenumber,
+	"import":            runImport,
+	"mail":              runMail,
+	"notes":             runNotes,
+}
+
+func loadADRs(dir string) ([]*ADR, error) {
+	adrs, errs, err := scanADRs(dir)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	if len(errs) > 0 {
+		return nil, &ErrInvalidCatalog{Dir: dir, Errs: errs}
+	}
+
+	return adrs, nil
 }
 
-func extractHeader(asciidocContent string) string {
-	// Regular expression to match AsciiDoc headers
-	headerRegex := regexp.MustCompile(`^=\s.*`)
+// scanCatalog scans the working tree, or the revision at when it is not empty
+func scanCatalog(at string, dir string) ([]*ADR, []error, error) {
+	if at == "" {
+		return scanADRs(dir)
+	}
 
-	// Find the first match
-	match := headerRegex.FindStringSubmatch(asciidocContent)
-	// Check if a match is found
-	if len(match) >= 1 {
-		return strings.TrimPrefix(match[0], "= ")
+	rev, err := resolveRevision(at)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// Return an empty string if no header is found
-	return ""
+	return scanADRsAt(rev, dir)
 }
 
-func main() {
-	dir, err := ioutil.ReadDir("adr")
+func loadADRsAt(at string, dir string) ([]*ADR, error) {
+	adrs, errs, err := scanCatalog(at, dir)
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+	if len(errs) > 0 {
+		return nil, &ErrInvalidCatalog{Dir: dir, Errs: errs}
 	}
 
+	return adrs, nil
+}
+
+// scanADRs parses every ADR in dir, files failing validation are skipped and
+// their errors returned alongside the valid ADRs
+func scanADRs(dir string) ([]*ADR, []error, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	parse := startSpan("parse")
+	parse.set("adr.dir", dir)
+	files := 0
+
 	adrs := []*ADR{}
+	errs := []error{}
 
-	for _, mdf := range dir {
+	for _, mdf := range entries {
 		if mdf.IsDir() {
 			continue
 		}
 
-		if path.Ext(mdf.Name()) != ".adoc" {
+		if !isRecordFile(mdf.Name()) {
 			continue
 		}
 
-		adr, err := parseADR(path.Join("adr", mdf.Name()))
+		files++
+		adr, err := parseADR(path.Join(dir, mdf.Name()))
 		if err != nil {
-			panic(err)
+			errs = append(errs, err)
+			continue
 		}
 
 		adrs = append(adrs, adr)
 	}
 
+	parse.set("adr.files", files)
+	parse.set("adr.records", len(adrs))
+	parse.set("adr.errors", len(errs))
+	parse.finish(nil)
+
+	validate := startSpan("validate")
+	invalid := []error{}
 	err = verifyUniqueIndexes(adrs)
 	if err != nil {
-		panic(err)
+		invalid = append(invalid, err)
+	}
+	invalid = append(invalid, verifySupersessions(adrs)...)
+	invalid = append(invalid, verifyConflicts(adrs)...)
+	errs = append(errs, invalid...)
+	validate.set("adr.records", len(adrs))
+	validate.set("adr.errors", len(invalid))
+	if len(invalid) > 0 {
+		validate.finish(&ErrInvalidCatalog{Dir: dir, Errs: invalid})
+	} else {
+		validate.finish(nil)
 	}
 
-	err = renderIndexes(adrs)
+	return adrs, errs, nil
+}
+
+func runBuild(args []string) error {
+	fs := flag.NewFlagSet("build", flag.ExitOnError)
+	dir := fs.String("dir", settings.Dir, "directory containing ADR files")
+	templatePath := fs.String("template", settings.Template, "index template")
+	output := fs.String("output", settings.Output, "file to write the index to atomically, defaults to output in the config or stdout, an .html file gets the standalone HTML index")
+	at := fs.String("at", "", "render the catalog as of a git revision or a YYYY-MM-DD date")
+	keepGoing := fs.Bool("keep-going", false, "leave out invalid records and list them in the index instead of failing")
+	verify := fs.Bool("verify", false, "check that the index at -output matches the files instead of writing it")
+	sandbox := fs.Bool("sandbox", false, "render with the time, output and function limits applied to untrusted templates")
+	manifest := fs.String("manifest", "", "file to write the manifest of the index with its hash and the hash of the records to")
+	graph := fs.Bool("graph", false, "add a map of how the records supersede and relate to each other to the HTML index, it is drawn by Mermaid loaded from "+mermaidScriptURL)
+	sortKey := fs.String("sort", "index", "order of the records within each tag and section: "+strings.Join(recordOrders, ", "))
+	sortOrder := fs.String("order", "asc", "asc or desc, e.g. -sort date -order desc for the newest first")
+	tagOutput := fs.String("tag-output", cfg.TagIndex.Output, "also write an index per tag to the files this template names with the Tag and its Slug, e.g. index/{{.Slug}}.adoc")
+	tagTemplate := fs.String("tag-template", cfg.TagIndex.Template, "index template of the tag indexes, -template when empty")
+	filter := filterFlags(fs)
+	fs.Parse(args)
+
+	f, err := filter()
+	if err != nil {
+		return err
+	}
+	order, err := parseRecordOrder(*sortKey, *sortOrder)
+	if err != nil {
+		return err
+	}
+	adrs, errs, err := scanCatalog(*at, *dir)
+	if err != nil {
+		return err
+	}
+	if *at == "" {
+		adrs, errs, err = withIncludes(adrs, errs)
+		if err != nil {
+			return err
+		}
+	} else if len(cfg.Includes) > 0 {
+		log.Printf("Leaving out included records, -at only applies to %s", *dir)
+	}
+	inherited, errs, err := withInherited(adrs, errs)
 	if err != nil {
-		panic(err)
+		return err
 	}
+	if len(errs) > 0 && !*keepGoing {
+		return &ErrInvalidCatalog{Dir: *dir, Errs: errs}
+	}
+	for _, e := range errs {
+		if fix := suggestFix(e); fix != nil {
+			log.Printf("Leaving out invalid record: %s, fix: %s", e, fix.Description)
+			continue
+		}
+		log.Printf("Leaving out invalid record: %s", e)
+	}
+	// the flags narrow the filter of the profile
+	published := f.apply(settings.Filter.apply(adrs))
+	if *tagTemplate == "" {
+		*tagTemplate = *templatePath
+	}
+
+	if *verify {
+		if *output == "" {
+			*output = "README.adoc"
+		}
+		err = verifyIndex(published, *dir, *templatePath, *output, renderOptions{Order: order})
+		if err != nil || *tagOutput == "" {
+			return err
+		}
+		indexes, err := tagIndexes(published, *tagOutput)
+		if err != nil {
+			return err
+		}
+		for _, ti := range indexes {
+			err = verifyIndex(NewCatalog(published).ByTag(ti.Tag), *dir, *tagTemplate, ti.Path, renderOptions{Order: order, Tag: ti.Tag})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if *manifest != "" && *output == "" {
+		return fmt.Errorf("-manifest needs the -output file it lists")
+	}
+
+	limits := templateLimits{}
+	if *sandbox {
+		limits = cfg.Sandbox.limits()
+	}
+	opts := renderOptions{Limits: limits, Invalid: invalidRecords(errs), Inherited: inherited, Graph: *graph, Order: order}
+	err = writeOutput(*output, func(w io.Writer) error {
+		if isHTMLOutput(*output) {
+			return renderHTMLIndex(published, *output, w, opts)
+		}
+		return renderIndexesWith(published, *templatePath, w, opts)
+	})
+	if err != nil {
+		return err
+	}
+	artifacts := map[string]string{*output: "asciidoc"}
+	if isHTMLOutput(*output) {
+		artifacts[*output] = "html"
+	}
+	if *tagOutput != "" {
+		written, err := writeTagIndexes(published, *tagTemplate, *tagOutput, opts)
+		if err != nil {
+			return err
+		}
+		for _, file := range written {
+			artifacts[file] = "asciidoc"
+		}
+		log.Printf("Wrote the indexes of %d tags", len(written))
+	}
+	if *manifest == "" {
+		return nil
+	}
+
+	return writeManifest(*manifest, published, artifacts)
+}
+
+func main() {
+	os.Exit(run())
+}
+
+// run is main returning the exit code, so the deferred unlock runs before the
+// process exits
+func run() int {
+	configPath := flag.String("config", envString("ADR_CONFIG", configFile), "project configuration file, yaml or TOML by extension, .adrconfig.toml is read when .adr.yaml is absent")
+	profile := flag.String("profile", envString("ADR_PROFILE", ""), "named configuration profile to apply")
+	flag.BoolVar(&offline, "offline", envBool("ADR_OFFLINE"), "disable every network feature and report what was skipped")
+	strict := flag.Bool("strict", envBool("ADR_STRICT"), "fail on lint findings such as unexpected or repeated metadata keys")
+	lenient := flag.Bool("lenient", envBool("ADR_LENIENT"), "read invalid records with warnings and defaults, e.g. untagged for missing tags")
+	format := flag.String("format", envString("ADR_FORMAT", "text"), "output of commands: text, json or yaml wrapped in an envelope with results and errors, sarif for code scanning or github for workflow annotations of the problems found")
+	color := flag.String("color", envString("ADR_COLOR", "auto"), "color terminal output: auto, always or never, auto honours NO_COLOR")
+	statuses := flag.String("statuses", "", "comma separated statuses allowed for ADRs, overrides statuses in the config")
+	layout := flag.String("date-layout", "", "format of Date values such as DD-MM-YYYY, overrides dateLayout in the config")
+	flag.Parse()
+
+	if err := setParseMode(*strict, *lenient); err != nil {
+		return reportError(os.Stderr, err, exitUsage)
+	}
+	if err := setColorMode(*color); err != nil {
+		return reportError(os.Stderr, err, exitUsage)
+	}
+	if err := setOutputFormat(*format); err != nil {
+		return reportError(os.Stderr, err, exitUsage)
+	}
+
+	name := "build"
+	args := flag.Args()
+	if len(args) > 0 {
+		name = args[0]
+		args = args[1:]
+	}
+
+	// an outdated binary still updates itself
+	if name != "self-update" {
+		if err := checkConfigVersion(*configPath); err != nil {
+			return reportError(os.Stderr, err, exitFailure)
+		}
+	}
+
+	var err error
+	cfg, err = loadConfig(*configPath)
+	if err != nil && name == "self-update" {
+		// the configuration may be one only the newer release reads
+		log.Printf("Ignoring %s: %s", *configPath, err)
+		cfg, err = defaultConfig(), nil
+	}
+	if err != nil {
+		return reportError(os.Stderr, err, exitUsage)
+	}
+
+	settings, err = cfg.profile(*profile)
+	if err != nil {
+		return reportError(os.Stderr, err, exitUsage)
+	}
+	err = applyEnv(cfg, &settings)
+	if err != nil {
+		return reportError(os.Stderr, err, exitUsage)
+	}
+	if *statuses != "" {
+		cfg.Statuses = parseCommaList(*statuses)
+	}
+	if *layout != "" {
+		cfg.DateLayout = *layout
+	}
+	err = cfg.applyRecordFormats()
+	if err != nil {
+		return reportError(os.Stderr, err, exitUsage)
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		return reportError(os.Stderr, fmt.Errorf("unknown command %q", name), exitUsage)
+	}
+
+	// ADR_MIN_VERSION may raise the version of the file
+	if name != "self-update" {
+		err = checkMinVersion(cfg.MinVersion)
+		if err != nil {
+			return reportError(os.Stderr, err, exitFailure)
+		}
+	}
+
+	if lockedCommands[name] {
+		unlock, err := lockRepo()
+		if err != nil {
+			return reportError(os.Stderr, err, exitFailure)
+		}
+		defer unlock()
+	}
+
+	startTracing("adr " + name)
+	if structuredOutput() && !interactiveCommands[name] {
+		err = runEnveloped(name, func() error { return cmd(args) })
+	} else {
+		err = cmd(args)
+	}
+	stopTracing(err)
+	reportOffline()
+	if err != nil {
+		return reportError(os.Stderr, err, exitFailure)
+	}
+
+	return 0
 }
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSplitSections(t *testing.T) {
+	sections := []Section{{Body: "A preamble."}, {Title: "Context", Level: 1, Body: "Some context."}}
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"table", "= Use Kafka\n\nA preamble.\n\n|===\n|Metadata |Value\n|Status |Approved\n|===\n\n== Context\n\nSome context.\n"},
+		{"styled table", "= Use Kafka\n\nA preamble.\n\n[cols=\"1,2\"]\n|===\n|Metadata |Value\n|Status |Approved\n|===\n\n== Context\n\nSome context.\n"},
+		{"attributes", "= Use Kafka\n:status: Approved\n:tags: messaging\n\nA preamble.\n\n== Context\n\nSome context.\n"},
+		{"markdown", "# Use Kafka\n\nA preamble.\n\n| Metadata | Value |\n|---|---|\n| Status | Approved |\n\n## Context\n\nSome context.\n"},
+		{"front matter", "---\nstatus: Approved\n---\n# Use Kafka\n\nA preamble.\n\n## Context\n\nSome context.\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := splitSections(test.body)
+			if !reflect.DeepEqual(got, sections) {
+				t.Errorf("splitSections() = %+v, want %+v", got, sections)
+			}
+		})
+	}
+}
+
+func TestADRJSON(t *testing.T) {
+	a := &ADR{Heading: "Use Kafka", Meta: ADRMeta{
+		Index:  1,
+		Date:   time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		People: []Person{{Name: "@alice"}},
+		Status: "Approved",
+		Path:   "adr/0001-use-kafka.adoc",
+	}}
+	body, err := json.Marshal(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"title":"Use Kafka","meta":{"index":1,"people":[{"name":"@alice"}],"status":"Approved","path":"adr/0001-use-kafka.adoc","date":"2024-02-01T00:00:00Z"}}`
+	if string(body) != want {
+		t.Errorf("got  %s\nwant %s", body, want)
+	}
+}
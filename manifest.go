@@ -0,0 +1,94 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const manifestFile = "manifest.json"
+
+// publishManifest lists the artifacts of one publishing run, consumers compare
+// the catalog hash to tell whether two artifacts come from the same sources
+type publishManifest struct {
+	Generator string             `json:"generator"`
+	Generated time.Time          `json:"generated"`
+	Catalog   manifestCatalog    `json:"catalog"`
+	Artifacts []manifestArtifact `json:"artifacts"`
+}
+
+type manifestCatalog struct {
+	SHA256  string `json:"sha256"`
+	Records int    `json:"records"`
+}
+
+// manifestArtifact is a published file, Path is relative to the manifest
+type manifestArtifact struct {
+	Path   string `json:"path"`
+	Format string `json:"format"`
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// catalogHash hashes the published records, every record adds its file name
+// and the checksum of its content with normalized line endings
+func catalogHash(adrs []*ADR) (string, error) {
+	lines := []string{}
+	for _, a := range adrs {
+		e, err := checksumOf(a)
+		if err != nil {
+			return "", err
+		}
+		lines = append(lines, e.File+" "+e.SHA256)
+	}
+	sort.Strings(lines)
+
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// writeManifest hashes the artifacts, format by file, and writes the manifest
+// of them to output
+func writeManifest(output string, adrs []*ADR, artifacts map[string]string) error {
+	hash, err := catalogHash(adrs)
+	if err != nil {
+		return err
+	}
+
+	m := publishManifest{
+		Generator: "adr-index " + version,
+		Generated: time.Now().UTC().Truncate(time.Second),
+		Catalog:   manifestCatalog{SHA256: hash, Records: len(adrs)},
+		Artifacts: []manifestArtifact{},
+	}
+	base := filepath.Dir(output)
+	for file, format := range artifacts {
+		body, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(base, file)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(body)
+		m.Artifacts = append(m.Artifacts, manifestArtifact{
+			Path:   filepath.ToSlash(rel),
+			Format: format,
+			SHA256: hex.EncodeToString(sum[:]),
+			Size:   int64(len(body)),
+		})
+	}
+	sort.Slice(m.Artifacts, func(i, j int) bool {
+		return m.Artifacts[i].Path < m.Artifacts[j].Path
+	})
+
+	return writeOutput(output, func(w io.Writer) error {
+		return encodeValue(w, "json", m)
+	})
+}
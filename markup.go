@@ -0,0 +1,258 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// markupRenderer turns the body of a section into HTML, it understands the
+// AsciiDoc and Markdown that records are written in: paragraphs, lists,
+// literal blocks, tables, admonitions and inline emphasis, code and links.
+// Everything else is kept as escaped text
+type markupRenderer struct {
+	// link rewrites the target of a link, e.g. a record file to its page
+	link func(target string) string
+	// ref returns the page of a record label such as ADR-12, "" when there is
+	// no such record
+	ref func(label string) string
+}
+
+var (
+	markupFenceRegex    = regexp.MustCompile("^(----|\\.\\.\\.\\.|```|~~~)")
+	markupBulletRegex   = regexp.MustCompile(`^\s*(\*+|-|\+)\s+(.*)$`)
+	markupNumberRegex   = regexp.MustCompile(`^\s*(\.+|\d+\.)\s+(.*)$`)
+	markupAdmonition    = regexp.MustCompile(`^(NOTE|TIP|IMPORTANT|WARNING|CAUTION):\s+(.*)$`)
+	markupBlockAttrs    = regexp.MustCompile(`^\[[^\]]*\]$`)
+	markupMarkdownRow   = regexp.MustCompile(`^\|.*\|$`)
+	markupAsciidocLink  = regexp.MustCompile(`(?:link:)?((?:https?://|mailto:)?[^\s\[\]<>]+)\[([^\]]*)\]`)
+	markupMarkdownLink  = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	markupBareURL       = regexp.MustCompile(`https?://[^\s<>\[\]()]+[^\s<>\[\]().,;:!?]`)
+	markupStrongRegex   = regexp.MustCompile(`\*\*([^*]+)\*\*|\*([^*\s](?:[^*]*[^*\s])?)\*`)
+	markupEmphasisRegex = regexp.MustCompile(`(^|[\s(])_([^_\s](?:[^_]*[^_\s])?)_`)
+	markupPlaceholder   = regexp.MustCompile("\x00(\\d+)\x00")
+	// links with other schemes than these, javascript: among them, are
+	// rendered as their label
+	markupSchemeRegex = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]*:`)
+	markupSafeScheme  = regexp.MustCompile(`^(?i:https?|mailto):`)
+)
+
+func (m markupRenderer) render(body string) template.HTML {
+	b := &strings.Builder{}
+	lines := strings.Split(strings.Replace(body, "\r\n", "\n", -1), "\n")
+
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "" || markupBlockAttrs.MatchString(trimmed):
+			i++
+		case markupFenceRegex.MatchString(trimmed):
+			fence := markupFenceRegex.FindString(trimmed)
+			end := i + 1
+			for end < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[end]), fence) {
+				end++
+			}
+			fmt.Fprintf(b, "<pre>%s</pre>\n", html.EscapeString(strings.Join(lines[i+1:minInt(end, len(lines))], "\n")))
+			i = end + 1
+		case strings.HasPrefix(trimmed, "|==="):
+			end := i + 1
+			for end < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[end]), "|===") {
+				end++
+			}
+			m.table(b, asciidocRows(lines[i+1:minInt(end, len(lines))]))
+			i = end + 1
+		case markupMarkdownRow.MatchString(trimmed):
+			end := i
+			rows := [][]string{}
+			for end < len(lines) && markupMarkdownRow.MatchString(strings.TrimSpace(lines[end])) {
+				if !markdownSeparatorRegex.MatchString(strings.TrimSpace(lines[end])) {
+					rows = append(rows, splitMarkdownRow(lines[end]))
+				}
+				end++
+			}
+			m.table(b, rows)
+			i = end
+		case markupBulletRegex.MatchString(line) || markupNumberRegex.MatchString(line):
+			tag, pattern := "ul", markupBulletRegex
+			if !markupBulletRegex.MatchString(line) {
+				tag, pattern = "ol", markupNumberRegex
+			}
+			fmt.Fprintf(b, "<%s>\n", tag)
+			for i < len(lines) && pattern.MatchString(lines[i]) {
+				item := pattern.FindStringSubmatch(lines[i])[2]
+				i++
+				// continuation lines belong to the item
+				for i < len(lines) && strings.TrimSpace(lines[i]) != "" && !markupBulletRegex.MatchString(lines[i]) &&
+					!markupNumberRegex.MatchString(lines[i]) && !markupFenceRegex.MatchString(strings.TrimSpace(lines[i])) {
+					item += " " + strings.TrimSpace(lines[i])
+					i++
+				}
+				fmt.Fprintf(b, "<li>%s</li>\n", m.inline(item))
+			}
+			fmt.Fprintf(b, "</%s>\n", tag)
+		default:
+			paragraph := []string{}
+			for i < len(lines) && strings.TrimSpace(lines[i]) != "" && !markupFenceRegex.MatchString(strings.TrimSpace(lines[i])) &&
+				!strings.HasPrefix(strings.TrimSpace(lines[i]), "|===") && !(len(paragraph) > 0 && markupBulletRegex.MatchString(lines[i])) {
+				paragraph = append(paragraph, strings.TrimSpace(lines[i]))
+				i++
+			}
+			text := strings.Join(paragraph, " ")
+			if a := markupAdmonition.FindStringSubmatch(text); a != nil {
+				fmt.Fprintf(b, "<p class=\"admonition\"><strong>%s</strong> %s</p>\n", strings.Title(strings.ToLower(a[1])), m.inline(a[2]))
+				continue
+			}
+			fmt.Fprintf(b, "<p>%s</p>\n", m.inline(text))
+		}
+	}
+
+	return template.HTML(b.String())
+}
+
+func (m markupRenderer) table(b *strings.Builder, rows [][]string) {
+	if len(rows) == 0 {
+		return
+	}
+
+	b.WriteString("<table>\n")
+	for n, row := range rows {
+		cell := "td"
+		if n == 0 && len(rows) > 1 {
+			cell = "th"
+		}
+		b.WriteString("<tr>")
+		for _, c := range row {
+			fmt.Fprintf(b, "<%s>%s</%s>", cell, m.inline(c), cell)
+		}
+		b.WriteString("</tr>\n")
+	}
+	b.WriteString("</table>\n")
+}
+
+// asciidocRows reads the rows of an AsciiDoc table, a row is either one line of
+// |cell |cell or one cell per line up to a blank line
+func asciidocRows(lines []string) [][]string {
+	rows := [][]string{}
+	row := []string{}
+	flush := func() {
+		if len(row) > 0 {
+			rows = append(rows, row)
+			row = []string{}
+		}
+	}
+
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			flush()
+			continue
+		}
+		cells := strings.Split(strings.TrimPrefix(line, "|"), "|")
+		for i := range cells {
+			cells[i] = strings.TrimSpace(cells[i])
+		}
+		if len(cells) > 1 {
+			flush()
+			rows = append(rows, cells)
+			continue
+		}
+		row = append(row, cells...)
+	}
+	flush()
+
+	return rows
+}
+
+func splitMarkdownRow(line string) []string {
+	cells := strings.Split(strings.Trim(strings.TrimSpace(line), "|"), "|")
+	for i := range cells {
+		cells[i] = strings.TrimSpace(cells[i])
+	}
+
+	return cells
+}
+
+// inline escapes text and applies code spans, links and emphasis, links are
+// held in placeholders so emphasis does not reach into their targets
+func (m markupRenderer) inline(text string) string {
+	b := &strings.Builder{}
+	for i, part := range strings.Split(text, "`") {
+		if i%2 == 1 {
+			fmt.Fprintf(b, "<code>%s</code>", html.EscapeString(part))
+			continue
+		}
+		b.WriteString(m.prose(part))
+	}
+
+	return b.String()
+}
+
+func (m markupRenderer) prose(text string) string {
+	// NULs delimit the placeholders, the text must not contain any of its own,
+	// browsers show them as U+FFFD anyway
+	text = strings.Replace(text, "\x00", "\uFFFD", -1)
+	held := []string{}
+	hold := func(s string) string {
+		held = append(held, s)
+		return fmt.Sprintf("\x00%d\x00", len(held)-1)
+	}
+	anchor := func(target string, label string) string {
+		if markupSchemeRegex.MatchString(target) && !markupSafeScheme.MatchString(target) {
+			return hold(html.EscapeString(label))
+		}
+		if m.link != nil {
+			target = m.link(target)
+		}
+		return hold(fmt.Sprintf("<a href=\"%s\">%s</a>", html.EscapeString(target), html.EscapeString(label)))
+	}
+
+	text = markupMarkdownLink.ReplaceAllStringFunc(text, func(s string) string {
+		p := markupMarkdownLink.FindStringSubmatch(s)
+		return anchor(p[2], p[1])
+	})
+	text = markupAsciidocLink.ReplaceAllStringFunc(text, func(s string) string {
+		p := markupAsciidocLink.FindStringSubmatch(s)
+		if !strings.HasPrefix(s, "link:") && !strings.Contains(p[1], "://") && !strings.HasPrefix(p[1], "mailto:") {
+			return s
+		}
+		label := p[2]
+		if label == "" {
+			label = p[1]
+		}
+		return anchor(p[1], label)
+	})
+	text = markupBareURL.ReplaceAllStringFunc(text, func(s string) string {
+		return anchor(s, s)
+	})
+	if m.ref != nil {
+		text = recordRefRegex.ReplaceAllStringFunc(text, func(s string) string {
+			if page := m.ref(s); page != "" {
+				return hold(fmt.Sprintf("<a href=\"%s\">%s</a>", html.EscapeString(page), html.EscapeString(s)))
+			}
+			return s
+		})
+	}
+
+	parts := markupPlaceholder.Split(text, -1)
+	refs := markupPlaceholder.FindAllStringSubmatch(text, -1)
+	b := &strings.Builder{}
+	for i, part := range parts {
+		escaped := html.EscapeString(part)
+		escaped = markupStrongRegex.ReplaceAllString(escaped, "<strong>$1$2</strong>")
+		escaped = markupEmphasisRegex.ReplaceAllString(escaped, "$1<em>$2</em>")
+		b.WriteString(escaped)
+		if i < len(refs) {
+			n, err := strconv.Atoi(refs[i][1])
+			if err != nil || n >= len(held) {
+				continue
+			}
+			b.WriteString(held[n])
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkupProse(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"link", "see https://example.com now", `see <a href="https://example.com">https://example.com</a> now`},
+		{"escaped", "a <b> & c", "a &lt;b&gt; &amp; c"},
+		{"placeholder in the text", "a \x000\x00 b \x0099\x00", "a �0� b �99�"},
+		{"placeholder next to a link", "\x005\x00 https://example.com", "�5� " + `<a href="https://example.com">https://example.com</a>`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := markupRenderer{}.prose(test.text)
+			if got != test.want {
+				t.Errorf("prose(%q) = %q, want %q", test.text, got, test.want)
+			}
+			if strings.Contains(got, "\x00") {
+				t.Errorf("prose(%q) keeps a NUL", test.text)
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+)
+
+const mcpProtocolVersion = "2024-11-05"
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type mcpTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+type mcpContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type mcpToolResult struct {
+	Content []mcpContent `json:"content"`
+	IsError bool         `json:"isError,omitempty"`
+}
+
+var mcpTools = []mcpTool{
+	{
+		Name:        "search_adrs",
+		Description: "Search architecture decision records by title, tag or author. All terms must match.",
+		InputSchema: objectSchema(map[string]string{"query": "string"}, "query"),
+	},
+	{
+		Name:        "get_adr",
+		Description: "Return the full AsciiDoc source and metadata of a single architecture decision record.",
+		InputSchema: objectSchema(map[string]string{"index": "integer"}, "index"),
+	},
+	{
+		Name:        "list_by_tag",
+		Description: "List all architecture decision records carrying a tag.",
+		InputSchema: objectSchema(map[string]string{"tag": "string"}, "tag"),
+	},
+}
+
+func objectSchema(props map[string]string, required ...string) map[string]interface{} {
+	properties := map[string]interface{}{}
+	for name, typ := range props {
+		properties[name] = map[string]string{"type": typ}
+	}
+
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+func runMCP(args []string) error {
+	fs := flag.NewFlagSet("mcp", flag.ExitOnError)
+	dir := fs.String("dir", settings.Dir, "directory containing ADR files")
+	fs.Parse(args)
+
+	return serveMCP(*dir, os.Stdin, os.Stdout)
+}
+
+// serveMCP speaks newline delimited JSON-RPC as described by the MCP stdio transport
+func serveMCP(dir string, in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	enc := json.NewEncoder(out)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var req rpcRequest
+		err := json.Unmarshal([]byte(line), &req)
+		if err != nil {
+			err = enc.Encode(rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{Code: -32700, Message: "parse error"}})
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		// notifications carry no id and get no response
+		if len(req.ID) == 0 {
+			continue
+		}
+
+		resp := rpcResponse{JSONRPC: "2.0", ID: req.ID}
+		result, rerr := handleMCP(dir, req)
+		if rerr != nil {
+			resp.Error = rerr
+		} else {
+			resp.Result = result
+		}
+
+		err = enc.Encode(resp)
+		if err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+func handleMCP(dir string, req rpcRequest) (interface{}, *rpcError) {
+	switch req.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"protocolVersion": mcpProtocolVersion,
+			"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+			"serverInfo":      map[string]string{"name": "adr-index", "version": "1.0.0"},
+		}, nil
+	case "ping":
+		return map[string]interface{}{}, nil
+	case "tools/list":
+		return map[string]interface{}{"tools": mcpTools}, nil
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		err := json.Unmarshal(req.Params, &params)
+		if err != nil {
+			return nil, &rpcError{Code: -32602, Message: "invalid params"}
+		}
+		return callMCPTool(dir, params.Name, params.Arguments), nil
+	}
+
+	return nil, &rpcError{Code: -32601, Message: fmt.Sprintf("method %q not found", req.Method)}
+}
+
+func callMCPTool(dir string, name string, rawArgs json.RawMessage) mcpToolResult {
+	var args struct {
+		Query string `json:"query"`
+		Index int    `json:"index"`
+		Tag   string `json:"tag"`
+	}
+	if len(rawArgs) > 0 {
+		err := json.Unmarshal(rawArgs, &args)
+		if err != nil {
+			return mcpError("invalid arguments: %s", err)
+		}
+	}
+
+	// an invalid record is left out and named in the result rather than
+	// failing every call
+	adrs, errs, err := scanADRs(dir)
+	if err != nil {
+		return mcpError("could not load ADR catalog: %s", err)
+	}
+	for _, err := range errs {
+		log.Printf("Skipped invalid ADR: %s", err)
+	}
+
+	switch name {
+	case "search_adrs":
+		return withSkipped(mcpList(searchADRs(adrs, args.Query)), errs)
+
+	case "get_adr":
+		adr := findADR(adrs, args.Index)
+		if adr == nil {
+			return withSkipped(mcpError("ADR-%d does not exist", args.Index), errs)
+		}
+		body, err := ioutil.ReadFile(adr.Meta.Path)
+		if err != nil {
+			return mcpError("could not read %s: %s", adr.Meta.Path, err)
+		}
+		return withSkipped(mcpToolResult{Content: []mcpContent{
+			{Type: "text", Text: summarizeADR(adr)},
+			{Type: "text", Text: string(body)},
+		}}, errs)
+
+	case "list_by_tag":
+		matched := []*ADR{}
+		for _, a := range adrs {
+			for _, t := range a.Meta.Tags {
+				if strings.EqualFold(t, args.Tag) {
+					matched = append(matched, a)
+					break
+				}
+			}
+		}
+		return withSkipped(mcpList(searchADRs(matched, "")), errs)
+	}
+
+	return mcpError("unknown tool %q", name)
+}
+
+func summarizeADR(adr *ADR) string {
+	return fmt.Sprintf("ADR-%d: %s\nStatus: %s\nDate: %s\nAuthors: %s\nTags: %s\nPath: %s",
+		adr.Meta.Index, adr.Heading, adr.Meta.Status, adr.Meta.Date.Format("2006-01-02"),
+		strings.Join(adr.Meta.Authors, ", "), strings.Join(adr.Meta.Tags, ", "), adr.Meta.Path)
+}
+
+func mcpList(adrs []*ADR) mcpToolResult {
+	if len(adrs) == 0 {
+		return mcpToolResult{Content: []mcpContent{{Type: "text", Text: "No matching ADRs"}}}
+	}
+
+	parts := []string{}
+	for _, a := range adrs {
+		parts = append(parts, summarizeADR(a))
+	}
+
+	return mcpToolResult{Content: []mcpContent{{Type: "text", Text: strings.Join(parts, "\n\n")}}}
+}
+
+// withSkipped ends result with the records that could not be read, so the
+// client knows the answer may be incomplete
+func withSkipped(result mcpToolResult, errs []error) mcpToolResult {
+	if len(errs) == 0 {
+		return result
+	}
+
+	lines := []string{fmt.Sprintf("%d invalid records were skipped:", len(errs))}
+	for _, err := range errs {
+		lines = append(lines, "- "+err.Error())
+	}
+	result.Content = append(result.Content, mcpContent{Type: "text", Text: strings.Join(lines, "\n")})
+
+	return result
+}
+
+func mcpError(format string, a ...interface{}) mcpToolResult {
+	return mcpToolResult{
+		Content: []mcpContent{{Type: "text", Text: fmt.Sprintf(format, a...)}},
+		IsError: true,
+	}
+}
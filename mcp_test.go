@@ -0,0 +1,37 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMCPToolSkipsInvalidRecords(t *testing.T) {
+	dir, err := ioutil.TempDir("", "adr-index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for file, body := range map[string]string{
+		"0001-use-kafka.adoc": recordBody("|Date |01-02-2024", "|Author |@alice", "|Status |Approved", "|Tags |messaging"),
+		"0002-broken.adoc":    recordBody("|Status |Maybe"),
+	} {
+		err = ioutil.WriteFile(filepath.Join(dir, file), []byte(body), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result := callMCPTool(dir, "search_adrs", []byte(`{"query":"kafka"}`))
+	if result.IsError {
+		t.Fatalf("search failed: %+v", result)
+	}
+	if len(result.Content) != 2 || !strings.HasPrefix(result.Content[0].Text, "ADR-1: Use Kafka") {
+		t.Fatalf("unexpected content %+v", result.Content)
+	}
+	if !strings.Contains(result.Content[1].Text, "1 invalid records were skipped") || !strings.Contains(result.Content[1].Text, "0002-broken.adoc") {
+		t.Errorf("skipped records not reported: %q", result.Content[1].Text)
+	}
+}
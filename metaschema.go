@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// MetadataConfig declares the metadata rows records may carry besides the
+// known keys and the rows required by their type
+type MetadataConfig struct {
+	// Keys are the additional rows, e.g. Security review
+	Keys []MetadataKey `yaml:"keys"`
+	// Strict fails on rows that are neither known nor declared, --strict does
+	// the same but fails on every other lint finding as well
+	Strict bool `yaml:"strict"`
+}
+
+// MetadataKey is an additional metadata row, its value must be one of Values
+// and match Pattern when those are set
+type MetadataKey struct {
+	Name    string   `yaml:"name"`
+	Values  []string `yaml:"values"`
+	Pattern string   `yaml:"pattern"`
+
+	pattern *regexp.Regexp
+}
+
+// compile checks the declared keys and compiles their patterns
+func (c *MetadataConfig) compile() error {
+	seen := map[string]bool{}
+	for i := range c.Keys {
+		k := &c.Keys[i]
+		if strings.TrimSpace(k.Name) == "" {
+			return fmt.Errorf("metadata key %d has no name in %s", i+1, configFile)
+		}
+		for _, known := range metadataKeys {
+			if strings.EqualFold(known, k.Name) {
+				return fmt.Errorf("metadata key %s is built in and cannot be declared in %s", k.Name, configFile)
+			}
+		}
+		if seen[strings.ToLower(k.Name)] {
+			return fmt.Errorf("metadata key %s is declared twice in %s", k.Name, configFile)
+		}
+		seen[strings.ToLower(k.Name)] = true
+		if k.Pattern == "" {
+			continue
+		}
+		pattern, err := regexp.Compile("^(?:" + k.Pattern + ")$")
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q of metadata key %s in %s: %s", k.Pattern, k.Name, configFile, err)
+		}
+		k.pattern = pattern
+	}
+
+	return nil
+}
+
+// key returns the declared key named name, nil when there is none
+func (c *MetadataConfig) key(name string) *MetadataKey {
+	for i := range c.Keys {
+		if c.Keys[i].Name == name {
+			return &c.Keys[i]
+		}
+	}
+
+	return nil
+}
+
+func (c *MetadataConfig) names() []string {
+	names := []string{}
+	for _, k := range c.Keys {
+		names = append(names, k.Name)
+	}
+
+	return names
+}
+
+// check returns why value is not allowed, empty when it is
+func (k *MetadataKey) check(value string) string {
+	if len(k.Values) > 0 && !containsFold(k.Values, value) {
+		return fmt.Sprintf("invalid %s %q, must be one of: %s", k.Name, value, strings.Join(k.Values, ", "))
+	}
+	if k.pattern != nil && !k.pattern.MatchString(value) {
+		return fmt.Sprintf("invalid %s %q, must match %s", k.Name, value, k.Pattern)
+	}
+
+	return ""
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// listKeys hold several values, repeated rows or attributes of these keys add
+// to the list instead of replacing the previous value
+var listKeys = []string{"Author", "Approvers", "Tags", "Incidents", "Cost", "Supersedes", "Superseded by", "Conflicts with", "Scope"}
+
+func isListKey(key string) bool {
+	for _, k := range listKeys {
+		if k == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Person is a structured author such as Jane Doe <jane@example.com> (Platform),
+// only the name is required, GitHub handles like @jane are names too and names
+// holding a comma are quoted, "Doe, Jane" <jane@example.com>
+type Person struct {
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty"`
+	Team  string `json:"team,omitempty"`
+}
+
+var personRegex = regexp.MustCompile(`^(.*?)\s*(?:<([^<>]*)>)?\s*(?:\(([^()]*)\))?$`)
+
+func parsePerson(s string) Person {
+	m := personRegex.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil || m[1] == "" {
+		return Person{Name: strings.TrimSpace(s)}
+	}
+
+	return Person{Name: strings.Trim(m[1], `"`), Email: strings.TrimSpace(m[2]), Team: strings.TrimSpace(m[3])}
+}
+
+func (p Person) String() string {
+	s := p.Name
+	if strings.Contains(s, ",") {
+		s = `"` + s + `"`
+	}
+	if p.Email != "" {
+		s += " <" + p.Email + ">"
+	}
+	if p.Team != "" {
+		s += " (" + p.Team + ")"
+	}
+
+	return s
+}
+
+// splitValues splits a metadata value into its items, items are separated by
+// commas outside of quotes, <> and (), by new lines, and may be written as a list with
+// * or - markers, empty items are dropped
+func splitValues(value string) []string {
+	values := []string{}
+	add := func(item string) {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			values = append(values, item)
+		}
+	}
+
+	for _, line := range strings.Split(value, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "* ") || strings.HasPrefix(line, "- ") {
+			line = line[2:]
+		}
+
+		depth := 0
+		start := 0
+		quoted := false
+		for i, r := range line {
+			switch {
+			case r == '"':
+				quoted = !quoted
+			case quoted:
+			case r == '<', r == '(':
+				depth++
+			case r == '>', r == ')':
+				if depth > 0 {
+					depth--
+				}
+			case r == ',':
+				if depth == 0 {
+					add(line[start:i])
+					start = i + 1
+				}
+			}
+		}
+		add(line[start:])
+	}
+
+	return values
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+func runMigrate(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dir := fs.String("dir", settings.Dir, "directory containing ADR files")
+	metadata := fs.String("metadata", "", "convert the metadata of every record, or the given files, to attributes, table or front-matter")
+	dryRun := fs.Bool("dry-run", false, "print the files that would change without writing them")
+	yes := fs.Bool("yes", false, "apply every proposed fix without asking")
+	only := fs.String("only", "", "comma separated kinds of fixes to offer: structure, date, status, filename")
+	fs.Parse(args)
+
+	if *metadata == "" {
+		kinds := migrationKinds
+		if *only != "" {
+			kinds = parseCommaList(*only)
+		}
+		return runMigrationPlan(*dir, kinds, *yes, *dryRun)
+	}
+
+	var convert func(string) string
+	switch *metadata {
+	case "attributes":
+		convert = metadataToAttributes
+	case "table":
+		convert = metadataToTable
+	case "front-matter":
+		convert = metadataToFrontMatter
+	default:
+		return fmt.Errorf("usage: migrate [-metadata attributes|table|front-matter] [files]")
+	}
+
+	files := fs.Args()
+	if len(files) == 0 {
+		adrs, err := loadADRs(*dir)
+		if err != nil {
+			return err
+		}
+		for _, a := range adrs {
+			files = append(files, a.Meta.Path)
+		}
+	}
+
+	for _, file := range files {
+		body, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		content := strings.Replace(string(body), "\r\n", "\n", -1)
+		converted := convert(content)
+		if converted == content {
+			fmt.Printf("%s: skipped, %s\n", file, conversionSkipped(content, *metadata))
+			continue
+		}
+
+		before, err := parseADRContent(file, body)
+		if err != nil {
+			return err
+		}
+		after, err := parseADRContent(file, []byte(converted))
+		if err != nil {
+			return fmt.Errorf("converted metadata does not validate in %s: %s", file, err)
+		}
+		if fmt.Sprint(before.Meta) != fmt.Sprint(after.Meta) {
+			return fmt.Errorf("converted metadata does not match the original in %s", file)
+		}
+
+		fmt.Printf("%s: metadata moved to %s\n", file, *metadata)
+		if *dryRun {
+			continue
+		}
+
+		err = writeOutput(file, func(w io.Writer) error {
+			_, err := io.WriteString(w, converted)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// conversionSkipped explains why converting the metadata of a record to target
+// left it unchanged
+func conversionSkipped(content string, target string) string {
+	b := findMetadata(strings.Split(content, "\n"))
+	notation := "table"
+	switch {
+	case b.Start < 0:
+		return "it has no metadata"
+	case b.FrontMatter:
+		notation = "front-matter"
+	case b.Markdown:
+		return "the metadata table of Markdown records cannot be converted"
+	case b.Attributes:
+		notation = "attributes"
+	}
+
+	switch {
+	case notation == target:
+		return "its metadata is in " + target + " already"
+	case target == "attributes" && b.FrontMatter:
+		return "front matter can only be converted to a table"
+	case target == "attributes" && b.Title < 0:
+		return "it has no = Title heading to put the attributes below"
+	}
+
+	return "the conversion does not change it"
+}
@@ -0,0 +1,383 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// migrationKinds are the groups of fixes in the order they are offered
+var migrationKinds = []string{"structure", "date", "status", "filename"}
+
+// legacyDateLayouts are date formats found in older records, slashes and dots
+// are read day first like the canonical DD-MM-YYYY
+var legacyDateLayouts = []string{"2006-01-02", "2-1-2006", "02/01/2006", "2006/01/02", "02.01.2006", "2 January 2006", "January 2, 2006", "2 Jan 2006", "Jan 2, 2006"}
+
+// statusAliases map statuses used by other ADR tools and the decision pages
+// of Confluence to ours
+var statusAliases = map[string]string{
+	"accepted":    "Approved",
+	"draft":       "Proposed",
+	"in progress": "Partially Implemented",
+	"done":        "Implemented",
+	"decided":     "Approved",
+	"not started": "Proposed",
+	"open":        "Proposed",
+	"in review":   "Proposed",
+}
+
+var legacyFilenameRegex = regexp.MustCompile(`^(\d+)[-_ ]+(.+)\.adoc$`)
+
+// migrationFix is one proposed change to a file, Apply rewrites the content
+// and Rename names the new file
+type migrationFix struct {
+	Kind        string
+	File        string
+	Description string
+	Apply       func(string) string
+	Rename      string
+}
+
+// planMigration inspects the raw files of dir, records that do not parse are
+// included since those are the ones a migration is for
+func planMigration(dir string) ([]migrationFix, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	fixes := []migrationFix{}
+	for _, e := range entries {
+		if e.IsDir() || !isRecordFile(e.Name()) {
+			continue
+		}
+		file := path.Join(dir, e.Name())
+		body, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		content := string(body)
+		t := typeForFile(file)
+
+		if f, ok := structureFix(file, content); ok {
+			fixes = append(fixes, f)
+			content = f.Apply(content)
+		}
+
+		lines := strings.Split(content, "\n")
+		for _, r := range findMetadata(lines).Rows {
+			switch r.Key {
+			case "Date", "Reviewed":
+				if f, ok := dateFix(file, r.Key, r.Value); ok {
+					fixes = append(fixes, f)
+				}
+			case "Status":
+				if f, ok := statusFix(file, t, r.Value); ok {
+					fixes = append(fixes, f)
+				}
+			}
+		}
+
+		if f, ok := filenameFix(file, t); ok {
+			fixes = append(fixes, f)
+		}
+	}
+
+	return fixes, nil
+}
+
+func structureFix(file string, content string) (migrationFix, bool) {
+	problems := []string{}
+	if strings.Contains(content, "\r\n") {
+		problems = append(problems, "CRLF line endings")
+	}
+	fixed := strings.Replace(content, "\r\n", "\n", -1)
+	if trimmed := strings.TrimLeft(fixed, "\n"); trimmed != fixed {
+		problems = append(problems, "blank lines before the title")
+		fixed = trimmed
+	}
+	if strings.HasPrefix(fixed, "# ") && path.Ext(file) == ".adoc" {
+		problems = append(problems, "Markdown title")
+		fixed = "= " + strings.TrimPrefix(fixed, "# ")
+	}
+	if len(problems) == 0 {
+		return migrationFix{}, false
+	}
+
+	return migrationFix{
+		Kind:        "structure",
+		File:        file,
+		Description: strings.Join(problems, ", "),
+		Apply:       func(string) string { return fixed },
+	}, true
+}
+
+func dateFix(file string, key string, value string) (migrationFix, bool) {
+	if _, err := time.Parse(dateLayout, value); err == nil {
+		return migrationFix{}, false
+	}
+
+	canonical, ok := canonicalDate(value)
+	if !ok {
+		return migrationFix{}, false
+	}
+
+	return migrationFix{
+		Kind:        "date",
+		File:        file,
+		Description: fmt.Sprintf("%s %q becomes %s", key, value, canonical),
+		Apply:       func(content string) string { return setMetaValue(content, key, canonical) },
+	}, true
+}
+
+func statusFix(file string, t *RecordType, value string) (migrationFix, bool) {
+	if isValidStatusFor(t, value) {
+		return migrationFix{}, false
+	}
+
+	status := canonicalStatus(t.statuses(), value)
+	if status == "" {
+		return migrationFix{}, false
+	}
+
+	return migrationFix{
+		Kind:        "status",
+		File:        file,
+		Description: fmt.Sprintf("Status %q becomes %s", value, status),
+		Apply:       func(content string) string { return setMetaValue(content, "Status", status) },
+	}, true
+}
+
+// filenameFix renames NN_title.adoc style ADR files to the NNNN-title.adoc
+// convention, the title part of the name is kept
+func filenameFix(file string, t *RecordType) (migrationFix, bool) {
+	if t.Key != adrTypeKey {
+		return migrationFix{}, false
+	}
+
+	m := legacyFilenameRegex.FindStringSubmatch(path.Base(file))
+	if m == nil {
+		return migrationFix{}, false
+	}
+	idx := 0
+	fmt.Sscanf(m[1], "%d", &idx)
+	target := path.Join(path.Dir(file), fmt.Sprintf(t.Filename, idx, slugify(m[2])))
+	if target == file {
+		return migrationFix{}, false
+	}
+	if _, err := os.Stat(target); err == nil {
+		log.Printf("Warning: not renaming %s, %s already exists", file, target)
+		return migrationFix{}, false
+	}
+
+	return migrationFix{
+		Kind:        "filename",
+		File:        file,
+		Description: fmt.Sprintf("rename to %s", path.Base(target)),
+		Rename:      target,
+	}, true
+}
+
+// runMigrationPlan prints the fixes grouped by kind with a diff per file and
+// applies the groups that are confirmed, or all of them with yes
+func runMigrationPlan(dir string, kinds []string, yes bool, dryRun bool) error {
+	if !dryRun {
+		if err := requireYes(yes); err != nil {
+			return err
+		}
+	}
+	fixes, err := planMigration(dir)
+	if err != nil {
+		return err
+	}
+	if len(fixes) == 0 {
+		fmt.Printf("No migration needed in %s\n", dir)
+		return nil
+	}
+
+	in := bufio.NewReader(os.Stdin)
+	selected := []migrationFix{}
+	for _, kind := range migrationKinds {
+		if !containsFold(kinds, kind) {
+			continue
+		}
+		group := []migrationFix{}
+		for _, f := range fixes {
+			if f.Kind == kind {
+				group = append(group, f)
+			}
+		}
+		if len(group) == 0 {
+			continue
+		}
+
+		fmt.Printf("== %s fixes (%d)\n", kind, len(group))
+		for _, f := range group {
+			fmt.Printf("%s: %s\n", f.File, f.Description)
+			if f.Apply != nil {
+				printFixDiff(os.Stdout, f)
+			}
+		}
+
+		if dryRun {
+			continue
+		}
+		if !yes && !confirm(in, fmt.Sprintf("Apply %d %s fixes?", len(group), kind)) {
+			continue
+		}
+		selected = append(selected, group...)
+	}
+
+	if dryRun || len(selected) == 0 {
+		return nil
+	}
+
+	return applyMigration(selected)
+}
+
+// applyMigration applies content fixes per file before renaming, so a file
+// keeps its fixes when it moves
+func applyMigration(fixes []migrationFix) error {
+	unlock, err := lockRepo()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for _, file := range fixedFiles(fixes) {
+		body, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		content := string(body)
+		rename := ""
+		for _, f := range fixes {
+			if f.File != file {
+				continue
+			}
+			if f.Apply != nil {
+				content = f.Apply(content)
+			}
+			if f.Rename != "" {
+				rename = f.Rename
+			}
+		}
+
+		if content != string(body) {
+			err = writeOutput(file, func(w io.Writer) error {
+				_, err := io.WriteString(w, content)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if rename != "" {
+			err = moveFile(file, rename)
+			if err != nil {
+				return err
+			}
+		}
+		fmt.Printf("Migrated %s\n", file)
+	}
+
+	return nil
+}
+
+func fixedFiles(fixes []migrationFix) []string {
+	files := []string{}
+	seen := map[string]bool{}
+	for _, f := range fixes {
+		if !seen[f.File] {
+			seen[f.File] = true
+			files = append(files, f.File)
+		}
+	}
+
+	return files
+}
+
+// printFixDiff shows the lines a fix changes, compared to the file on disk
+func printFixDiff(w io.Writer, f migrationFix) {
+	body, err := ioutil.ReadFile(f.File)
+	if err != nil {
+		return
+	}
+	before := strings.Split(strings.Replace(string(body), "\r\n", "\n", -1), "\n")
+	after := strings.Split(strings.Replace(f.Apply(string(body)), "\r\n", "\n", -1), "\n")
+
+	p := newPainter(w)
+	for _, l := range lineDiff(before, after) {
+		if strings.HasPrefix(l, "-") {
+			l = p.error(l)
+		} else {
+			l = p.ok(l)
+		}
+		fmt.Fprintf(w, "    %s\n", l)
+	}
+}
+
+// lineDiff returns the removed and added lines of a longest common
+// subsequence diff, records are short enough for the quadratic table
+func lineDiff(a []string, b []string) []string {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	out := []string{}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			out = append(out, "-"+a[i])
+			i++
+		default:
+			out = append(out, "+"+b[j])
+			j++
+		}
+	}
+
+	return out
+}
+
+// confirm asks a yes or no question on the terminal, anything but y declines.
+// The question goes to stderr so it stays out of redirected output
+func confirm(in *bufio.Reader, question string) bool {
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", question)
+	answer, _ := in.ReadString('\n')
+
+	return strings.EqualFold(strings.TrimSpace(answer), "y")
+}
+
+// requireYes fails unless yes is set when the output is structured, the output
+// is captured for the envelope and nobody would see or answer a confirm
+func requireYes(yes bool) error {
+	if yes || !structuredOutput() {
+		return nil
+	}
+
+	return fmt.Errorf("-yes is required with --format %s, there is no terminal to confirm the changes on", outputFormat)
+}
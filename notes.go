@@ -0,0 +1,296 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// noteDecision is an entry of the decision log of a meeting notes file, Record
+// is the record it was promoted to
+type noteDecision struct {
+	File     string `json:"file"`
+	Meeting  string `json:"meeting"`
+	ID       string `json:"id"`
+	Decision string `json:"decision"`
+	Owner    string `json:"owner,omitempty"`
+	Context  string `json:"context,omitempty"`
+	Record   string `json:"record,omitempty"`
+}
+
+var (
+	decisionLogHeading = regexp.MustCompile(`^(?i)(=+|#+)\s+decision log\s*$`)
+	noteHeading        = regexp.MustCompile(`^(=+|#+)\s+\S`)
+)
+
+// key is how records name the entry they were promoted from, the notes file
+// and the anchor of the entry, e.g. 2026-10-01-arch-sync.adoc#D2
+func (d noteDecision) key() string {
+	return path.Base(d.File) + "#" + d.ID
+}
+
+// readDecisionLog reads the table of the Decision log section of a meeting
+// notes file, the first row names the columns ID, Decision and optionally
+// Owner, Context and Record
+func readDecisionLog(file string) ([]noteDecision, error) {
+	body, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(strings.Replace(string(body), "\r\n", "\n", -1), "\n")
+	meeting := strings.TrimSuffix(path.Base(file), path.Ext(file))
+	rows := [][]string{}
+	inLog := false
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		switch {
+		case i == 0 && noteHeading.MatchString(trimmed):
+			meeting = strings.TrimSpace(strings.TrimLeft(trimmed, "=#"))
+		case decisionLogHeading.MatchString(trimmed):
+			inLog = true
+		case !inLog:
+		case noteHeading.MatchString(trimmed):
+			inLog = false
+		case strings.HasPrefix(trimmed, "|==="):
+			end := i + 1
+			for end < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[end]), "|===") {
+				end++
+			}
+			rows = append(rows, asciidocRows(lines[i+1:minInt(end, len(lines))])...)
+			i = end
+		case markupMarkdownRow.MatchString(trimmed):
+			if !markdownSeparatorRegex.MatchString(trimmed) {
+				rows = append(rows, splitMarkdownRow(trimmed))
+			}
+		}
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	columns := map[string]int{}
+	for i, name := range rows[0] {
+		columns[strings.ToLower(name)] = i
+	}
+	for _, name := range []string{"id", "decision"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("the decision log has no %s column in %s", strings.Title(name), file)
+		}
+	}
+	cell := func(row []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return row[i]
+	}
+
+	decisions := []noteDecision{}
+	seen := map[string]bool{}
+	for _, row := range rows[1:] {
+		d := noteDecision{
+			File:     file,
+			Meeting:  meeting,
+			ID:       cell(row, "id"),
+			Decision: cell(row, "decision"),
+			Owner:    cell(row, "owner"),
+			Context:  cell(row, "context"),
+			Record:   cell(row, "record"),
+		}
+		if d.ID == "" || d.Decision == "" {
+			return nil, fmt.Errorf("a decision log entry lacks its ID or decision in %s", file)
+		}
+		if seen[d.ID] {
+			return nil, fmt.Errorf("decision %s is logged twice in %s", d.ID, file)
+		}
+		seen[d.ID] = true
+		decisions = append(decisions, d)
+	}
+
+	return decisions, nil
+}
+
+// loadDecisionLogs reads the decision logs of the notes below dir and fills in
+// the records that name an entry as their origin
+func loadDecisionLogs(dir string, adrs []*ADR) ([]noteDecision, error) {
+	decisions := []noteDecision{}
+	err := filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		ext := filepath.Ext(file)
+		if info.IsDir() || (ext != ".adoc" && ext != ".md") {
+			return nil
+		}
+		logged, err := readDecisionLog(filepath.ToSlash(file))
+		if err != nil {
+			return err
+		}
+		decisions = append(decisions, logged...)
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no meeting notes in %s, set notesDir in %s or pass -notes", dir, configFile)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, a := range adrs {
+		body, err := ioutil.ReadFile(a.Meta.Path)
+		if err != nil {
+			return nil, err
+		}
+		for i, d := range decisions {
+			if d.Record == "" && strings.Contains(string(body), d.key()) {
+				decisions[i].Record = recordLabel(a)
+			}
+		}
+	}
+
+	return decisions, nil
+}
+
+// originSection links a record back to the meeting that took the decision,
+// loadDecisionLogs finds the link to tell the entry is promoted
+func (d noteDecision) originSection(adrDir string) string {
+	target := d.File
+	if abs, err := filepath.Abs(d.File); err == nil {
+		if base, err := filepath.Abs(adrDir); err == nil {
+			if rel, err := filepath.Rel(base, abs); err == nil {
+				target = filepath.ToSlash(rel)
+			}
+		}
+	}
+
+	b := &strings.Builder{}
+	b.WriteString("\n== Origin\n\n")
+	fmt.Fprintf(b, "Promoted from decision %s of link:%s#%s[%s]", d.ID, target, d.ID, d.Meeting)
+	if d.Owner != "" {
+		fmt.Fprintf(b, ", owned by %s", d.Owner)
+	}
+	fmt.Fprintf(b, ":\n\n____\n%s\n____\n", d.Decision)
+	if d.Context != "" {
+		fmt.Fprintf(b, "\n%s\n", d.Context)
+	}
+
+	return b.String()
+}
+
+// selectDecision finds the entry named by ID, or by notes file and ID when
+// meetings reuse IDs, e.g. 2026-10-01-arch-sync#D2
+func selectDecision(decisions []noteDecision, name string) (noteDecision, error) {
+	found := []noteDecision{}
+	for _, d := range decisions {
+		stem := strings.TrimSuffix(path.Base(d.File), path.Ext(d.File))
+		if d.ID == name || d.key() == name || stem+"#"+d.ID == name {
+			found = append(found, d)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return noteDecision{}, fmt.Errorf("no decision %s in the meeting notes", name)
+	case 1:
+		return found[0], nil
+	}
+	keys := []string{}
+	for _, d := range found {
+		keys = append(keys, d.key())
+	}
+
+	return noteDecision{}, fmt.Errorf("decision %s is logged by several meetings, name one of %s", name, strings.Join(keys, ", "))
+}
+
+// runNotes lists the decisions of the meeting notes that have no record yet
+// and with -promote scaffolds Proposed records from the selected entries
+func runNotes(args []string) error {
+	fs := flag.NewFlagSet("notes", flag.ExitOnError)
+	dir := fs.String("dir", settings.Dir, "directory containing ADR files")
+	notesDir := fs.String("notes", cfg.notesDir(), "directory containing the meeting notes")
+	all := fs.Bool("all", false, "list the promoted decisions with their records as well")
+	promote := fs.String("promote", "", "comma separated decisions to scaffold records from, by ID or notes file#ID")
+	recordType := fs.String("type", adrTypeKey, "record type of the promoted records")
+	tags := fs.String("tags", placeholderTag, "comma separated tags of the promoted records")
+	fs.Parse(args)
+
+	adrs, err := loadADRs(*dir)
+	if err != nil {
+		return err
+	}
+	decisions, err := loadDecisionLogs(*notesDir, adrs)
+	if err != nil {
+		return err
+	}
+
+	if *promote == "" {
+		listed := []noteDecision{}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, d := range decisions {
+			if d.Record != "" && !*all {
+				continue
+			}
+			listed = append(listed, d)
+			record := d.Record
+			if record == "" {
+				record = "-"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", d.key(), record, d.Decision, d.Owner)
+		}
+		w.Flush()
+		setResults(listed)
+		if len(listed) == 0 {
+			fmt.Printf("Every decision of the meeting notes in %s has a record\n", *notesDir)
+		}
+		return nil
+	}
+
+	t := typeByName(*recordType)
+	if t == nil {
+		return fmt.Errorf("unknown record type %q", *recordType)
+	}
+	promoted := []noteDecision{}
+	for _, name := range parseCommaList(*promote) {
+		d, err := selectDecision(decisions, name)
+		if err != nil {
+			return err
+		}
+		if d.Record != "" {
+			return fmt.Errorf("decision %s was already promoted to %s", d.key(), d.Record)
+		}
+
+		authors := []string{gitAuthor()}
+		if d.Owner != "" {
+			authors = parseCommaList(d.Owner)
+		}
+		d.Record, err = createADR(*dir, scaffold{
+			Type:     t,
+			Title:    d.Decision,
+			Authors:  authors,
+			Tags:     parseCommaList(*tags),
+			Status:   "Proposed",
+			Date:     time.Now(),
+			Template: t.Skeleton,
+			Appendix: d.originSection(*dir),
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := parseADR(d.Record); err != nil {
+			os.Remove(d.Record)
+			return fmt.Errorf("promoted record does not validate, %s in %s", err, d.File)
+		}
+		fmt.Printf("%s: %s\n", d.key(), d.Record)
+		promoted = append(promoted, d)
+	}
+	setResults(promoted)
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+)
+
+// offline is set by the global --offline flag, no command then talks to the
+// network and everything skipped for that reason is reported on exit
+var offline bool
+
+var (
+	offlineMu      sync.Mutex
+	offlineSkipped []string
+)
+
+// networkAllowed reports whether what may use the network, recording it as
+// skipped in offline mode
+func networkAllowed(what string) bool {
+	if !offline {
+		return true
+	}
+
+	offlineMu.Lock()
+	offlineSkipped = append(offlineSkipped, what)
+	offlineMu.Unlock()
+
+	return false
+}
+
+func reportOffline() {
+	if !offline {
+		return
+	}
+
+	if len(offlineSkipped) == 0 {
+		log.Println("Offline mode: no network features were needed")
+		return
+	}
+
+	log.Printf("Offline mode: skipped %d network features", len(offlineSkipped))
+	for _, s := range offlineSkipped {
+		log.Printf("  skipped %s", s)
+	}
+}
+
+// offlineTransport refuses every request, it backs up the checks in the
+// commands should one of them miss a network call
+type offlineTransport struct{}
+
+func (offlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	networkAllowed(fmt.Sprintf("%s %s", req.Method, req.URL.Host+req.URL.Path))
+
+	return nil, fmt.Errorf("%s %s: network access is disabled by --offline", req.Method, req.URL.Host+req.URL.Path)
+}
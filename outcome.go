@@ -0,0 +1,134 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// validOutcomes are the verdicts of an outcome review, recorded in the Outcome
+// metadata row and explained in the Outcome section
+var validOutcomes = []string{"Confirmed", "Mixed", "Refuted"}
+
+func isValidOutcome(outcome string) bool {
+	for _, o := range validOutcomes {
+		if o == outcome {
+			return true
+		}
+	}
+
+	return false
+}
+
+type outcomeReview struct {
+	ADR         *ADR
+	Implemented time.Time
+}
+
+func runOutcomes(args []string) error {
+	fs := flag.NewFlagSet("outcomes", flag.ExitOnError)
+	dir := fs.String("dir", settings.Dir, "directory containing ADR files")
+	after := fs.Int("after", 6, "months after implementation an outcome review is due")
+	webhook := fs.String("webhook", "", "post the decisions due for review to this Slack compatible webhook")
+	fs.Parse(args)
+
+	adrs, err := loadADRs(*dir)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	due := []outcomeReview{}
+	reviewed := map[string][]*ADR{}
+	for _, a := range searchADRs(settings.Filter.apply(adrs), "") {
+		if a.Meta.Outcome != "" {
+			reviewed[a.Meta.Outcome] = append(reviewed[a.Meta.Outcome], a)
+			continue
+		}
+		if a.Meta.Status != "Implemented" && a.Meta.Status != "Partially Implemented" {
+			continue
+		}
+
+		since := implementedSince(a)
+		if !since.AddDate(0, *after, 0).After(now) {
+			due = append(due, outcomeReview{ADR: a, Implemented: since})
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintf(w, "Due for outcome review (%d)\n", len(due))
+	for _, r := range due {
+		fmt.Fprintf(w, "  %s\t%s\timplemented %s\t%s\n", recordLabel(r.ADR), r.ADR.Heading, r.Implemented.Format("2006-01-02"), age(now.Sub(r.Implemented)))
+	}
+
+	fmt.Fprintf(w, "\nValidated decisions (%d)\n", len(reviewed["Confirmed"]))
+	for _, a := range reviewed["Confirmed"] {
+		fmt.Fprintf(w, "  %s\t%s\t%s\n", recordLabel(a), a.Heading, outcomeSummary(a))
+	}
+
+	for _, o := range validOutcomes[1:] {
+		fmt.Fprintf(w, "\n%s outcomes (%d)\n", o, len(reviewed[o]))
+		for _, a := range reviewed[o] {
+			fmt.Fprintf(w, "  %s\t%s\t%s\n", recordLabel(a), a.Heading, outcomeSummary(a))
+		}
+	}
+
+	if *webhook != "" && len(due) > 0 && networkAllowed("outcome review webhook") {
+		return apiRequest("POST", *webhook, nil, map[string]string{"text": outcomePrompt(due)}, nil)
+	}
+
+	return nil
+}
+
+// implementedSince returns when the Status row or attribute first read Implemented according
+// to git, the decision date is used outside a repository or for untracked files
+func implementedSince(a *ADR) time.Time {
+	out, err := git("log", "--reverse", "--format=%at", "-E", "-G", `^(\|Status *\||:status:).*Implemented`, "--", a.Meta.Path)
+	if err != nil || out == "" {
+		return a.Meta.Date
+	}
+
+	sec, err := strconv.ParseInt(strings.SplitN(out, "\n", 2)[0], 10, 64)
+	if err != nil {
+		return a.Meta.Date
+	}
+
+	return time.Unix(sec, 0)
+}
+
+// outcomeSummary returns the first paragraph of the Outcome section
+func outcomeSummary(a *ADR) string {
+	body, err := ioutil.ReadFile(a.Meta.Path)
+	if err != nil {
+		return ""
+	}
+
+	for _, s := range splitSections(string(body)) {
+		if strings.EqualFold(s.Title, "Outcome") {
+			return strings.SplitN(s.Body, "\n\n", 2)[0]
+		}
+	}
+
+	return ""
+}
+
+func outcomePrompt(due []outcomeReview) string {
+	lines := []string{fmt.Sprintf("%d decisions are due for an outcome review, did they work out?", len(due))}
+	for _, r := range due {
+		name := fmt.Sprintf("%s %s", recordLabel(r.ADR), r.ADR.Heading)
+		if link := siteLink(r.ADR); link != "" {
+			name = fmt.Sprintf("<%s|%s>", link, name)
+		}
+		line := fmt.Sprintf("• %s, implemented %s", name, r.Implemented.Format("2006-01-02"))
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+// parseMode decides what happens to problems found while parsing a record,
+// the default fails on invalid metadata and warns about lint findings
+type parseMode int
+
+const (
+	parseDefault parseMode = iota
+	// parseStrict fails on lint findings too, for CI gating
+	parseStrict
+	// parseLenient warns about invalid metadata and falls back to defaults, for
+	// exploratory reports over legacy content
+	parseLenient
+)
+
+var parsing = parseDefault
+
+func setParseMode(strict bool, lenient bool) error {
+	switch {
+	case strict && lenient:
+		return fmt.Errorf("--strict and --lenient cannot be combined")
+	case strict:
+		parsing = parseStrict
+	case lenient:
+		parsing = parseLenient
+	}
+
+	return nil
+}
+
+// recordParser applies a parse mode to one record, findings are logged as
+// warnings unless Collect is set
+type recordParser struct {
+	mode parseMode
+	// Collect keeps the findings instead of logging them
+	Collect  bool
+	Findings []Finding
+}
+
+func (rp *recordParser) report(severity string, err error) {
+	if rp.Collect {
+		rp.Findings = append(rp.Findings, newFinding(severity, err))
+		return
+	}
+	log.Printf("Warning: %s", err)
+}
+
+// lint reports a finding that does not keep the record from being read, it is
+// an error in strict mode
+func (rp *recordParser) lint(err error) error {
+	if rp.mode == parseStrict {
+		return err
+	}
+	rp.report(severityWarning, err)
+
+	return nil
+}
+
+// tolerate reports an invalid record as a warning in lenient mode, the caller
+// then continues with a default value, in the other modes err is returned
+func (rp *recordParser) tolerate(err error) error {
+	if rp.mode != parseLenient {
+		return err
+	}
+	rp.report(severityError, err)
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordBody is a record with the given metadata table rows
+func recordBody(rows ...string) string {
+	return "= Use Kafka\n\n|===\n|Metadata |Value\n" + strings.Join(rows, "\n") + "\n|===\n\n== Context\n"
+}
+
+func TestParseNotations(t *testing.T) {
+	want := ADRMeta{
+		Index:   1,
+		Date:    time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		Authors: []string{"@alice", "@bob"},
+		People:  []Person{{Name: "@alice"}, {Name: "@bob"}},
+		Status:  "Approved",
+		Tags:    []string{"messaging", "infra"},
+	}
+
+	tests := []struct {
+		name string
+		file string
+		body string
+	}{
+		{"table", "adr/0001-use-kafka.adoc", recordBody("|Date |01-02-2024", "|Author |@alice, @bob", "|Status |Approved", "|Tags |messaging, infra")},
+		{"repeated rows", "adr/0001-use-kafka.adoc", recordBody("|Date |01-02-2024", "|Author |@alice", "|Author |@bob", "|Status |Approved", "|Tags |messaging", "|Tags |infra")},
+		{"multi-line cells", "adr/0001-use-kafka.adoc", recordBody("|Date |01-02-2024", "|Author |@alice", "@bob", "|Status", "|Approved", "|Tags |messaging", "* infra")},
+		{"attributes", "adr/0001-use-kafka.adoc", "= Use Kafka\n:date: 01-02-2024\n:authors: @alice, @bob\n:status: Approved\n:tags: messaging, infra\n\n== Context\n"},
+		{"markdown", "adr/0001-use-kafka.md", "# Use Kafka\n\n| Metadata | Value |\n|---|---|\n| Date | 01-02-2024 |\n| Author | @alice, @bob |\n| Status | Approved |\n| Tags | messaging, infra |\n\n## Context\n"},
+		{"front matter", "adr/0001-use-kafka.md", "---\ndate: 2024-02-01\nauthors: [\"@alice\", \"@bob\"]\nstatus: Approved\ntags:\n  - messaging\n  - infra\n---\n# Use Kafka\n\n## Context\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a, err := (&recordParser{mode: parseDefault, Collect: true}).parse(test.file, []byte(test.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if a.Heading != "Use Kafka" {
+				t.Errorf("Heading = %q, want %q", a.Heading, "Use Kafka")
+			}
+			meta := want
+			meta.Path = test.file
+			meta.Type = a.Meta.Type
+			if !reflect.DeepEqual(a.Meta, meta) {
+				t.Errorf("Meta = %+v, want %+v", a.Meta, meta)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	valid := []string{"|Date |01-02-2024", "|Author |@alice", "|Status |Approved", "|Tags |messaging"}
+	replace := func(i int, row string) []string {
+		rows := append([]string{}, valid...)
+		rows[i] = row
+		return rows
+	}
+	without := func(i int) []string {
+		return append(append([]string{}, valid[:i]...), valid[i+1:]...)
+	}
+
+	tests := []struct {
+		name string
+		file string
+		body string
+		mode parseMode
+		// err is a part of the error, empty when the record parses
+		err string
+		// line is the line of an ErrInvalidMetadata or ErrInvalidStatus
+		line int
+		// findings is the number of findings reported without failing
+		findings int
+	}{
+		{name: "invalid date", body: recordBody(replace(0, "|Date |2024-02-01")...), err: "invalid date format", line: 5},
+		{name: "invalid date lenient", body: recordBody(replace(0, "|Date |2024-02-01")...), mode: parseLenient, findings: 1},
+		{name: "invalid status", body: recordBody(replace(2, "|Status |Maybe")...), err: `invalid status "Maybe"`, line: 7},
+		{name: "invalid status lenient", body: recordBody(replace(2, "|Status |Maybe")...), mode: parseLenient, findings: 1},
+		{name: "invalid status in attributes", body: "= Use Kafka\n:date: 01-02-2024\n:author: @alice\n:status: Maybe\n:tags: messaging\n", err: `invalid status "Maybe"`, line: 4},
+		{name: "invalid status in front matter", file: "adr/0001-use-kafka.md", body: "---\nstatus: Maybe\ndate: 2024-02-01\nauthor: \"@alice\"\ntags: [messaging]\n---\n# Use Kafka\n", err: `invalid status "Maybe"`, line: 2},
+		{name: "missing tags", body: recordBody(without(3)...), err: "tags is required"},
+		{name: "missing tags lenient", body: recordBody(without(3)...), mode: parseLenient, findings: 1},
+		{name: "missing author", body: recordBody(without(1)...), err: "author is required"},
+		{name: "bad outcome", body: recordBody(append(valid, "|Outcome |Great")...), err: `invalid outcome "Great"`, line: 9},
+		{name: "bad cost", body: recordBody(append(valid, "|Cost |lots")...), err: `invalid cost "lots"`, line: 9},
+		{name: "bad scope", body: recordBody(append(valid, "|Scope |galaxy:far")...), err: "invalid scope", line: 9},
+		{name: "unknown key", body: recordBody(append(valid, "|Color |blue")...), findings: 1},
+		{name: "unknown key strict", body: recordBody(append(valid, "|Color |blue")...), mode: parseStrict, err: "unexpected metadata key Color", line: 9},
+		{name: "repeated key", body: recordBody(append(valid, "|Status |Rejected")...), findings: 1},
+		{name: "repeated key strict", body: recordBody(append(valid, "|Status |Rejected")...), mode: parseStrict, err: "repeated metadata key Status", line: 9},
+		{name: "first invalid row is reported", body: recordBody("|Reviewed |soon", "|Date |later", "|Author |@alice", "|Status |Approved", "|Tags |messaging"), err: "invalid date format", line: 5},
+		{name: "missing title", body: "|===\n|Metadata |Value\n" + strings.Join(valid, "\n") + "\n|===\n", findings: 1},
+		{name: "missing title strict", body: "|===\n|Metadata |Value\n" + strings.Join(valid, "\n") + "\n|===\n", mode: parseStrict, err: "missing = Title heading"},
+		{name: "no index", file: "adr/use-kafka.adoc", body: recordBody(valid...), err: "invalid file sequence"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			file := test.file
+			if file == "" {
+				file = "adr/0001-use-kafka.adoc"
+			}
+			rp := &recordParser{mode: test.mode, Collect: true}
+			_, err := rp.parse(file, []byte(test.body))
+
+			if test.err == "" {
+				if err != nil {
+					t.Fatalf("unexpected error %s", err)
+				}
+				if len(rp.Findings) != test.findings {
+					t.Errorf("%d findings, want %d: %+v", len(rp.Findings), test.findings, rp.Findings)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("error %v, want one containing %q", err, test.err)
+			}
+			if !strings.HasSuffix(err.Error(), " in "+file) {
+				t.Errorf("error %q does not name %s", err, file)
+			}
+
+			line := 0
+			var invalid *ErrInvalidMetadata
+			var status *ErrInvalidStatus
+			switch {
+			case errors.As(err, &invalid):
+				line = invalid.Line
+			case errors.As(err, &status):
+				line = status.Line
+			}
+			if line != test.line {
+				t.Errorf("error on line %d, want %d", line, test.line)
+			}
+		})
+	}
+}
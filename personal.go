@@ -0,0 +1,245 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PersonalConfig enables the page of what is relevant to the reader at /me,
+// serve trusts the headers of the authenticating proxy in front of it, with
+// oauth2-proxy they carry the email and groups claims of the OIDC token
+type PersonalConfig struct {
+	// UserHeader names the reader, e.g. X-Forwarded-Email, /me is disabled
+	// when empty
+	UserHeader string `yaml:"userHeader"`
+	// GroupsHeader lists the teams of the reader comma separated, e.g.
+	// X-Forwarded-Groups
+	GroupsHeader string `yaml:"groupsHeader"`
+	// Teams is a YAML file mapping team names to their members and the tags
+	// they follow, a team not in the file follows the tag of its name
+	Teams string `yaml:"teams"`
+	// RecentDays is how long changes are listed for, 14 days when zero
+	RecentDays int `yaml:"recentDays"`
+}
+
+func (p PersonalConfig) enabled() bool {
+	return p.UserHeader != ""
+}
+
+func (p PersonalConfig) recent() time.Duration {
+	days := p.RecentDays
+	if days <= 0 {
+		days = 14
+	}
+
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// teamConfig is a team of the teams file
+type teamConfig struct {
+	Members []string `yaml:"members"`
+	Tags    []string `yaml:"tags"`
+}
+
+func loadTeams(file string) (map[string]teamConfig, error) {
+	teams := map[string]teamConfig{}
+	if file == "" {
+		return teams, nil
+	}
+
+	body, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	err = yaml.Unmarshal(body, &teams)
+	if err != nil {
+		return nil, fmt.Errorf("invalid teams file %s: %s", file, err)
+	}
+
+	return teams, nil
+}
+
+// reader is who asks for /me, their teams from the groups header and the teams
+// file and the tags those teams follow
+type reader struct {
+	User  string   `json:"user"`
+	Teams []string `json:"teams"`
+	Tags  []string `json:"tags"`
+}
+
+// identify reads the reader from the proxy headers, false when the request
+// carries no user
+func identify(r *http.Request, pc PersonalConfig, teams map[string]teamConfig) (reader, bool) {
+	rd := reader{User: strings.TrimSpace(r.Header.Get(pc.UserHeader)), Teams: []string{}, Tags: []string{}}
+	if rd.User == "" {
+		return rd, false
+	}
+
+	if groups := r.Header.Get(pc.GroupsHeader); groups != "" {
+		rd.Teams = append(rd.Teams, parseCommaList(groups)...)
+	}
+	names := []string{}
+	for name := range teams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if containsFold(teams[name].Members, rd.User) && !containsFold(rd.Teams, name) {
+			rd.Teams = append(rd.Teams, name)
+		}
+	}
+
+	for _, team := range rd.Teams {
+		tags := []string{team}
+		for name, t := range teams {
+			if strings.EqualFold(name, team) && len(t.Tags) > 0 {
+				tags = t.Tags
+			}
+		}
+		for _, tag := range tags {
+			if !containsFold(rd.Tags, tag) {
+				rd.Tags = append(rd.Tags, tag)
+			}
+		}
+	}
+
+	return rd, true
+}
+
+// is tells whether a person or team value such as an approver or an author
+// means the reader
+func (rd reader) is(value string) bool {
+	p := parsePerson(value)
+	if strings.EqualFold(p.Name, rd.User) || (p.Email != "" && strings.EqualFold(p.Email, rd.User)) {
+		return true
+	}
+
+	return containsFold(rd.Teams, p.Name)
+}
+
+func (rd reader) authored(a *ADR) bool {
+	for _, p := range a.Meta.People {
+		if strings.EqualFold(p.Name, rd.User) || (p.Email != "" && strings.EqualFold(p.Email, rd.User)) {
+			return true
+		}
+	}
+	for _, name := range a.Meta.Authors {
+		if strings.EqualFold(name, rd.User) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (rd reader) follows(a *ADR) bool {
+	for _, t := range a.Meta.Tags {
+		if containsFold(rd.Tags, t) {
+			return true
+		}
+	}
+
+	return rd.authored(a)
+}
+
+// changedRecord is a record with the time it last changed
+type changedRecord struct {
+	ADR     *ADR      `json:"record"`
+	Changed time.Time `json:"changed"`
+}
+
+// personalPage is what /me shows the reader, the pending records waiting for
+// their approval, what changed recently in the records they follow and the
+// records of their tags and their own that are not terminal
+type personalPage struct {
+	Title      string          `json:"-"`
+	Reader     reader          `json:"reader"`
+	RecentDays int             `json:"recentDays"`
+	Approvals  []*ADR          `json:"approvals"`
+	Changed    []changedRecord `json:"changed"`
+	Records    []*ADR          `json:"records"`
+}
+
+func personalView(rd reader, adrs []*ADR, updated map[*ADR]time.Time, now time.Time, recent time.Duration) personalPage {
+	page := personalPage{
+		Title:      "Relevant to " + rd.User,
+		Reader:     rd,
+		RecentDays: int(recent.Hours() / 24),
+		Approvals:  []*ADR{},
+		Changed:    []changedRecord{},
+		Records:    []*ADR{},
+	}
+
+	for _, a := range adrs {
+		lifecycle := lifecycleOf(a.Meta.Status)
+		if lifecycle == lifecyclePending {
+			for _, approver := range a.Meta.Approvers {
+				if rd.is(approver) {
+					page.Approvals = append(page.Approvals, a)
+					break
+				}
+			}
+		}
+		if !rd.follows(a) {
+			continue
+		}
+		if t := updated[a]; now.Sub(t) <= recent {
+			page.Changed = append(page.Changed, changedRecord{a, t})
+		}
+		if lifecycle != lifecycleTerminal {
+			page.Records = append(page.Records, a)
+		}
+	}
+	sort.SliceStable(page.Changed, func(i, j int) bool {
+		return page.Changed[i].Changed.After(page.Changed[j].Changed)
+	})
+
+	return page
+}
+
+func (s *server) personalPage(w http.ResponseWriter, r *http.Request) (personalPage, bool) {
+	if !cfg.Personal.enabled() {
+		http.Error(w, "the personal view is disabled, set personal.userHeader in "+configFile, http.StatusNotFound)
+		return personalPage{}, false
+	}
+	rd, ok := identify(r, cfg.Personal, s.teams)
+	if !ok {
+		http.Error(w, "no user, serve expects the "+cfg.Personal.UserHeader+" header of an authenticating proxy", http.StatusUnauthorized)
+		return personalPage{}, false
+	}
+
+	// the answer differs per reader, shared caches must not keep it
+	w.Header().Set("Cache-Control", "private, no-store")
+	snap := s.snapshot()
+	return personalView(rd, snap.ADRs, snap.Updated, time.Now(), cfg.Personal.recent()), true
+}
+
+func (s *server) handleMe(w http.ResponseWriter, r *http.Request) {
+	page, ok := s.personalPage(w, r)
+	if !ok {
+		return
+	}
+
+	body, err := s.snapshot().execute(serveMeTemplate, page)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(body)
+}
+
+func (s *server) handleMeAPI(w http.ResponseWriter, r *http.Request) {
+	page, ok := s.personalPage(w, r)
+	if !ok {
+		return
+	}
+
+	writeResult(w, r, http.StatusOK, page)
+}
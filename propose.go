@@ -0,0 +1,359 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+type proposal struct {
+	ADR       *ADR
+	Branch    string
+	Base      string
+	Title     string
+	Body      string
+	Reviewers []string
+}
+
+func runPropose(args []string) error {
+	fs := flag.NewFlagSet("propose", flag.ExitOnError)
+	dir := fs.String("dir", settings.Dir, "directory containing ADR files")
+	recordType := fs.String("type", adrTypeKey, "record type of the new record")
+	template := fs.String("template", "", "skeleton used for the new record, defaults to the skeleton of the record type")
+	author := fs.String("author", gitAuthor(), "comma separated authors of the new ADR")
+	tags := fs.String("tags", "", "comma separated tags of the new ADR")
+	reviewers := fs.String("reviewers", "", "comma separated reviewers, defaults to authors of ADRs sharing a tag")
+	remote := fs.String("remote", "origin", "git remote to push to")
+	base := fs.String("base", "", "target branch of the pull request, defaults to the remote HEAD")
+	provider := fs.String("provider", "auto", "code host API to use: auto, github or gitlab")
+	noPR := fs.Bool("no-pr", false, "create the branch and commit but do not push or open a pull request")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: propose [flags] \"<title>\"")
+	}
+	title := fs.Arg(0)
+
+	if *tags == "" {
+		return fmt.Errorf("at least one tag is required, use -tags")
+	}
+
+	t := typeByName(*recordType)
+	if t == nil {
+		return fmt.Errorf("unknown record type %q", *recordType)
+	}
+	if *template == "" {
+		*template = t.Skeleton
+	}
+
+	existing, err := loadADRs(*dir)
+	if err != nil {
+		return err
+	}
+
+	target, err := createADR(*dir, scaffold{
+		Type:     t,
+		Title:    title,
+		Authors:  parseCommaList(*author),
+		Tags:     parseCommaList(*tags),
+		Status:   "Proposed",
+		Date:     time.Now(),
+		Template: *template,
+	})
+	if err != nil {
+		return err
+	}
+
+	adr, err := parseADR(target)
+	if err != nil {
+		return fmt.Errorf("scaffolded ADR does not validate, check %s: %s", *template, err)
+	}
+
+	p := proposal{
+		ADR:    adr,
+		Branch: "adr/" + strings.TrimSuffix(path.Base(target), path.Ext(target)),
+		Base:   *base,
+		Title:  fmt.Sprintf("%s-%d: %s", t.Label, adr.Meta.Index, title),
+	}
+	if p.Base == "" {
+		p.Base = defaultBranch(*remote)
+	}
+	if *reviewers != "" {
+		p.Reviewers = parseCommaList(*reviewers)
+	} else {
+		p.Reviewers = suggestReviewers(existing, adr, 3)
+	}
+	p.Body = proposalBody(p)
+
+	if !*noPR && !networkAllowed(fmt.Sprintf("pushing %s and opening a pull request", p.Branch)) {
+		*noPR = true
+	}
+
+	msg, err := formatCommit(commitEntry{Verb: statusVerbs["Proposed"], Index: adr.Meta.Index, Slug: fileSlug(target), Title: adr.Heading, Status: adr.Meta.Status})
+	if err != nil {
+		return err
+	}
+	// only the record is committed, whatever else is staged stays staged
+	steps := [][]string{
+		{"checkout", "-b", p.Branch},
+		{"add", "--", target},
+		{"commit", "-m", msg, "--", target},
+	}
+	if !*noPR {
+		steps = append(steps, []string{"push", "-u", *remote, p.Branch})
+	}
+	for _, step := range steps {
+		_, err := git(step...)
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Printf("Created %s on branch %s", target, p.Branch)
+
+	if *noPR {
+		return nil
+	}
+
+	r, err := remoteFor(*remote)
+	if err != nil {
+		return err
+	}
+
+	if *provider == "auto" {
+		*provider = "github"
+		if strings.Contains(r.Host, "gitlab") {
+			*provider = "gitlab"
+		}
+	}
+
+	var link string
+	switch *provider {
+	case "github":
+		link, err = openGitHubPR(r, p)
+	case "gitlab":
+		link, err = openGitLabMR(r, p)
+	default:
+		return fmt.Errorf("unknown provider %q", *provider)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(link)
+
+	return nil
+}
+
+// suggestReviewers ranks authors of existing ADRs by the number of tags they share
+// with the proposal
+func suggestReviewers(adrs []*ADR, proposed *ADR, limit int) []string {
+	tags := map[string]bool{}
+	for _, t := range proposed.Meta.Tags {
+		tags[strings.ToLower(t)] = true
+	}
+	self := map[string]bool{}
+	for _, a := range proposed.Meta.Authors {
+		self[a] = true
+	}
+
+	scores := map[string]int{}
+	for _, a := range adrs {
+		shared := 0
+		for _, t := range a.Meta.Tags {
+			if tags[strings.ToLower(t)] {
+				shared++
+			}
+		}
+		if shared == 0 {
+			continue
+		}
+		for _, author := range a.Meta.Authors {
+			if !self[author] && strings.HasPrefix(author, "@") {
+				scores[author] += shared
+			}
+		}
+	}
+
+	candidates := []string{}
+	for c := range scores {
+		candidates = append(candidates, c)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if scores[candidates[i]] == scores[candidates[j]] {
+			return candidates[i] < candidates[j]
+		}
+		return scores[candidates[i]] > scores[candidates[j]]
+	})
+
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+
+	return candidates
+}
+
+func proposalBody(p proposal) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Proposes **ADR-%d: %s** for review.\n\n", p.ADR.Meta.Index, p.ADR.Heading)
+	fmt.Fprintf(&b, "| Field | Value |\n|---|---|\n")
+	fmt.Fprintf(&b, "| File | `%s` |\n", p.ADR.Meta.Path)
+	fmt.Fprintf(&b, "| Authors | %s |\n", strings.Join(p.ADR.Meta.Authors, ", "))
+	fmt.Fprintf(&b, "| Tags | %s |\n", strings.Join(p.ADR.Meta.Tags, ", "))
+	if len(p.Reviewers) > 0 {
+		fmt.Fprintf(&b, "| Suggested reviewers | %s |\n", strings.Join(p.Reviewers, ", "))
+	}
+	fmt.Fprintf(&b, "\nFill in the context, decision and consequences sections, once accepted flip the Status to `Approved` before merging.\n")
+
+	return b.String()
+}
+
+func apiRequest(method string, endpoint string, headers map[string]string, payload interface{}, result interface{}) error {
+	var body []byte
+	if payload != nil {
+		var err error
+		body, err = json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := newHTTPClient(30 * time.Second).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s failed: %s: %s", method, endpoint, resp.Status, strings.TrimSpace(string(respBody)))
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	return json.Unmarshal(respBody, result)
+}
+
+// githubAPI returns the API root and request headers for the host of r, GitHub
+// Enterprise hosts serve the API below /api/v3
+func githubAPI(r gitRemote) (string, map[string]string, error) {
+	token, err := resolveCredential("github", "GITHUB_TOKEN")
+	if err != nil {
+		return "", nil, err
+	}
+	if token == "" {
+		return "", nil, fmt.Errorf("GITHUB_TOKEN or a github credential is required to access the GitHub API")
+	}
+
+	api := "https://api.github.com"
+	if r.Host != "github.com" {
+		api = "https://" + r.Host + "/api/v3"
+	}
+	headers := map[string]string{
+		"Authorization": "Bearer " + token,
+		"Accept":        "application/vnd.github+json",
+	}
+
+	return api, headers, nil
+}
+
+func openGitHubPR(r gitRemote, p proposal) (string, error) {
+	api, headers, err := githubAPI(r)
+	if err != nil {
+		return "", err
+	}
+
+	var pr struct {
+		Number  int    `json:"number"`
+		HTMLURL string `json:"html_url"`
+	}
+	err = apiRequest("POST", fmt.Sprintf("%s/repos/%s/pulls", api, r.Path()), headers, map[string]string{
+		"title": p.Title,
+		"head":  p.Branch,
+		"base":  p.Base,
+		"body":  p.Body,
+	}, &pr)
+	if err != nil {
+		return "", err
+	}
+
+	if len(p.Reviewers) > 0 {
+		logins := []string{}
+		for _, rv := range p.Reviewers {
+			logins = append(logins, strings.TrimPrefix(rv, "@"))
+		}
+		err = apiRequest("POST", fmt.Sprintf("%s/repos/%s/pulls/%d/requested_reviewers", api, r.Path(), pr.Number), headers, map[string][]string{"reviewers": logins}, nil)
+		if err != nil {
+			log.Printf("Could not request reviewers: %s", err)
+		}
+	}
+
+	return pr.HTMLURL, nil
+}
+
+func openGitLabMR(r gitRemote, p proposal) (string, error) {
+	token, err := resolveCredential("gitlab", "GITLAB_TOKEN")
+	if err != nil {
+		return "", err
+	}
+	if token == "" {
+		return "", fmt.Errorf("GITLAB_TOKEN or a gitlab credential is required to open a merge request")
+	}
+
+	api := "https://" + r.Host + "/api/v4"
+	headers := map[string]string{"PRIVATE-TOKEN": token}
+
+	reviewerIDs := []int{}
+	for _, rv := range p.Reviewers {
+		var users []struct {
+			ID int `json:"id"`
+		}
+		err := apiRequest("GET", fmt.Sprintf("%s/users?username=%s", api, url.QueryEscape(strings.TrimPrefix(rv, "@"))), headers, nil, &users)
+		if err != nil || len(users) == 0 {
+			log.Printf("Could not resolve reviewer %s", rv)
+			continue
+		}
+		reviewerIDs = append(reviewerIDs, users[0].ID)
+	}
+
+	var mr struct {
+		WebURL string `json:"web_url"`
+	}
+	err = apiRequest("POST", fmt.Sprintf("%s/projects/%s/merge_requests", api, url.PathEscape(r.Path())), headers, map[string]interface{}{
+		"title":         p.Title,
+		"source_branch": p.Branch,
+		"target_branch": p.Base,
+		"description":   p.Body,
+		"reviewer_ids":  reviewerIDs,
+	}, &mr)
+	if err != nil {
+		return "", err
+	}
+
+	return mr.WebURL, nil
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+const (
+	summaryStartMarker = "<!-- adr-summary -->"
+	summaryEndMarker   = "<!-- /adr-summary -->"
+)
+
+type adrChange struct {
+	Kind string
+	Path string
+	Old  *ADR
+	New  *ADR
+	Err  error
+}
+
+func runPRSummary(args []string) error {
+	fs := flag.NewFlagSet("pr-summary", flag.ExitOnError)
+	dir := fs.String("dir", settings.Dir, "directory containing ADR files")
+	base := fs.String("base", "origin/main", "git ref the branch is compared against")
+	update := fs.Bool("update-pr", false, "replace the summary section in the body of the GitHub pull request for the current branch")
+	remote := fs.String("remote", "origin", "git remote hosting the pull request")
+	fs.Parse(args)
+
+	changes, err := branchChanges(*base, *dir)
+	if err != nil {
+		return err
+	}
+
+	summary := renderPRSummary(changes)
+
+	if !*update || !networkAllowed("updating the pull request body") {
+		fmt.Print(summary)
+		return nil
+	}
+
+	return updateGitHubPRBody(*remote, summary)
+}
+
+// branchChanges compares the ADR files at the merge base of base and HEAD with the
+// versions in HEAD
+func branchChanges(base string, dir string) ([]adrChange, error) {
+	out, err := git("diff", "--name-status", "--no-renames", "--relative", base+"...HEAD", "--", dir)
+	if err != nil {
+		return nil, err
+	}
+
+	mergeBase, err := git("merge-base", base, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []adrChange{}
+	for _, line := range strings.Split(out, "\n") {
+		parts := strings.Fields(line)
+		if len(parts) != 2 || !isRecordFile(parts[1]) {
+			continue
+		}
+
+		change := adrChange{Path: parts[1]}
+
+		if parts[0] != "A" {
+			change.Old, _ = parseADRAt(mergeBase, change.Path)
+		}
+		if parts[0] != "D" {
+			change.New, change.Err = parseADRAt("HEAD", change.Path)
+		}
+
+		switch {
+		case parts[0] == "A":
+			change.Kind = "added"
+		case parts[0] == "D":
+			change.Kind = "removed"
+		case change.Err != nil:
+			change.Kind = "modified"
+		case change.Old != nil && change.Old.Meta.Status != change.New.Meta.Status:
+			change.Kind = "status"
+			if strings.HasPrefix(change.New.Meta.Status, "Superseded") {
+				change.Kind = "superseded"
+			}
+		default:
+			change.Kind = "modified"
+		}
+
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
+// parseADRAt parses file, relative to the working directory, as it is in ref
+func parseADRAt(ref string, file string) (*ADR, error) {
+	body, err := git("show", ref+":./"+file)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseADRContent(file, []byte(body))
+}
+
+func renderPRSummary(changes []adrChange) string {
+	var b strings.Builder
+
+	b.WriteString(summaryStartMarker + "\n")
+	b.WriteString("### Architecture Decision Records\n\n")
+
+	if len(changes) == 0 {
+		b.WriteString("No ADRs were changed in this branch.\n")
+		b.WriteString(summaryEndMarker + "\n")
+		return b.String()
+	}
+
+	sections := []struct {
+		kind  string
+		title string
+	}{
+		{"added", "New decisions"},
+		{"status", "Status changes"},
+		{"superseded", "Superseded"},
+		{"modified", "Updated"},
+		{"removed", "Removed"},
+	}
+
+	for _, s := range sections {
+		lines := []string{}
+		for _, c := range changes {
+			if c.Kind == s.kind {
+				lines = append(lines, "* "+describeChange(c))
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&b, "#### %s\n\n%s\n\n", s.title, strings.Join(lines, "\n"))
+	}
+
+	invalid := []string{}
+	for _, c := range changes {
+		if c.Err != nil {
+			invalid = append(invalid, fmt.Sprintf("* `%s`: %s", c.Path, c.Err))
+		}
+	}
+	if len(invalid) > 0 {
+		fmt.Fprintf(&b, "#### :warning: Does not validate\n\n%s\n\n", strings.Join(invalid, "\n"))
+	}
+
+	b.WriteString(summaryEndMarker + "\n")
+
+	return b.String()
+}
+
+func describeChange(c adrChange) string {
+	adr := c.New
+	if adr == nil {
+		adr = c.Old
+	}
+	if adr == nil {
+		return fmt.Sprintf("`%s`", c.Path)
+	}
+
+	desc := fmt.Sprintf("**ADR-%d** %s (`%s`)", adr.Meta.Index, adr.Heading, c.Path)
+	switch c.Kind {
+	case "added":
+		desc += fmt.Sprintf(" — %s, tags: %s", adr.Meta.Status, strings.Join(adr.Meta.Tags, ", "))
+	case "status", "superseded":
+		desc += fmt.Sprintf(" — %s → %s", c.Old.Meta.Status, c.New.Meta.Status)
+	}
+
+	return desc
+}
+
+// updateGitHubPRBody swaps the marked summary section of the open pull request for
+// the current branch, appending it when the body has none yet
+func updateGitHubPRBody(remote string, summary string) error {
+	r, err := remoteFor(remote)
+	if err != nil {
+		return err
+	}
+
+	api, headers, err := githubAPI(r)
+	if err != nil {
+		return err
+	}
+
+	branch, err := git("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return err
+	}
+
+	var prs []struct {
+		Number int    `json:"number"`
+		Body   string `json:"body"`
+	}
+	err = apiRequest("GET", fmt.Sprintf("%s/repos/%s/pulls?state=open&head=%s:%s", api, r.Path(), r.Owner, branch), headers, nil, &prs)
+	if err != nil {
+		return err
+	}
+	if len(prs) == 0 {
+		return fmt.Errorf("no open pull request found for branch %s", branch)
+	}
+
+	body := prs[0].Body
+	start := strings.Index(body, summaryStartMarker)
+	end := strings.Index(body, summaryEndMarker)
+	if start >= 0 && end > start {
+		body = body[:start] + strings.TrimSuffix(summary, "\n") + body[end+len(summaryEndMarker):]
+	} else {
+		body = strings.TrimRight(body, "\n") + "\n\n" + summary
+	}
+
+	return apiRequest("PATCH", fmt.Sprintf("%s/repos/%s/pulls/%d", api, r.Path(), prs[0].Number), headers, map[string]string{"body": body}, nil)
+}
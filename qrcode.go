@@ -0,0 +1,442 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+// qrVersions describe the QR code versions 1 to 10 at error correction level
+// M, Total is the number of codewords, ECC the error correction codewords of
+// each of Blocks blocks and Align the positions of the alignment patterns
+var qrVersions = []struct {
+	Total  int
+	ECC    int
+	Blocks int
+	Align  []int
+}{
+	{26, 10, 1, nil},
+	{44, 16, 1, []int{6, 18}},
+	{70, 26, 1, []int{6, 22}},
+	{100, 18, 2, []int{6, 26}},
+	{134, 24, 2, []int{6, 30}},
+	{172, 16, 4, []int{6, 34}},
+	{196, 18, 4, []int{6, 22, 38}},
+	{242, 22, 4, []int{6, 24, 42}},
+	{292, 22, 5, []int{6, 26, 46}},
+	{346, 26, 5, []int{6, 28, 50}},
+}
+
+// qrCode is the module matrix of a QR code, true modules are dark
+type qrCode struct {
+	size     int
+	modules  [][]bool
+	function [][]bool
+}
+
+// encodeQR encodes text in byte mode in the smallest version that holds it,
+// permalinks are short enough for the versions up to 10
+func encodeQR(text string) (*qrCode, error) {
+	data := []byte(text)
+	version := 0
+	for v := range qrVersions {
+		countBits := 8
+		if v+1 >= 10 {
+			countBits = 16
+		}
+		capacity := qrVersions[v].Total - qrVersions[v].ECC*qrVersions[v].Blocks
+		if 4+countBits+len(data)*8 <= capacity*8 {
+			version = v + 1
+			break
+		}
+	}
+	if version == 0 {
+		return nil, fmt.Errorf("%d bytes do not fit a QR code of version 10", len(data))
+	}
+	info := qrVersions[version-1]
+
+	// the bit stream: byte mode, the length, the data, a terminator and padding
+	bits := []bool{}
+	put := func(value int, n int) {
+		for i := n - 1; i >= 0; i-- {
+			bits = append(bits, value>>uint(i)&1 == 1)
+		}
+	}
+	put(4, 4)
+	if version >= 10 {
+		put(len(data), 16)
+	} else {
+		put(len(data), 8)
+	}
+	for _, b := range data {
+		put(int(b), 8)
+	}
+	capacity := (info.Total - info.ECC*info.Blocks) * 8
+	put(0, minInt(4, capacity-len(bits)))
+	put(0, (8-len(bits)%8)%8)
+	for pad := 0xEC; len(bits) < capacity; pad ^= 0xEC ^ 0x11 {
+		put(pad, 8)
+	}
+	codewords := make([]byte, capacity/8)
+	for i, bit := range bits {
+		if bit {
+			codewords[i/8] |= 1 << uint(7-i%8)
+		}
+	}
+
+	q := newQRCode(version)
+	q.drawCodewords(qrInterleave(codewords, info.Total, info.ECC, info.Blocks))
+
+	best, penalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		q.applyMask(mask)
+		q.drawFormat(mask)
+		if p := q.penalty(); penalty < 0 || p < penalty {
+			best, penalty = mask, p
+		}
+		q.applyMask(mask)
+	}
+	q.applyMask(best)
+	q.drawFormat(best)
+
+	return q, nil
+}
+
+// qrInterleave splits the data into blocks, appends their error correction
+// and interleaves the codewords of the blocks, the shorter blocks come first
+func qrInterleave(data []byte, total int, ecc int, blocks int) []byte {
+	short := blocks - total%blocks
+	shortLen := total / blocks
+	generator := qrGenerator(ecc)
+
+	all := [][]byte{}
+	for i, at := 0, 0; i < blocks; i++ {
+		n := shortLen - ecc
+		if i >= short {
+			n++
+		}
+		block := append([]byte{}, data[at:at+n]...)
+		at += n
+		all = append(all, append(block, qrRemainder(block, generator)...))
+	}
+
+	out := []byte{}
+	for i := 0; i <= shortLen; i++ {
+		for j, block := range all {
+			// short blocks have no codeword at the last data position
+			k := i
+			if j < short && i >= shortLen-ecc {
+				if i == shortLen-ecc {
+					continue
+				}
+				k = i - 1
+			}
+			if k < len(block) {
+				out = append(out, block[k])
+			}
+		}
+	}
+
+	return out
+}
+
+// qrMultiply multiplies in GF(256) with the QR polynomial 0x11D
+func qrMultiply(x byte, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = z<<1 ^ (z>>7)*0x11D
+		z ^= int(y>>uint(i)&1) * int(x)
+	}
+
+	return byte(z)
+}
+
+// qrGenerator is the Reed-Solomon generator of degree n without its leading
+// coefficient, highest power first
+func qrGenerator(n int) []byte {
+	g := make([]byte, n)
+	g[n-1] = 1
+	root := byte(1)
+	for i := 0; i < n; i++ {
+		for j := range g {
+			g[j] = qrMultiply(g[j], root)
+			if j+1 < n {
+				g[j] ^= g[j+1]
+			}
+		}
+		root = qrMultiply(root, 2)
+	}
+
+	return g
+}
+
+func qrRemainder(data []byte, generator []byte) []byte {
+	r := make([]byte, len(generator))
+	for _, b := range data {
+		factor := b ^ r[0]
+		copy(r, r[1:])
+		r[len(r)-1] = 0
+		for i, c := range generator {
+			r[i] ^= qrMultiply(c, factor)
+		}
+	}
+
+	return r
+}
+
+func newQRCode(version int) *qrCode {
+	size := version*4 + 17
+	q := &qrCode{size: size}
+	for i := 0; i < size; i++ {
+		q.modules = append(q.modules, make([]bool, size))
+		q.function = append(q.function, make([]bool, size))
+	}
+
+	for i := 0; i < size; i++ {
+		q.set(6, i, i%2 == 0)
+		q.set(i, 6, i%2 == 0)
+	}
+	for _, c := range [][2]int{{3, 3}, {size - 4, 3}, {3, size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := c[0]+dx, c[1]+dy
+				if x >= 0 && x < size && y >= 0 && y < size {
+					d := maxInt(absInt(dx), absInt(dy))
+					q.set(x, y, d != 2 && d != 4)
+				}
+			}
+		}
+	}
+	align := qrVersions[version-1].Align
+	for i, ax := range align {
+		for j, ay := range align {
+			if (i == 0 && j == 0) || (i == 0 && j == len(align)-1) || (i == len(align)-1 && j == 0) {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					q.set(ax+dx, ay+dy, maxInt(absInt(dx), absInt(dy)) != 1)
+				}
+			}
+		}
+	}
+
+	// reserve the format areas, drawFormat fills them in
+	q.drawFormat(0)
+	if version >= 7 {
+		rem := version
+		for i := 0; i < 12; i++ {
+			rem = rem<<1 ^ (rem>>11)*0x1F25
+		}
+		bits := version<<12 | rem
+		for i := 0; i < 18; i++ {
+			dark := bits>>uint(i)&1 == 1
+			a, b := size-11+i%3, i/3
+			q.set(a, b, dark)
+			q.set(b, a, dark)
+		}
+	}
+
+	return q
+}
+
+// set draws a function module at column x and row y
+func (q *qrCode) set(x int, y int, dark bool) {
+	q.modules[y][x] = dark
+	q.function[y][x] = true
+}
+
+// drawFormat draws both copies of the format information, level M and mask
+func (q *qrCode) drawFormat(mask int) {
+	data := mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return bits>>uint(i)&1 == 1 }
+
+	for i := 0; i <= 5; i++ {
+		q.set(8, i, bit(i))
+	}
+	q.set(8, 7, bit(6))
+	q.set(8, 8, bit(7))
+	q.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		q.set(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		q.set(q.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		q.set(8, q.size-15+i, bit(i))
+	}
+	q.set(8, q.size-8, true)
+}
+
+// drawCodewords fills the data modules in the zigzag of two columns from the
+// bottom right, remainder bits stay light
+func (q *qrCode) drawCodewords(data []byte) {
+	i := 0
+	for right := q.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < q.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = q.size - 1 - vert
+				}
+				if !q.function[y][x] && i < len(data)*8 {
+					q.modules[y][x] = data[i>>3]>>uint(7-i&7)&1 == 1
+					i++
+				}
+			}
+		}
+	}
+}
+
+// applyMask flips the data modules of the mask pattern, applying it twice
+// undoes it
+func (q *qrCode) applyMask(mask int) {
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			var flip bool
+			switch mask {
+			case 0:
+				flip = (x+y)%2 == 0
+			case 1:
+				flip = y%2 == 0
+			case 2:
+				flip = x%3 == 0
+			case 3:
+				flip = (x+y)%3 == 0
+			case 4:
+				flip = (x/3+y/2)%2 == 0
+			case 5:
+				flip = x*y%2+x*y%3 == 0
+			case 6:
+				flip = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				flip = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if flip && !q.function[y][x] {
+				q.modules[y][x] = !q.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores how hard the code is to read, runs, blocks, finder like
+// patterns and an uneven share of dark modules count against it
+func (q *qrCode) penalty() int {
+	p := 0
+	dark := 0
+	finder := []bool{true, false, true, true, true, false, true}
+	line := func(at func(i int) bool) {
+		run := 1
+		for i := 0; i < q.size; i++ {
+			if i > 0 && at(i) == at(i-1) {
+				run++
+			} else {
+				run = 1
+			}
+			if run == 5 {
+				p += 3
+			} else if run > 5 {
+				p++
+			}
+			if i+7 > q.size {
+				continue
+			}
+			match := true
+			for k, f := range finder {
+				if at(i+k) != f {
+					match = false
+					break
+				}
+			}
+			if !match {
+				continue
+			}
+			light := func(from int, to int) bool {
+				for k := from; k < to; k++ {
+					if k >= 0 && k < q.size && at(k) {
+						return false
+					}
+				}
+				return true
+			}
+			if light(i-4, i) || light(i+7, i+11) {
+				p += 40
+			}
+		}
+	}
+
+	for y := 0; y < q.size; y++ {
+		line(func(i int) bool { return q.modules[y][i] })
+		line(func(i int) bool { return q.modules[i][y] })
+		for x := 0; x < q.size; x++ {
+			if q.modules[y][x] {
+				dark++
+			}
+			if x+1 < q.size && y+1 < q.size {
+				c := q.modules[y][x]
+				if c == q.modules[y][x+1] && c == q.modules[y+1][x] && c == q.modules[y+1][x+1] {
+					p += 3
+				}
+			}
+		}
+	}
+
+	total := q.size * q.size
+	p += (absInt(dark*20-total*10)+total-1)/total*10 - 10
+
+	return p
+}
+
+// svg draws the code with a quiet zone of four modules, scaled by the viewer
+func (q *qrCode) svg() string {
+	b := &strings.Builder{}
+	n := q.size + 8
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges"><rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, n, n, n, n)
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			if q.modules[y][x] {
+				fmt.Fprintf(b, "M%d %dh1v1h-1z", x+4, y+4)
+			}
+		}
+	}
+	b.WriteString(`"/></svg>`)
+
+	return b.String()
+}
+
+// qrSVG is the QR code of text as inline SVG, empty when it does not fit
+func qrSVG(text string) template.HTML {
+	q, err := encodeQR(text)
+	if err != nil {
+		return ""
+	}
+
+	return template.HTML(q.svg())
+}
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
+
+func absInt(a int) int {
+	if a < 0 {
+		return -a
+	}
+
+	return a
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	quarantineDir      = "_quarantine"
+	quarantineManifest = "quarantine.yaml"
+)
+
+// quarantineEntry records why a file was moved out of the catalog, the manifest
+// is kept next to the files so the history travels with the repository
+type quarantineEntry struct {
+	File   string    `yaml:"file"`
+	Reason string    `yaml:"reason"`
+	Since  time.Time `yaml:"since"`
+}
+
+func runQuarantine(args []string) error {
+	fs := flag.NewFlagSet("quarantine", flag.ExitOnError)
+	dir := fs.String("dir", settings.Dir, "directory containing ADR files")
+	grace := fs.Duration("grace", 7*24*time.Hour, "how long a file must have been unchanged while invalid before it is quarantined")
+	report := fs.Bool("report", false, "list quarantined files and whether they are fixed instead of quarantining")
+	release := fs.Bool("release", false, "move fixed files back into the catalog")
+	dryRun := fs.Bool("dry-run", false, "print what would be moved without moving anything")
+	fs.Parse(args)
+
+	qdir := path.Join(*dir, quarantineDir)
+	entries, err := readQuarantine(qdir)
+	if err != nil {
+		return err
+	}
+
+	if *report || *release {
+		return quarantineReport(*dir, qdir, entries, *release, *dryRun)
+	}
+
+	_, errs, err := scanADRs(*dir)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	moved := 0
+	for _, r := range invalidRecords(errs) {
+		if r.Path == "" {
+			log.Printf("Cannot quarantine, no file known for: %s", r.Error)
+			continue
+		}
+
+		changed := lastChanged(r.Path)
+		if now.Sub(changed) < *grace {
+			log.Printf("Not yet quarantining %s, last changed %s: %s", r.Path, changed.Format("2006-01-02"), r.Error)
+			continue
+		}
+
+		target := path.Join(qdir, path.Base(r.Path))
+		fmt.Printf("quarantine %s -> %s: %s\n", r.Path, target, r.Error)
+		if *dryRun {
+			continue
+		}
+
+		err = moveFile(r.Path, target)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, quarantineEntry{File: path.Base(r.Path), Reason: r.Error, Since: now.Truncate(time.Second)})
+		moved++
+	}
+
+	if moved == 0 || *dryRun {
+		return nil
+	}
+
+	return writeQuarantine(qdir, entries)
+}
+
+// quarantineReport re-parses every quarantined file, fixed files are moved back
+// when release is set
+func quarantineReport(dir string, qdir string, entries []quarantineEntry, release bool, dryRun bool) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintf(w, "Quarantined records in %s: %d\n", qdir, len(entries))
+	remaining := []quarantineEntry{}
+	for _, e := range entries {
+		file := path.Join(qdir, e.File)
+		state := "INVALID"
+		if _, err := parseADR(file); err == nil {
+			state = "FIXED"
+		}
+		fmt.Fprintf(w, "  %s\t%s\tsince %s\t%s\n", state, e.File, e.Since.Format("2006-01-02"), e.Reason)
+
+		if state == "FIXED" && release {
+			if !dryRun {
+				err := moveFile(file, path.Join(dir, e.File))
+				if err != nil {
+					w.Flush()
+					return err
+				}
+			}
+			continue
+		}
+		remaining = append(remaining, e)
+	}
+	w.Flush()
+
+	if !release || dryRun || len(remaining) == len(entries) {
+		return nil
+	}
+
+	return writeQuarantine(qdir, remaining)
+}
+
+// lastChanged returns the last commit time of file, or its modification time
+// when it is not committed
+func lastChanged(file string) time.Time {
+	out, err := git("log", "-1", "--format=%at", "--", file)
+	if err == nil && out != "" {
+		if sec, err := strconv.ParseInt(out, 10, 64); err == nil {
+			return time.Unix(sec, 0)
+		}
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return info.ModTime()
+}
+
+// moveFile uses git mv for tracked files so history follows the file
+func moveFile(from string, to string) error {
+	err := os.MkdirAll(path.Dir(to), 0755)
+	if err != nil {
+		return err
+	}
+
+	if _, err := git("ls-files", "--error-unmatch", "--", from); err == nil {
+		_, err = git("mv", "--", from, to)
+		return err
+	}
+
+	return os.Rename(from, to)
+}
+
+func readQuarantine(qdir string) ([]quarantineEntry, error) {
+	body, err := ioutil.ReadFile(path.Join(qdir, quarantineManifest))
+	if os.IsNotExist(err) {
+		return []quarantineEntry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []quarantineEntry{}
+	err = yaml.Unmarshal(body, &entries)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quarantine manifest %s: %s", path.Join(qdir, quarantineManifest), err)
+	}
+
+	return entries, nil
+}
+
+func writeQuarantine(qdir string, entries []quarantineEntry) error {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].File < entries[j].File
+	})
+
+	body, err := yaml.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(path.Join(qdir, quarantineManifest), func(w io.Writer) error {
+		_, err := w.Write(body)
+		return err
+	})
+}
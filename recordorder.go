@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// recordOrders are the keys the index can be sorted by
+var recordOrders = []string{"index", "date", "title", "status"}
+
+// recordOrder sorts the records within each tag and section of the index, the
+// zero value keeps the numeric order
+type recordOrder struct {
+	Key        string
+	Descending bool
+}
+
+func parseRecordOrder(key string, order string) (recordOrder, error) {
+	o := recordOrder{Key: strings.ToLower(key)}
+	if !containsFold(recordOrders, o.Key) {
+		return o, fmt.Errorf("invalid -sort %q, expected one of: %s", key, strings.Join(recordOrders, ", "))
+	}
+	switch strings.ToLower(order) {
+	case "asc":
+	case "desc":
+		o.Descending = true
+	default:
+		return o, fmt.Errorf("invalid -order %q, expected asc or desc", order)
+	}
+
+	return o, nil
+}
+
+// sort orders adrs by the key and then the index, statuses sort in the order
+// their record type lists them
+func (o recordOrder) sort(adrs []*ADR) {
+	if o.Key == "" || (o.Key == "index" && !o.Descending) {
+		return
+	}
+
+	statusRank := func(a *ADR) int {
+		statuses := typeByName(a.Meta.Type).statuses()
+		for i, s := range statuses {
+			if strings.EqualFold(s, a.Meta.Status) {
+				return i
+			}
+		}
+		return len(statuses)
+	}
+	compare := func(a, b *ADR) int {
+		switch o.Key {
+		case "date":
+			switch {
+			case a.Meta.Date.Before(b.Meta.Date):
+				return -1
+			case a.Meta.Date.After(b.Meta.Date):
+				return 1
+			}
+		case "title":
+			if c := strings.Compare(strings.ToLower(a.Heading), strings.ToLower(b.Heading)); c != 0 {
+				return c
+			}
+		case "status":
+			if c := statusRank(a) - statusRank(b); c != 0 {
+				return c
+			}
+		}
+		return a.Meta.Index - b.Meta.Index
+	}
+
+	sort.SliceStable(adrs, func(i, j int) bool {
+		if o.Descending {
+			return compare(adrs[i], adrs[j]) > 0
+		}
+		return compare(adrs[i], adrs[j]) < 0
+	})
+}
+
+// apply sorts the records of every tag and section
+func (o recordOrder) apply(tags []TagADRs, sections []TypeSection) {
+	for _, t := range tags {
+		o.sort(t.Adrs)
+	}
+	for _, s := range sections {
+		o.sort(s.Records)
+	}
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+
+	"adr-index/analysis/adrref"
+)
+
+// codeRef is a mention of a record such as ADR-0042 in a source file
+type codeRef struct {
+	File string
+	Line int
+	Ref  string
+	ADR  *ADR
+	Err  error
+}
+
+var skippedSourceDirs = map[string]bool{".git": true, "node_modules": true, "vendor": true}
+
+func runRefs(args []string) error {
+	if len(args) == 0 || args[0] != "scan" {
+		return fmt.Errorf("usage: refs scan [flags] [paths]")
+	}
+
+	fs := flag.NewFlagSet("refs scan", flag.ExitOnError)
+	dir := fs.String("dir", settings.Dir, "directory containing ADR files")
+	src := fs.String("src", "", "comma separated source trees to scan, a trailing /... is accepted, defaults to the current directory")
+	fs.Parse(args[1:])
+
+	adrs, err := loadADRs(*dir)
+	if err != nil {
+		return err
+	}
+
+	roots := parseCommaList(*src)
+	roots = append(roots, fs.Args()...)
+	if len(roots) == 0 {
+		roots = []string{"."}
+	}
+
+	refs, err := scanCodeRefs(roots, *dir, adrs)
+	if err != nil {
+		return err
+	}
+
+	if structuredOutput() {
+		setResults(refResults(refs))
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	defer w.Flush()
+
+	byADR := map[*ADR][]codeRef{}
+	broken := 0
+	for _, r := range refs {
+		if r.Err != nil {
+			broken++
+			continue
+		}
+		byADR[r.ADR] = append(byADR[r.ADR], r)
+	}
+
+	fmt.Fprintf(w, "Referenced decisions (%d)\n", len(byADR))
+	unreferenced := []*ADR{}
+	for _, a := range searchADRs(adrs, "") {
+		locations := byADR[a]
+		if len(locations) == 0 {
+			if !isSuperseded(a) {
+				unreferenced = append(unreferenced, a)
+			}
+			continue
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%d references\n", recordLabel(a), a.Heading, len(locations))
+		for _, r := range locations {
+			fmt.Fprintf(w, "    %s:%d\n", r.File, r.Line)
+		}
+	}
+
+	fmt.Fprintf(w, "\nInvalid references (%d)\n", broken)
+	for _, r := range refs {
+		if r.Err != nil {
+			fmt.Fprintf(w, "  %s:%d\t%s\t%s\n", r.File, r.Line, r.Ref, r.Err)
+		}
+	}
+
+	fmt.Fprintf(w, "\nDecisions never referenced from code (%d)\n", len(unreferenced))
+	for _, a := range unreferenced {
+		fmt.Fprintf(w, "  %s\t%s\t%s\n", recordLabel(a), a.Heading, a.Meta.Status)
+	}
+
+	if broken > 0 {
+		w.Flush()
+		return fmt.Errorf("%d references point at missing or superseded decisions", broken)
+	}
+
+	return nil
+}
+
+// refResult is the structured form of a code reference
+type refResult struct {
+	File   string         `json:"file"`
+	Line   int            `json:"line"`
+	Ref    string         `json:"ref"`
+	Record *adrref.Record `json:"record,omitempty"`
+	Error  string         `json:"error,omitempty"`
+}
+
+func refResults(refs []codeRef) []refResult {
+	results := []refResult{}
+	for _, r := range refs {
+		res := refResult{File: r.File, Line: r.Line, Ref: r.Ref}
+		if r.ADR != nil {
+			record := catalogRecord(r.ADR)
+			res.Record = &record
+		}
+		if r.Err != nil {
+			res.Error = r.Err.Error()
+		}
+		results = append(results, res)
+	}
+
+	return results
+}
+
+// isSuperseded covers statuses such as "Superseded" and "Superseded by ADR-12"
+func isSuperseded(a *ADR) bool {
+	return strings.HasPrefix(a.Meta.Status, "Superseded")
+}
+
+// recordRefPattern matches references using the label of any record type,
+// ADR-0042 or Note-7, the number may be zero padded
+func recordRefPattern() *regexp.Regexp {
+	labels := []string{}
+	for _, t := range cfg.types {
+		labels = append(labels, regexp.QuoteMeta(t.Label))
+	}
+
+	return regexp.MustCompile(`\b(` + strings.Join(labels, "|") + `)-(\d+)\b`)
+}
+
+// checkCodeRef resolves a reference, it fails for missing and superseded records
+func checkCodeRef(adrs []*ADR, ref string) (*ADR, error) {
+	a, err := resolveRecord(adrs, ref)
+	if err != nil {
+		return nil, err
+	}
+	if isSuperseded(a) {
+		return a, fmt.Errorf("%s is %s", recordLabel(a), a.Meta.Status)
+	}
+
+	return a, nil
+}
+
+// generatedFiles are the absolute paths of the files adr-index writes for the
+// config: the indexes of the profiles and tags and the feed. They list records
+// rather than depend on them
+func generatedFiles(adrs []*ADR) map[string]bool {
+	files := []string{settings.Output, cfg.Output, feedFile}
+	if settings.Output == "" && cfg.Output == "" {
+		// the index of init and build -verify
+		files = append(files, "README.adoc")
+	}
+	for _, p := range cfg.Profiles {
+		files = append(files, p.Output)
+	}
+	if cfg.TagIndex.Output != "" {
+		if indexes, err := tagIndexes(adrs, cfg.TagIndex.Output); err == nil {
+			for _, ti := range indexes {
+				files = append(files, ti.Path)
+			}
+		}
+	}
+
+	generated := map[string]bool{}
+	for _, f := range files {
+		if f == "" || f == "-" {
+			continue
+		}
+		if abs, err := filepath.Abs(f); err == nil {
+			generated[abs] = true
+		}
+	}
+
+	return generated
+}
+
+// addManifest adds the manifest of adr-index in dir and the artifacts it lists
+// to generated, exports and sites are published with one
+func addManifest(generated map[string]bool, dir string) {
+	file := filepath.Join(dir, manifestFile)
+	body, err := ioutil.ReadFile(file)
+	if err != nil {
+		return
+	}
+	var m publishManifest
+	if json.Unmarshal(body, &m) != nil || !strings.HasPrefix(m.Generator, "adr-index") {
+		return
+	}
+
+	paths := []string{manifestFile}
+	for _, a := range m.Artifacts {
+		paths = append(paths, a.Path)
+	}
+	for _, f := range paths {
+		if abs, err := filepath.Abs(filepath.Join(dir, filepath.FromSlash(f))); err == nil {
+			generated[abs] = true
+		}
+	}
+}
+
+func scanCodeRefs(roots []string, adrDir string, adrs []*ADR) ([]codeRef, error) {
+	pattern := recordRefPattern()
+	skip, _ := filepath.Abs(adrDir)
+	generated := generatedFiles(adrs)
+	refs := []codeRef{}
+
+	for _, root := range roots {
+		root = strings.TrimSuffix(strings.TrimSuffix(root, "..."), "/")
+		if root == "" {
+			root = "."
+		}
+
+		err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				abs, _ := filepath.Abs(p)
+				if abs == skip || (p != root && skippedSourceDirs[info.Name()]) {
+					return filepath.SkipDir
+				}
+				addManifest(generated, p)
+				return nil
+			}
+			if abs, err := filepath.Abs(p); err == nil && generated[abs] {
+				return nil
+			}
+
+			body, err := ioutil.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			head := body
+			if len(head) > 8000 {
+				head = head[:8000]
+			}
+			if bytes.IndexByte(head, 0) >= 0 {
+				return nil
+			}
+
+			scanner := bufio.NewScanner(bytes.NewReader(body))
+			scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+			for line := 1; scanner.Scan(); line++ {
+				for _, ref := range pattern.FindAllString(scanner.Text(), -1) {
+					a, err := checkCodeRef(adrs, ref)
+					refs = append(refs, codeRef{File: p, Line: line, Ref: ref, ADR: a, Err: err})
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return refs, nil
+}
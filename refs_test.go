@@ -0,0 +1,43 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestScanCodeRefsSkipsGeneratedFiles(t *testing.T) {
+	_, cleanup := gitRepo(t, "")
+	defer cleanup()
+
+	files := map[string]string{
+		"adr/0001-use-kafka.adoc": recordBody("|Date |01-02-2024", "|Author |@alice", "|Status |Approved", "|Tags |messaging"),
+		"README.adoc":             "|link:adr/0001-use-kafka.adoc[ADR-1]\n",
+		feedFile:                  "<title>ADR-1 Use Kafka</title>\n",
+		"public/manifest.json":    `{"generator": "adr-index dev", "artifacts": [{"path": "catalog.json"}]}`,
+		"public/catalog.json":     `[{"label": "ADR-1"}]`,
+		"src/producer.go":         "package src\n\n// see ADR-1\n",
+	}
+	for file, body := range files {
+		err := os.MkdirAll(filepath.Dir(file), 0755)
+		if err == nil {
+			err = ioutil.WriteFile(file, []byte(body), 0644)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	adrs, err := loadADRs("adr")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	refs, err := scanCodeRefs([]string{"."}, "adr", adrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(refs) != 1 || refs[0].File != "src/producer.go" || refs[0].Line != 3 {
+		t.Errorf("refs = %+v, want ADR-1 on line 3 of src/producer.go only", refs)
+	}
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// numbering is the records sharing a filename convention and so an index
+// sequence, e.g. ADRs and the types named like them
+type numbering struct {
+	Labels     []string         `json:"labels"`
+	Gaps       []int            `json:"gaps"`
+	Collisions map[int][]string `json:"collisions"`
+
+	records []*ADR
+}
+
+// renumbering is the new index of a record, its file is renamed to Rename,
+// Shared tells that an earlier record had the same index
+type renumbering struct {
+	ADR    *ADR
+	Index  int
+	Rename string
+	Shared bool
+}
+
+// numberings groups adrs by filename convention and finds the indexes missing
+// from each sequence and those used twice, a sequence starts at 1 unless a
+// record has index 0
+func numberings(adrs []*ADR) []*numbering {
+	byFilename := map[string]*numbering{}
+	groups := []*numbering{}
+	for _, a := range adrs {
+		if a.Meta.Source != nil {
+			continue
+		}
+		t := typeByName(a.Meta.Type)
+		n, ok := byFilename[t.Filename]
+		if !ok {
+			n = &numbering{Gaps: []int{}, Collisions: map[int][]string{}}
+			byFilename[t.Filename] = n
+			groups = append(groups, n)
+		}
+		if !containsFold(n.Labels, t.Label) {
+			n.Labels = append(n.Labels, t.Label)
+		}
+		n.records = append(n.records, a)
+	}
+
+	for _, n := range groups {
+		sort.SliceStable(n.records, func(i, j int) bool {
+			a, b := n.records[i], n.records[j]
+			if a.Meta.Index != b.Meta.Index {
+				return a.Meta.Index < b.Meta.Index
+			}
+			if !a.Meta.Date.Equal(b.Meta.Date) {
+				return a.Meta.Date.Before(b.Meta.Date)
+			}
+			return a.Meta.Path < b.Meta.Path
+		})
+
+		used := map[int][]string{}
+		for _, a := range n.records {
+			used[a.Meta.Index] = append(used[a.Meta.Index], a.Meta.Path)
+		}
+		first := 1
+		if n.records[0].Meta.Index == 0 {
+			first = 0
+		}
+		for i := first; i <= n.records[len(n.records)-1].Meta.Index; i++ {
+			switch len(used[i]) {
+			case 0:
+				n.Gaps = append(n.Gaps, i)
+			case 1:
+			default:
+				n.Collisions[i] = used[i]
+			}
+		}
+	}
+
+	return groups
+}
+
+// plan gives every record of n a free index, the later of two records sharing
+// an index moves, to the end of the sequence with keepGaps and otherwise every
+// record is numbered on from the first index without gaps
+func (n *numbering) plan(keepGaps bool) []renumbering {
+	moves := []renumbering{}
+	next := n.records[0].Meta.Index
+	last := n.records[len(n.records)-1].Meta.Index
+	for i, a := range n.records {
+		shared := i > 0 && n.records[i-1].Meta.Index == a.Meta.Index
+		idx := a.Meta.Index
+		switch {
+		case keepGaps && shared:
+			last++
+			idx = last
+		case !keepGaps:
+			idx = next
+			next++
+		}
+		if idx != a.Meta.Index {
+			moves = append(moves, renumbering{ADR: a, Index: idx, Rename: renumberedPath(a, idx), Shared: shared})
+		}
+	}
+
+	return moves
+}
+
+// renumberedPath is the file name of a with its index replaced by idx, the
+// rest of the name and the width of the number are kept
+func renumberedPath(a *ADR, idx int) string {
+	t := typeByName(a.Meta.Type)
+	base := path.Base(a.Meta.Path)
+	start, end := 0, strings.Index(base, "-")
+	if t.pattern != nil && t.Key != adrTypeKey {
+		m := t.pattern.FindStringSubmatchIndex(base)
+		start, end = m[2], m[3]
+	}
+
+	return path.Join(path.Dir(a.Meta.Path), base[:start]+fmt.Sprintf("%0*d", end-start, idx)+base[end:])
+}
+
+// renumberReferences rewrites the labels and file names of the moved records
+// in content, a label two records shared keeps meaning the first of them
+func renumberReferences(content string, moves []renumbering) string {
+	labels := map[string]string{}
+	stems := []string{}
+	for _, m := range moves {
+		if !m.Shared {
+			t := typeByName(m.ADR.Meta.Type)
+			labels[fmt.Sprintf("%s-%d", t.Label, m.ADR.Meta.Index)] = fmt.Sprintf("%s-%d", t.Label, m.Index)
+		}
+		oldStem := strings.TrimSuffix(path.Base(m.ADR.Meta.Path), path.Ext(m.ADR.Meta.Path))
+		newStem := strings.TrimSuffix(path.Base(m.Rename), path.Ext(m.Rename))
+		stems = append(stems, oldStem, newStem)
+	}
+	b := &strings.Builder{}
+	at := 0
+	for _, loc := range recordRefPattern().FindAllStringIndex(content, -1) {
+		ref := content[loc[0]:loc[1]]
+		// platform:ADR-3 is a record of an included catalog
+		if loc[0] > 0 && content[loc[0]-1] == ':' {
+			continue
+		}
+		if to, ok := labels[ref]; ok {
+			b.WriteString(content[at:loc[0]])
+			b.WriteString(to)
+			at = loc[1]
+		}
+	}
+	b.WriteString(content[at:])
+
+	return strings.NewReplacer(stems...).Replace(b.String())
+}
+
+// runRenumber reports the gaps and collisions of the index sequences and with
+// -fix renames the records to close them, rewriting the references between
+// records
+func runRenumber(args []string) error {
+	fs := flag.NewFlagSet("renumber", flag.ExitOnError)
+	dir := fs.String("dir", settings.Dir, "directory containing ADR files")
+	fix := fs.Bool("fix", false, "rename records to close the gaps and resolve the collisions")
+	keepGaps := fs.Bool("keep-gaps", false, "only resolve collisions, the later record of a shared index moves to the end of the sequence")
+	dryRun := fs.Bool("dry-run", false, "print the renames and changed references without writing them")
+	yes := fs.Bool("yes", false, "apply the renumbering without asking")
+	fs.Parse(args)
+	if *fix && !*dryRun {
+		if err := requireYes(*yes); err != nil {
+			return err
+		}
+	}
+
+	adrs, errs, err := scanADRs(*dir)
+	if err != nil {
+		return err
+	}
+	for _, err := range errs {
+		var duplicate *ErrDuplicateIndex
+		if !errors.As(err, &duplicate) {
+			return fmt.Errorf("renumbering needs a catalog that loads apart from its indexes: %s", err)
+		}
+	}
+	if len(adrs) == 0 {
+		return fmt.Errorf("no records in %s", *dir)
+	}
+
+	groups := numberings(adrs)
+	setResults(groups)
+
+	problems := 0
+	for _, n := range groups {
+		name := strings.Join(n.Labels, ", ")
+		if len(n.Gaps) > 0 {
+			problems++
+			fmt.Printf("%s: %d missing from the sequence: %s\n", name, len(n.Gaps), joinInts(n.Gaps))
+		}
+		indexes := []int{}
+		for idx := range n.Collisions {
+			indexes = append(indexes, idx)
+		}
+		sort.Ints(indexes)
+		for _, idx := range indexes {
+			problems++
+			fmt.Printf("%s: %d is used by %s\n", name, idx, strings.Join(n.Collisions[idx], ", "))
+		}
+	}
+	if problems == 0 {
+		fmt.Printf("The indexes in %s are contiguous\n", *dir)
+		return nil
+	}
+	if !*fix && !*dryRun {
+		return fmt.Errorf("the indexes in %s have gaps or collisions, renumber -fix closes them", *dir)
+	}
+
+	moves := []renumbering{}
+	for _, n := range groups {
+		if *keepGaps && len(n.Collisions) == 0 {
+			continue
+		}
+		moves = append(moves, n.plan(*keepGaps)...)
+	}
+	if len(moves) == 0 {
+		fmt.Println("Nothing to rename")
+		return nil
+	}
+
+	renamed := map[string]string{}
+	for _, m := range moves {
+		if _, err := os.Stat(m.Rename); err == nil {
+			return fmt.Errorf("renumbering %s would overwrite %s", m.ADR.Meta.Path, m.Rename)
+		}
+		renamed[m.ADR.Meta.Path] = m.Rename
+	}
+
+	fixes := []migrationFix{}
+	for _, a := range adrs {
+		body, err := ioutil.ReadFile(a.Meta.Path)
+		if err != nil {
+			return err
+		}
+		f := migrationFix{Kind: "renumber", File: a.Meta.Path, Rename: renamed[a.Meta.Path]}
+		descriptions := []string{}
+		if f.Rename != "" {
+			descriptions = append(descriptions, "rename to "+path.Base(f.Rename))
+		}
+		if renumberReferences(string(body), moves) != string(body) {
+			f.Apply = func(content string) string { return renumberReferences(content, moves) }
+			descriptions = append(descriptions, "update references")
+		}
+		if len(descriptions) == 0 {
+			continue
+		}
+		f.Description = strings.Join(descriptions, ", ")
+		fixes = append(fixes, f)
+	}
+
+	for _, f := range fixes {
+		fmt.Printf("%s: %s\n", f.File, f.Description)
+		if f.Apply != nil {
+			printFixDiff(os.Stdout, f)
+		}
+	}
+	if *dryRun {
+		return nil
+	}
+	if !*yes && !confirm(bufio.NewReader(os.Stdin), fmt.Sprintf("Renumber %d records?", len(moves))) {
+		return nil
+	}
+
+	return applyMigration(fixes)
+}
+
+func joinInts(values []int) string {
+	s := []string{}
+	for _, v := range values {
+		s = append(s, strconv.Itoa(v))
+	}
+
+	return strings.Join(s, ", ")
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+type riskEntry struct {
+	ADR     *ADR
+	Section string
+	Risk    string
+}
+
+// riskSections are the section titles risks are collected from, matched case
+// insensitively as a prefix so "Risks and Mitigations" counts too
+var riskSections = []string{"Risks", "Consequences"}
+
+func collectRisks(adrs []*ADR) ([]riskEntry, error) {
+	risks := []riskEntry{}
+
+	for _, adr := range adrs {
+		body, err := ioutil.ReadFile(adr.Meta.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, section := range splitSections(string(body)) {
+			if !isRiskSection(section.Title) {
+				continue
+			}
+
+			for _, item := range riskItems(section.Body) {
+				risks = append(risks, riskEntry{ADR: adr, Section: section.Title, Risk: item})
+			}
+		}
+	}
+
+	return risks, nil
+}
+
+func isRiskSection(title string) bool {
+	for _, s := range riskSections {
+		if strings.HasPrefix(strings.ToLower(title), strings.ToLower(s)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// riskItems turns every list item into a risk, sections without a list yield
+// one risk per paragraph
+func riskItems(body string) []string {
+	items := []string{}
+	paragraphs := []string{}
+	current := []string{}
+
+	for _, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "* ") || strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, ". "):
+			items = append(items, strings.TrimSpace(trimmed[2:]))
+		case trimmed == "":
+			if len(current) > 0 {
+				paragraphs = append(paragraphs, strings.Join(current, " "))
+				current = []string{}
+			}
+		case len(items) > 0 && len(current) == 0:
+			// continuation of the previous list item
+			items[len(items)-1] += " " + trimmed
+		default:
+			current = append(current, trimmed)
+		}
+	}
+	if len(current) > 0 {
+		paragraphs = append(paragraphs, strings.Join(current, " "))
+	}
+
+	if len(items) > 0 {
+		return items
+	}
+
+	return paragraphs
+}
+
+func exportRiskRegisterCSV(adrs []*ADR, opts exportOptions, w io.Writer) error {
+	risks, err := collectRisks(adrs)
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"adr", "title", "status", "impact", "section", "risk", "path"})
+	for _, r := range risks {
+		cw.Write([]string{
+			strconv.Itoa(r.ADR.Meta.Index),
+			r.ADR.Heading,
+			r.ADR.Meta.Status,
+			r.ADR.Meta.Impact,
+			r.Section,
+			r.Risk,
+			r.ADR.Meta.Path,
+		})
+	}
+	cw.Flush()
+
+	return cw.Error()
+}
+
+func exportRiskRegister(adrs []*ADR, opts exportOptions, w io.Writer) error {
+	risks, err := collectRisks(adrs)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "= Risk Register\n\n")
+	fmt.Fprintf(w, "Risks and consequences collected from %d architecture decision records.\n\n", len(adrs))
+	fmt.Fprintf(w, "|===\n|ADR |Status |Impact |Risk\n")
+	for _, r := range risks {
+		fmt.Fprintf(w, "\n|link:%s[%s] %s\n|%s\n|%s\n|%s\n", r.ADR.Meta.Path, recordLabel(r.ADR), escapeCell(r.ADR.Heading), r.ADR.Meta.Status, r.ADR.Meta.Impact, escapeCell(r.Risk))
+	}
+	fmt.Fprintf(w, "|===\n")
+
+	return nil
+}
+
+// escapeCell keeps text from terminating an AsciiDoc table cell
+func escapeCell(s string) string {
+	return strings.Replace(s, "|", "\\|", -1)
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+const defaultRollupTemplate = `= Architecture Decision Records
+{{- range . }}
+
+== {{ .Tag | title }}
+
+|===
+|Component |Index |Description
+{{- range .Adrs }}
+|{{.Meta.Component}}
+|link:{{.Meta.Path}}[ADR-{{.Meta.Index}}]
+|{{.Heading}}
+{{- end }}
+|===
+{{- end }}
+`
+
+// componentRefRegex matches references to ADRs of other components, e.g. payments:ADR-12
+var componentRefRegex = regexp.MustCompile(`\b([A-Za-z0-9][A-Za-z0-9_-]*):ADR-(\d+)\b`)
+
+type component struct {
+	Name string
+	Dir  string
+	ADRs []*ADR
+}
+
+func runRollup(args []string) error {
+	fs := flag.NewFlagSet("rollup", flag.ExitOnError)
+	templatePath := fs.String("template", ".rollup.templ", "template for the global index, a built in template is used when missing")
+	localOutput := fs.String("local-output", "README.adoc", "file written inside every component with its local index, empty to skip")
+	output := fs.String("output", "", "file to write the global index to, defaults to stdout")
+	fs.Parse(args)
+
+	components, err := discoverComponents(".", cfg.Components)
+	if err != nil {
+		return err
+	}
+	if len(components) == 0 {
+		return fmt.Errorf("no components with an adr directory found")
+	}
+
+	all := []*ADR{}
+	for i := range components {
+		c := &components[i]
+
+		c.ADRs, err = loadADRs(path.Join(c.Dir, "adr"))
+		if err != nil {
+			return fmt.Errorf("component %s: %s", c.Name, err)
+		}
+		for _, a := range c.ADRs {
+			a.Meta.Component = c.Name
+		}
+
+		all = append(all, c.ADRs...)
+	}
+
+	err = verifyComponentRefs(components)
+	if err != nil {
+		return err
+	}
+
+	if *localOutput != "" {
+		for _, c := range components {
+			err := writeComponentIndex(c, *localOutput)
+			if err != nil {
+				return fmt.Errorf("component %s: %s", c.Name, err)
+			}
+		}
+	}
+
+	tmpl := template.New("rollup").Funcs(templateFuncs)
+	body, err := ioutil.ReadFile(*templatePath)
+	switch {
+	case os.IsNotExist(err):
+		tmpl, err = tmpl.Parse(defaultRollupTemplate)
+	case err == nil:
+		tmpl, err = tmpl.Parse(string(body))
+	}
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(*output, func(w io.Writer) error {
+		return tmpl.Execute(w, groupByTag(all))
+	})
+}
+
+// discoverComponents returns the configured component directories or, when none
+// are configured, every directory below root holding an adr directory
+func discoverComponents(root string, configured []string) ([]component, error) {
+	dirs := configured
+
+	if len(dirs) == 0 {
+		err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() {
+				return nil
+			}
+
+			name := info.Name()
+			if p != root && (strings.HasPrefix(name, ".") || name == "node_modules" || name == "vendor" || name == "adr") {
+				return filepath.SkipDir
+			}
+
+			if p == root {
+				return nil
+			}
+
+			st, err := os.Stat(filepath.Join(p, "adr"))
+			if err == nil && st.IsDir() {
+				dirs = append(dirs, filepath.ToSlash(p))
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	components := []component{}
+	seen := map[string]string{}
+	for _, d := range dirs {
+		d = path.Clean(filepath.ToSlash(d))
+		name := path.Base(d)
+		if other, ok := seen[name]; ok {
+			return nil, fmt.Errorf("components %s and %s share the name %s", other, d, name)
+		}
+		seen[name] = d
+
+		components = append(components, component{Name: name, Dir: d})
+	}
+
+	sort.Slice(components, func(i, j int) bool {
+		return components[i].Name < components[j].Name
+	})
+
+	return components, nil
+}
+
+func verifyComponentRefs(components []component) error {
+	known := map[string]map[int]bool{}
+	for _, c := range components {
+		known[c.Name] = map[int]bool{}
+		for _, a := range c.ADRs {
+			known[c.Name][a.Meta.Index] = true
+		}
+	}
+
+	// org:ADR-3 of an inherited catalog is checked with the inherited records
+	inherited := map[string]bool{}
+	for _, inh := range cfg.Inherit {
+		inherited[inh.Name] = true
+	}
+
+	problems := []string{}
+	for _, c := range components {
+		for _, a := range c.ADRs {
+			body, err := ioutil.ReadFile(a.Meta.Path)
+			if err != nil {
+				return err
+			}
+
+			for _, m := range componentRefRegex.FindAllStringSubmatch(string(body), -1) {
+				if inherited[m[1]] {
+					continue
+				}
+				idx, _ := strconv.Atoi(m[2])
+				indexes, ok := known[m[1]]
+				switch {
+				case !ok:
+					problems = append(problems, fmt.Sprintf("%s references unknown component %s in %s", a.Meta.Path, m[1], m[0]))
+				case !indexes[idx]:
+					problems = append(problems, fmt.Sprintf("%s references missing %s", a.Meta.Path, m[0]))
+				}
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid cross component references:\n  %s", strings.Join(problems, "\n  "))
+	}
+
+	return nil
+}
+
+// writeComponentIndex renders the local index of c with paths relative to the
+// component, using the component template when it has one
+func writeComponentIndex(c component, output string) error {
+	local := []*ADR{}
+	for _, a := range c.ADRs {
+		copied := *a
+		copied.Meta.Path = strings.TrimPrefix(a.Meta.Path, c.Dir+"/")
+		local = append(local, &copied)
+	}
+
+	templatePath := path.Join(c.Dir, ".readme.templ")
+	_, err := os.Stat(templatePath)
+	if os.IsNotExist(err) {
+		templatePath = ".readme.templ"
+	}
+
+	err = writeOutput(path.Join(c.Dir, output), func(w io.Writer) error {
+		return renderIndexes(local, templatePath, w)
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Wrote %s", path.Join(c.Dir, output))
+
+	return nil
+}
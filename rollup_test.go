@@ -0,0 +1,44 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVerifyComponentRefs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "adr-index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "0001-use-kafka.adoc")
+	err = ioutil.WriteFile(file, []byte("Follows org:ADR-3 and payments:ADR-1, see billing:ADR-9 and payments:ADR-7.\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(c *Config) { cfg = c }(cfg)
+	cfg = defaultConfig()
+	cfg.Inherit = []InheritConfig{{Name: "org", URL: "https://example.com/catalog.json"}}
+
+	components := []component{{Name: "payments", Dir: dir, ADRs: []*ADR{{Meta: ADRMeta{Index: 1, Path: file}}}}}
+	err = verifyComponentRefs(components)
+	if err == nil {
+		t.Fatal("no problems reported")
+	}
+	msg := err.Error()
+	if strings.Contains(msg, "org:ADR-3") {
+		t.Errorf("inherited reference reported: %s", msg)
+	}
+	for _, want := range []string{"unknown component billing in billing:ADR-9", "missing payments:ADR-7"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("%q not reported: %s", want, msg)
+		}
+	}
+	if strings.Contains(msg, "payments:ADR-1") {
+		t.Errorf("valid reference reported: %s", msg)
+	}
+}
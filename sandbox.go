@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"sync/atomic"
+	"text/template"
+	"text/template/parse"
+	"time"
+)
+
+// templateLimits bound the execution of templates supplied by someone else, for
+// example another team in a shared serve instance, the zero value means trusted
+type templateLimits struct {
+	Timeout   time.Duration
+	MaxOutput int64
+}
+
+// SandboxConfig configures the limits of templates rendered on behalf of
+// others, the index templates serve renders and build -sandbox
+type SandboxConfig struct {
+	// Timeout is how long a template may run, 5s when empty
+	Timeout time.Duration `yaml:"timeout"`
+	// MaxOutput is the number of bytes a template may write, 8 MiB when 0
+	MaxOutput int64 `yaml:"maxOutput"`
+}
+
+func (c SandboxConfig) limits() templateLimits {
+	limits := templateLimits{Timeout: c.Timeout, MaxOutput: c.MaxOutput}
+	if limits.Timeout <= 0 {
+		limits.Timeout = 5 * time.Second
+	}
+	if limits.MaxOutput <= 0 {
+		limits.MaxOutput = 8 << 20
+	}
+
+	return limits
+}
+
+// maxFormatWidth bounds the widths and precisions of printf in sandboxed
+// templates, %999999999d would allocate before the output cap applies
+const maxFormatWidth = 1000
+
+var formatWidthRegex = regexp.MustCompile(`%[-+# 0]*(\*|\d+)?(?:\.(\*|\d+))?`)
+
+// sandboxFuncs replace the builtins that reach beyond the catalog data or
+// allocate without bound, call would invoke any function value the data
+// happens to hold. sandboxTick is called by every range iteration and template
+// of a sandboxed template, executeLimited binds it to the deadline
+var sandboxFuncs = template.FuncMap{
+	"call": func(args ...interface{}) (string, error) {
+		return "", fmt.Errorf("call is not allowed in sandboxed templates")
+	},
+	"printf": func(format string, args ...interface{}) (string, error) {
+		for _, m := range formatWidthRegex.FindAllStringSubmatch(format, -1) {
+			for _, n := range m[1:] {
+				if n == "*" {
+					return "", fmt.Errorf("printf widths from arguments are not allowed in sandboxed templates")
+				}
+				if width, err := strconv.Atoi(n); err == nil && width > maxFormatWidth {
+					return "", fmt.Errorf("printf widths above %d are not allowed in sandboxed templates", maxFormatWidth)
+				}
+			}
+		}
+		return fmt.Sprintf(format, args...), nil
+	},
+	"sandboxTick": func() string { return "" },
+}
+
+func (l templateLimits) sandboxed() bool {
+	return l.Timeout > 0 || l.MaxOutput > 0
+}
+
+// cappedBuffer fails writes beyond max bytes or past the deadline, which
+// aborts template execution
+type cappedBuffer struct {
+	bytes.Buffer
+	max      int64
+	deadline time.Time
+	aborted  int32
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if atomic.LoadInt32(&b.aborted) != 0 || (!b.deadline.IsZero() && time.Now().After(b.deadline)) {
+		return 0, fmt.Errorf("template execution aborted")
+	}
+	if b.max > 0 && int64(b.Len()+len(p)) > b.max {
+		return 0, fmt.Errorf("template output exceeds %d bytes", b.max)
+	}
+
+	return b.Buffer.Write(p)
+}
+
+// tickEveryLoop makes every range iteration and every template of t call
+// sandboxTick, so a loop that writes nothing still stops at the deadline
+func tickEveryLoop(t *template.Template) error {
+	parsed, err := template.New("tick").Funcs(sandboxFuncs).Parse("{{sandboxTick}}")
+	if err != nil {
+		return err
+	}
+	tick := parsed.Tree.Root.Nodes[0]
+
+	var walk func(n parse.Node)
+	walk = func(n parse.Node) {
+		switch n := n.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child)
+			}
+		case *parse.RangeNode:
+			n.List.Nodes = append([]parse.Node{tick}, n.List.Nodes...)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.IfNode:
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walk(n.List)
+			walk(n.ElseList)
+		}
+	}
+	for _, tmpl := range t.Templates() {
+		if tmpl.Tree == nil || tmpl.Tree.Root == nil {
+			continue
+		}
+		walk(tmpl.Tree.Root)
+		tmpl.Tree.Root.Nodes = append([]parse.Node{tick}, tmpl.Tree.Root.Nodes...)
+	}
+
+	return nil
+}
+
+// executeLimited renders into memory and copies to w only on success, so a
+// template hitting a limit never leaves partial output behind. t must have
+// been parsed with sandboxFuncs
+func executeLimited(t *template.Template, data interface{}, w io.Writer, limits templateLimits) error {
+	if !limits.sandboxed() {
+		return t.Execute(w, data)
+	}
+
+	out := &cappedBuffer{max: limits.MaxOutput}
+	if limits.Timeout > 0 {
+		out.deadline = time.Now().Add(limits.Timeout)
+	}
+	err := tickEveryLoop(t)
+	if err != nil {
+		return err
+	}
+	t.Funcs(template.FuncMap{"sandboxTick": func() (string, error) {
+		if atomic.LoadInt32(&out.aborted) != 0 || (!out.deadline.IsZero() && time.Now().After(out.deadline)) {
+			return "", fmt.Errorf("did not finish within %s", limits.Timeout)
+		}
+		return "", nil
+	}})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- t.Execute(out, data)
+	}()
+
+	var timeout <-chan time.Time
+	if limits.Timeout > 0 {
+		timer := time.NewTimer(limits.Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return err
+		}
+	case <-timeout:
+		// the next write, range iteration or template of the abandoned
+		// execution fails
+		atomic.StoreInt32(&out.aborted, 1)
+		return fmt.Errorf("template %s did not finish within %s", t.Name(), limits.Timeout)
+	}
+
+	_, err = w.Write(out.Bytes())
+	return err
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// sarifSchema is the schema of the SARIF 2.1.0 logs GitHub code scanning reads
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+// annotation is a problem located in a file, the findings of validate and
+// lint and the errors of any command
+type annotation struct {
+	Path     string
+	Line     int
+	Severity string
+	Rule     string
+	Message  string
+}
+
+// annotations collects the findings of the validations a command reported
+// and the problems of the error it returned, a summary of the validations
+// without a file is left out
+func annotations(results interface{}, errs []InvalidRecord) []annotation {
+	found := []annotation{}
+	validations, ok := results.([]validation)
+	if ok {
+		for _, v := range validations {
+			for _, f := range v.Findings {
+				message := f.Message
+				if f.Fix != nil {
+					message += ", fix: " + f.Fix.Description
+				}
+				found = append(found, annotation{Path: v.File, Line: f.Line, Severity: f.Severity, Rule: f.Rule, Message: message})
+			}
+		}
+	}
+	for _, r := range errs {
+		// a command reporting validations sums them up in its error
+		if ok && r.Path == "" {
+			continue
+		}
+		message := r.Error
+		if r.Fix != nil {
+			message += ", fix: " + r.Fix.Description
+		}
+		found = append(found, annotation{Path: r.Path, Line: r.Line, Severity: severityError, Rule: r.Rule, Message: message})
+	}
+
+	return found
+}
+
+// writeAnnotations writes GitHub workflow commands, the runner turns them into
+// annotations on the lines of the pull request
+func writeAnnotations(w io.Writer, found []annotation) {
+	for _, a := range found {
+		command := "notice"
+		switch a.Severity {
+		case severityError:
+			command = "error"
+		case severityWarning:
+			command = "warning"
+		}
+
+		properties := []string{}
+		if a.Path != "" {
+			properties = append(properties, "file="+githubProperty(filepath.ToSlash(a.Path)))
+			if a.Line > 0 {
+				properties = append(properties, fmt.Sprintf("line=%d", a.Line))
+			}
+		}
+		if a.Rule != "" && a.Rule != "other" {
+			properties = append(properties, "title="+githubProperty(a.Rule))
+		}
+		fmt.Fprintf(w, "::%s %s::%s\n", command, strings.Join(properties, ","), githubData(a.Message))
+	}
+}
+
+func githubData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+func githubProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name    string      `json:"name"`
+	Version string      `json:"version"`
+	Rules   []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string     `json:"id"`
+	ShortDescription *sarifText `json:"shortDescription,omitempty"`
+	Help             *sarifText `json:"help,omitempty"`
+}
+
+type sarifText struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifText       `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysical `json:"physicalLocation"`
+}
+
+type sarifPhysical struct {
+	ArtifactLocation sarifArtifact `json:"artifactLocation"`
+	Region           *sarifRegion  `json:"region,omitempty"`
+}
+
+type sarifArtifact struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// writeSARIF writes the problems as a SARIF log for GitHub code scanning, the
+// rules are those of adr-index explain
+func writeSARIF(w io.Writer, found []annotation) error {
+	rules := map[string]bool{}
+	results := []sarifResult{}
+	for _, a := range found {
+		// code scanning rejects results without a location
+		if a.Path == "" {
+			continue
+		}
+		rule := a.Rule
+		if rule == "" {
+			rule = "other"
+		}
+		rules[rule] = true
+
+		level := "note"
+		switch a.Severity {
+		case severityError:
+			level = "error"
+		case severityWarning:
+			level = "warning"
+		}
+		l := sarifLocation{PhysicalLocation: sarifPhysical{ArtifactLocation: sarifArtifact{URI: filepath.ToSlash(a.Path)}}}
+		if a.Line > 0 {
+			l.PhysicalLocation.Region = &sarifRegion{StartLine: a.Line}
+		}
+		results = append(results, sarifResult{RuleID: rule, Level: level, Message: sarifText{a.Message}, Locations: []sarifLocation{l}})
+	}
+
+	ids := []string{}
+	for id := range rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	driver := sarifDriver{Name: "adr-index", Version: version, Rules: []sarifRule{}}
+	for _, id := range ids {
+		rule := sarifRule{ID: id}
+		if topic, ok := ruleTopics[id]; ok {
+			rule.ShortDescription = &sarifText{topic.Summary}
+			rule.Help = &sarifText{"adr-index explain " + id}
+		}
+		driver.Rules = append(driver.Rules, rule)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(sarifLog{Schema: sarifSchema, Version: "2.1.0", Runs: []sarifRun{{Tool: sarifTool{driver}, Results: results}}})
+}
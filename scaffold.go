@@ -0,0 +1,254 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+const defaultSkeleton = `= Title
+
+|===
+|Metadata |Value
+
+|Date |YYYY-MM-DD
+|Author |@<user>, @<user>
+|Status |Proposed
+|Tags |jetstream, client, server
+|===
+
+|===
+|Revision|Date|Author|Info
+|1 |YYYY-MM-DD|@author|Initial design
+|===
+
+== Context and Problem Statement
+
+[Describe the context and problem statement, e.g., in free form using two to three sentences. You may want to articulate the problem in form of a question.]
+
+== Design
+
+[If this is a specification or actual design, write something here.]
+
+== Decision
+
+[Maybe this was just an architectural decision…]
+
+== Consequences
+
+[Any consequences of this design, such as breaking change or Vorpal Bunnies]
+`
+
+// placeholderTag is set when no tags are given so the record validates, it is
+// meant to be replaced before review
+const placeholderTag = "untagged"
+
+func runNew(args []string) error {
+	fs := flag.NewFlagSet("new", flag.ExitOnError)
+	dir := fs.String("dir", settings.Dir, "directory containing ADR files")
+	recordType := fs.String("type", adrTypeKey, "record type of the new record")
+	template := fs.String("template", "", "skeleton used for the new record, defaults to the skeleton of the record type")
+	author := fs.String("author", gitAuthor(), "comma separated authors of the new record")
+	tags := fs.String("tags", placeholderTag, "comma separated tags of the new record")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: new [flags] \"<title>\"")
+	}
+
+	t := typeByName(*recordType)
+	if t == nil {
+		return fmt.Errorf("unknown record type %q", *recordType)
+	}
+	if *template == "" {
+		*template = t.Skeleton
+	}
+
+	target, err := createADR(*dir, scaffold{
+		Type:     t,
+		Title:    fs.Arg(0),
+		Authors:  parseCommaList(*author),
+		Tags:     parseCommaList(*tags),
+		Status:   "Proposed",
+		Date:     time.Now(),
+		Template: *template,
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := parseADR(target); err != nil {
+		return fmt.Errorf("scaffolded record does not validate, check %s: %s", *template, err)
+	}
+	fmt.Println(target)
+
+	return nil
+}
+
+type scaffold struct {
+	Type     *RecordType
+	Title    string
+	Authors  []string
+	Tags     []string
+	Status   string
+	Date     time.Time
+	Template string
+	// Skeleton is used instead of Template when set
+	Skeleton string
+	// Supersedes adds a Supersedes row naming these records
+	Supersedes []string
+	// Appendix is added after the last section of the skeleton
+	Appendix string
+}
+
+// nextIndex returns the next free index for records named like t, types sharing
+// a filename convention share the numbering
+func nextIndex(adrs []*ADR, t *RecordType) int {
+	max := 0
+	for _, a := range adrs {
+		if typeByName(a.Meta.Type).Filename != t.Filename {
+			continue
+		}
+		if a.Meta.Index > max {
+			max = a.Meta.Index
+		}
+	}
+
+	return max + 1
+}
+
+// render fills the metadata table of the skeleton, falling back to the built in
+// skeleton when the template file is not present
+func (s scaffold) render() (string, error) {
+	skeleton := defaultSkeleton
+	if s.Skeleton != "" {
+		skeleton = s.Skeleton
+	} else if s.Template != "" {
+		body, err := ioutil.ReadFile(s.Template)
+		if err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+		if err == nil {
+			skeleton = string(body)
+		}
+	}
+
+	date := s.Date.Format(dateLayout)
+	authors := strings.Join(s.Authors, ", ")
+
+	lines := strings.Split(skeleton, "\n")
+	if len(lines) > 0 && strings.HasPrefix(lines[0], "= ") {
+		lines[0] = "= " + s.Title
+	}
+	content := strings.Join(lines, "\n")
+
+	content = setMetaValue(content, "Date", date)
+	content = setMetaValue(content, "Author", authors)
+	content = setMetaValue(content, "Status", s.Status)
+	content = setMetaValue(content, "Tags", strings.Join(s.Tags, ", "))
+	if s.Type != nil && s.Type.Key != adrTypeKey {
+		content = ensureMetaRow(content, "Type", s.Type.Name)
+	}
+	if len(s.Supersedes) > 0 {
+		content = ensureMetaRow(content, "Supersedes", strings.Join(s.Supersedes, ", "))
+	}
+
+	content = strings.Replace(content, "YYYY-MM-DD", date, -1)
+	if len(s.Authors) > 0 {
+		content = strings.Replace(content, "@author", s.Authors[0], -1)
+	}
+	if s.Appendix != "" {
+		content = strings.TrimRight(content, "\n") + "\n" + s.Appendix
+	}
+
+	return content, nil
+}
+
+// setMetaValue replaces the value of the first |Key |Value row or :key: value
+// attribute in body
+func setMetaValue(body string, key string, value string) string {
+	lines := strings.Split(body, "\n")
+	b := findMetadata(lines)
+	for _, r := range b.Rows {
+		if r.Key == key {
+			lines = append(append(lines[:r.Line], b.format(key, value)), lines[r.End+1:]...)
+			return strings.Join(lines, "\n")
+		}
+	}
+
+	return body
+}
+
+// ensureMetaRow sets key or, when the metadata has no such row, appends it as
+// the last row of the table or the last metadata attribute
+func ensureMetaRow(body string, key string, value string) string {
+	lines := strings.Split(body, "\n")
+	b := findMetadata(lines)
+	for _, r := range b.Rows {
+		if r.Key == key {
+			return setMetaValue(body, key, value)
+		}
+	}
+
+	at := b.End
+	switch {
+	case b.Attributes && b.Start < 0:
+		at = b.Title + 1
+	case b.Attributes, b.Markdown:
+		at = b.End + 1
+	case b.Start < 0:
+		return body
+	}
+
+	rows := append([]string{b.format(key, value)}, lines[at:]...)
+	return strings.Join(append(lines[:at], rows...), "\n")
+}
+
+// createADR writes a new ADR with the next free index into dir and returns its path
+func createADR(dir string, s scaffold) (string, error) {
+	adrs, err := loadADRs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := s.render()
+	if err != nil {
+		return "", err
+	}
+
+	if s.Type == nil {
+		s.Type = typeByName("")
+	}
+
+	target := path.Join(dir, s.Type.fileName(nextIndex(adrs, s.Type), s.Title))
+
+	err = writeNewFile(target, content)
+	if err != nil {
+		return "", err
+	}
+
+	return target, nil
+}
+
+// writeNewFile writes content to a file that must not exist yet
+func writeNewFile(target string, content string) error {
+	unlock, err := lockRepo()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.WriteString(content)
+
+	return err
+}
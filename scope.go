@@ -0,0 +1,186 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// scopeKinds are the levels a decision can be scoped to, broadest first, org
+// may go without a name
+var scopeKinds = []string{"org", "department", "repo", "service"}
+
+// parseScope normalizes a Scope value such as Service:Payments to
+// service:payments
+func parseScope(s string) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	parts := strings.SplitN(s, ":", 2)
+	if !containsFold(scopeKinds, parts[0]) || (len(parts) == 2 && strings.TrimSpace(parts[1]) == "") || (len(parts) == 1 && parts[0] != "org") {
+		return "", fmt.Errorf("invalid scope %q, expected org or one of department:, repo: or service: followed by a name", s)
+	}
+	if len(parts) == 1 {
+		return s, nil
+	}
+
+	return parts[0] + ":" + strings.TrimSpace(parts[1]), nil
+}
+
+// scopeChain lists scope and the scopes it inherits decisions from, the
+// parents configured under scopes up to the org
+func scopeChain(scope string) ([]string, error) {
+	chain := []string{}
+	seen := map[string]bool{}
+	for s := scope; s != ""; {
+		if seen[s] {
+			return nil, fmt.Errorf("scope %s is its own parent in %s", s, configFile)
+		}
+		seen[s] = true
+		chain = append(chain, s)
+
+		parent, ok := cfg.Scopes[s]
+		if !ok {
+			break
+		}
+		p, err := parseScope(parent)
+		if err != nil {
+			return nil, fmt.Errorf("parent of scope %s: %s in %s", s, err, configFile)
+		}
+		s = p
+	}
+	if !seen["org"] && !strings.HasPrefix(chain[len(chain)-1], "org:") {
+		chain = append(chain, "org")
+	}
+
+	return chain, nil
+}
+
+// appliesIn returns the scope of chain a applies through, records without a
+// Scope apply everywhere and return ""
+func appliesIn(a *ADR, chain []string) (string, bool) {
+	if len(a.Meta.Scope) == 0 {
+		return "", true
+	}
+	for _, s := range chain {
+		for _, scope := range a.Meta.Scope {
+			if scope == s || (s == "org" && strings.HasPrefix(scope, "org")) {
+				return scope, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// effectiveDecision is a decision in force for a scope, From is the scope it
+// is inherited from, empty for the requested scope and unscoped records
+type effectiveDecision struct {
+	Record string `json:"record"`
+	Title  string `json:"title"`
+	Status string `json:"status"`
+	Scope  string `json:"scope,omitempty"`
+	From   string `json:"inheritedFrom,omitempty"`
+	// Overrides lists the broader decisions this one supersedes for the scope
+	Overrides []string `json:"overrides,omitempty"`
+	Path      string   `json:"path"`
+}
+
+// runEffective lists the decisions that apply to a scope, active records of
+// the scope and the scopes it inherits from that no applying record supersedes
+func runEffective(args []string) error {
+	fs := flag.NewFlagSet("effective", flag.ExitOnError)
+	dir := fs.String("dir", settings.Dir, "directory containing ADR files")
+	scope := fs.String("scope", "", "scope to resolve, e.g. service:payments")
+	fs.Parse(args)
+
+	if *scope == "" {
+		return fmt.Errorf("usage: effective -scope <kind:name>, e.g. -scope service:payments")
+	}
+	target, err := parseScope(*scope)
+	if err != nil {
+		return err
+	}
+	chain, err := scopeChain(target)
+	if err != nil {
+		return err
+	}
+
+	adrs, err := loadADRs(*dir)
+	if err != nil {
+		return err
+	}
+	inherited, errs, err := withInherited(adrs, nil)
+	if err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return &ErrInvalidCatalog{Dir: *dir, Errs: errs}
+	}
+	for _, a := range inherited {
+		// inherited catalogs carry the decisions of the org
+		a.Meta.Scope = []string{"org"}
+	}
+
+	candidates := []*ADR{}
+	for _, a := range append(searchADRs(settings.Filter.apply(adrs), ""), inherited...) {
+		if lifecycleOf(a.Meta.Status) != lifecycleActive || isSuperseded(a) {
+			continue
+		}
+		if _, ok := appliesIn(a, chain); ok {
+			candidates = append(candidates, a)
+		}
+	}
+
+	overridden := map[*ADR]*ADR{}
+	for _, a := range candidates {
+		for _, ref := range a.Meta.Supersedes {
+			for _, other := range candidates {
+				if other != a && (strings.EqualFold(ref, recordLabel(other)) || refersTo(adrs, []string{ref}, other)) {
+					overridden[other] = a
+				}
+			}
+		}
+	}
+
+	decisions := []effectiveDecision{}
+	for _, a := range candidates {
+		if overridden[a] != nil {
+			continue
+		}
+		in, _ := appliesIn(a, chain)
+		d := effectiveDecision{Record: recordLabel(a), Title: a.Heading, Status: a.Meta.Status, Scope: in, Path: a.Meta.Path}
+		if in != "" && in != target {
+			d.From = in
+		}
+		for other, by := range overridden {
+			if by == a {
+				d.Overrides = append(d.Overrides, recordLabel(other))
+			}
+		}
+		sort.Strings(d.Overrides)
+		decisions = append(decisions, d)
+	}
+	setResults(decisions)
+
+	fmt.Printf("%d decisions apply to %s (%s)\n", len(decisions), target, strings.Join(chain, " < "))
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	defer w.Flush()
+	p := newPainter(os.Stdout)
+	for _, d := range decisions {
+		note := "everywhere"
+		switch {
+		case d.From != "":
+			note = "from " + d.From
+		case d.Scope != "":
+			note = d.Scope
+		}
+		if len(d.Overrides) > 0 {
+			note += ", overrides " + strings.Join(d.Overrides, ", ")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", d.Record, p.status(d.Status), d.Title, note)
+	}
+
+	return nil
+}
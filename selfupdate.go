@@ -0,0 +1,354 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// version is set at release time with -ldflags "-X main.version=1.4.0", builds
+// from source report dev
+var version = "dev"
+
+// releaseKey is the base64 ed25519 key the checksums of releases are signed
+// with, set at release time with -ldflags "-X main.releaseKey=...", builds
+// without one cannot self-update
+var releaseKey = ""
+
+const (
+	defaultReleaseURL = "https://api.github.com/repos/cloudbackenddev/adr-template/releases/latest"
+	releaseNotesURL   = "https://github.com/cloudbackenddev/adr-template/releases/tag/v"
+)
+
+// UpdateConfig points self-update at the releases, the endpoint answers like
+// the GitHub latest release API with a tag_name and the assets. The key the
+// releases are verified with is built into the binary, not configured, so a
+// repository cannot have self-update install a binary of its choosing
+type UpdateConfig struct {
+	URL string `yaml:"url"`
+}
+
+const (
+	checksumsAsset = "checksums.txt"
+	signatureAsset = "checksums.txt.sig"
+	// maxArtifactSize bounds every download of self-update
+	maxArtifactSize = 200 << 20
+)
+
+type release struct {
+	Tag    string `json:"tag_name"`
+	Assets []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+func (r release) asset(name string) string {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a.URL
+		}
+	}
+
+	return ""
+}
+
+// runSelfUpdate replaces the running binary with the latest release once the
+// signature of its checksums and its checksum are verified
+func runSelfUpdate(args []string) error {
+	fs := flag.NewFlagSet("self-update", flag.ExitOnError)
+	endpoint := fs.String("url", cfg.Update.URL, "release endpoint, defaults to the latest GitHub release of adr-template")
+	check := fs.Bool("check", false, "only report whether a newer release is available")
+	force := fs.Bool("force", false, "install the latest release even if it is not newer, e.g. over a dev build")
+	fs.Parse(args)
+
+	if *endpoint == "" {
+		*endpoint = defaultReleaseURL
+	}
+	if !networkAllowed("self-update " + *endpoint) {
+		return fmt.Errorf("self-update needs the network, it is disabled by --offline")
+	}
+	if releaseKey == "" {
+		return fmt.Errorf("this build has no release key to verify updates with, install a release of adr-index to self-update")
+	}
+
+	headers := map[string]string{"Accept": "application/vnd.github+json"}
+	// the token is only for the GitHub API, the endpoint may come from the
+	// configuration of any repository
+	if u, err := url.Parse(*endpoint); err == nil && u.Scheme == "https" && strings.EqualFold(u.Hostname(), "api.github.com") {
+		token, err := resolveCredential("github", "GITHUB_TOKEN")
+		if err != nil {
+			return err
+		}
+		if token != "" {
+			headers["Authorization"] = "Bearer " + token
+		}
+	}
+
+	var latest release
+	err := apiRequest("GET", *endpoint, headers, nil, &latest)
+	if err != nil {
+		return err
+	}
+
+	newer, err := isNewerVersion(latest.Tag, version)
+	if err != nil && !*force {
+		return fmt.Errorf("%s, use -force to install %s anyway", err, latest.Tag)
+	}
+	if !newer && !*force {
+		fmt.Printf("adr-index %s is up to date, the latest release is %s\n", version, latest.Tag)
+		return nil
+	}
+	if *check {
+		fmt.Printf("adr-index %s can be updated to %s, run adr-index self-update\n", version, latest.Tag)
+		return nil
+	}
+
+	name := artifactName()
+	binary, err := download(latest.asset(name), name)
+	if err != nil {
+		return err
+	}
+	checksums, err := download(latest.asset(checksumsAsset), checksumsAsset)
+	if err != nil {
+		return err
+	}
+	signature, err := download(latest.asset(signatureAsset), signatureAsset)
+	if err != nil {
+		return err
+	}
+	err = verifySignature(checksums, signature, releaseKey)
+	if err != nil {
+		return err
+	}
+	err = verifyChecksum(binary, checksums, name)
+	if err != nil {
+		return err
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	exe, err = filepath.EvalSymlinks(exe)
+	if err != nil {
+		return err
+	}
+	err = replaceBinary(exe, binary)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Updated %s from %s to %s\n", exe, version, latest.Tag)
+
+	return nil
+}
+
+// artifactName is the release asset built for this platform
+func artifactName() string {
+	name := fmt.Sprintf("adr-index_%s_%s", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+
+	return name
+}
+
+func download(url string, name string) ([]byte, error) {
+	if url == "" {
+		return nil, fmt.Errorf("the release has no %s asset", name)
+	}
+
+	resp, err := newHTTPClient(5 * time.Minute).Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("downloading %s failed: %s", name, resp.Status)
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxArtifactSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxArtifactSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", name, maxArtifactSize)
+	}
+
+	return body, nil
+}
+
+// verifyChecksum looks name up in a sha256sum style checksums file
+func verifyChecksum(binary []byte, checksums []byte, name string) error {
+	sum := sha256.Sum256(binary)
+	actual := hex.EncodeToString(sum[:])
+
+	s := bufio.NewScanner(bytes.NewReader(checksums))
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) != 2 || strings.TrimPrefix(fields[1], "*") != name {
+			continue
+		}
+		if !strings.EqualFold(fields[0], actual) {
+			return fmt.Errorf("checksum mismatch for %s, expected %s and downloaded %s", name, fields[0], actual)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("%s has no checksum for %s", checksumsAsset, name)
+}
+
+// verifySignature checks the ed25519 signature of the checksums file, the
+// signature may be raw or base64 encoded
+func verifySignature(checksums []byte, signature []byte, publicKey string) error {
+	key, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid release key built into adr-index, expected a base64 ed25519 key")
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature))); err == nil {
+		signature = decoded
+	}
+
+	if !ed25519.Verify(ed25519.PublicKey(key), checksums, signature) {
+		return fmt.Errorf("%s does not verify with the release key, refusing to update", signatureAsset)
+	}
+
+	return nil
+}
+
+// replaceBinary writes the new binary next to exe and swaps it in, the running
+// binary is moved aside first since Windows cannot overwrite it
+func replaceBinary(exe string, binary []byte) error {
+	info, err := os.Stat(exe)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(exe), "."+filepath.Base(exe)+".")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(binary)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), info.Mode().Perm()|0111)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	old := exe + ".old"
+	os.Remove(old)
+	err = os.Rename(exe, old)
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	err = os.Rename(tmp.Name(), exe)
+	if err != nil {
+		os.Rename(old, exe)
+		os.Remove(tmp.Name())
+		return err
+	}
+	// fails on Windows while the old binary runs, the next update removes it
+	os.Remove(old)
+
+	return nil
+}
+
+// parseVersion reads major.minor.patch with an optional v prefix, a pre-release
+// or build suffix is ignored
+func parseVersion(v string) ([3]int, error) {
+	parsed := [3]int{}
+	core := strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(core, "-+"); i >= 0 {
+		core = core[:i]
+	}
+
+	parts := strings.Split(core, ".")
+	if len(parts) > 3 {
+		return parsed, fmt.Errorf("invalid version %q, expected major.minor.patch", v)
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return parsed, fmt.Errorf("invalid version %q, expected major.minor.patch", v)
+		}
+		parsed[i] = n
+	}
+
+	return parsed, nil
+}
+
+func compareVersions(a [3]int, b [3]int) int {
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// isNewerVersion reports whether release is newer than current, dev builds
+// cannot be compared
+func isNewerVersion(release string, current string) (bool, error) {
+	if current == "dev" {
+		return false, fmt.Errorf("this is a development build")
+	}
+
+	r, err := parseVersion(release)
+	if err != nil {
+		return false, err
+	}
+	c, err := parseVersion(current)
+	if err != nil {
+		return false, err
+	}
+
+	return compareVersions(r, c) > 0, nil
+}
+
+// checkMinVersion fails when the configuration requires a newer release than
+// this binary, dev builds are taken to be current
+func checkMinVersion(min string) error {
+	if min == "" {
+		return nil
+	}
+	want, err := parseVersion(min)
+	if err != nil {
+		return fmt.Errorf("invalid minVersion in %s: %s", configFile, err)
+	}
+	have, err := parseVersion(version)
+	if version == "dev" || err != nil {
+		return nil
+	}
+
+	if compareVersions(have, want) < 0 {
+		return fmt.Errorf("%s requires adr-index %s or newer and this is %s, run adr-index self-update, the release notes at %s%s list what changed",
+			configFile, min, version, releaseNotesURL, strings.TrimPrefix(min, "v"))
+	}
+
+	return nil
+}
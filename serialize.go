@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// serializeADR renders a record from the in-memory model, AsciiDoc with a
+// metadata table or Markdown for .md paths, the sections follow the table.
+// Values the format cannot hold, such as a | in a table cell, are an error
+// rather than a document that reads back differently
+func serializeADR(a *ADR, sections []Section) (string, error) {
+	markdown := path.Ext(a.Meta.Path) == ".md"
+	err := checkLine("title", a.Heading, true)
+	if err != nil {
+		return "", err
+	}
+
+	rows, err := metadataRows(a)
+	if err != nil {
+		return "", err
+	}
+
+	b := &strings.Builder{}
+	heading := "="
+	if markdown {
+		heading = "#"
+		fmt.Fprintf(b, "# %s\n\n| Metadata | Value |\n|----------|-------|\n", a.Heading)
+		for _, r := range rows {
+			fmt.Fprintf(b, "| %s | %s |\n", r.Key, r.Value)
+		}
+	} else {
+		fmt.Fprintf(b, "= %s\n\n|===\n|Metadata |Value\n\n", a.Heading)
+		for _, r := range rows {
+			fmt.Fprintf(b, "|%s |%s\n", r.Key, r.Value)
+		}
+		b.WriteString("|===\n")
+	}
+
+	for _, s := range sections {
+		err = checkSection(s)
+		if err != nil {
+			return "", err
+		}
+		if s.Level > 0 {
+			fmt.Fprintf(b, "\n%s %s\n", strings.Repeat(heading, s.Level+1), s.Title)
+		}
+		if s.Body != "" {
+			fmt.Fprintf(b, "\n%s\n", s.Body)
+		}
+	}
+
+	return b.String(), nil
+}
+
+// metadataRows are the table rows of the model in the order of metadataKeys
+// followed by the Extra rows by name, empty values are left out
+func metadataRows(a *ADR) ([]metaRow, error) {
+	m := a.Meta
+	authors := []string{}
+	for i, name := range m.Authors {
+		p := Person{Name: name}
+		if len(m.People) == len(m.Authors) {
+			p = m.People[i]
+		}
+		if strings.ContainsAny(p.Name, `"<>()`) || strings.ContainsAny(p.Email+p.Team, `"<>(),`) {
+			return nil, fmt.Errorf("cannot serialize author %q, names and emails must not hold quotes, <> or ()", p.String())
+		}
+		authors = append(authors, p.String())
+	}
+	costs := []string{}
+	for _, c := range m.Cost {
+		costs = append(costs, formatCost(c))
+	}
+
+	values := map[string]string{
+		"Author":  strings.Join(authors, ", "),
+		"Status":  m.Status,
+		"Impact":  m.Impact,
+		"Cost":    strings.Join(costs, ", "),
+		"Outcome": m.Outcome,
+		"Decides": m.Decides,
+	}
+	if !m.Date.IsZero() {
+		values["Date"] = m.Date.Format(dateLayout)
+	}
+	if !m.Reviewed.IsZero() {
+		values["Reviewed"] = m.Reviewed.Format(dateLayout)
+	}
+	lists := map[string][]string{"Approvers": m.Approvers, "Tags": m.Tags, "Incidents": m.Incidents, "Supersedes": m.Supersedes, "Superseded by": m.SupersededBy, "Conflicts with": m.ConflictsWith, "Scope": m.Scope}
+	for key, items := range lists {
+		for _, item := range items {
+			if strings.ContainsAny(item, `,"<>()`) || strings.HasPrefix(item, "* ") || strings.HasPrefix(item, "- ") {
+				return nil, fmt.Errorf("cannot serialize %s item %q, items must not hold commas, quotes, <> or ()", key, item)
+			}
+		}
+		values[key] = strings.Join(items, ", ")
+	}
+	if m.Type != typeForFile(m.Path).metaType() {
+		values["Type"] = m.Type
+	}
+
+	rows := []metaRow{}
+	for _, key := range metadataKeys {
+		v := values[key]
+		if v == "" {
+			continue
+		}
+		err := checkCell(key, v)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, metaRow{Key: key, Value: v})
+	}
+	extra := []string{}
+	for key := range m.Extra {
+		extra = append(extra, key)
+	}
+	sort.Strings(extra)
+	for _, key := range extra {
+		v := m.Extra[key]
+		if v == "" {
+			continue
+		}
+		err := checkCell(key, v)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, metaRow{Key: key, Value: v})
+	}
+
+	return rows, nil
+}
+
+func formatCost(c CostItem) string {
+	s := fmt.Sprintf("%s %s %s", c.Kind, strconv.FormatFloat(c.Amount, 'f', -1, 64), c.Currency)
+	if c.Period != "" {
+		s += "/" + c.Period
+	}
+
+	return s
+}
+
+// checkLine rejects headings that change when read back
+func checkLine(what string, s string, required bool) error {
+	switch {
+	case s == "" && required:
+		return fmt.Errorf("cannot serialize an empty %s", what)
+	case strings.ContainsAny(s, "\r\n"):
+		return fmt.Errorf("cannot serialize %s %q, it must be a single line", what, s)
+	case s != strings.TrimSpace(s):
+		return fmt.Errorf("cannot serialize %s %q with surrounding spaces", what, s)
+	}
+
+	return nil
+}
+
+// checkCell rejects metadata values that change in a table cell
+func checkCell(key string, s string) error {
+	if strings.Contains(s, "|") {
+		return fmt.Errorf("cannot serialize %s %q, table cells must not hold |", key, s)
+	}
+
+	return checkLine(key, s, false)
+}
+
+// checkSection rejects section bodies that would read back as headings or
+// metadata, bodies are trimmed when read
+func checkSection(s Section) error {
+	if s.Level > 0 {
+		if err := checkLine("section title", s.Title, true); err != nil {
+			return err
+		}
+	}
+	if s.Body != strings.TrimSpace(s.Body) {
+		return fmt.Errorf("cannot serialize section %q, its body has surrounding white space", s.Title)
+	}
+	for _, line := range strings.Split(s.Body, "\n") {
+		if sectionHeadingRegex.MatchString(line) || strings.HasPrefix(line, "= ") || strings.HasPrefix(line, "# ") || strings.HasPrefix(line, "---") || (s.Level == 0 && strings.HasPrefix(line, "|")) {
+			return fmt.Errorf("cannot serialize section %q, the line %q reads back as structure", s.Title, line)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+// quickRecord is a random record for testing/quick, the index is drawn from
+// the generator so replaying a seed replays the records
+type quickRecord struct {
+	ADR      *ADR
+	Sections []Section
+}
+
+func (quickRecord) Generate(r *rand.Rand, size int) reflect.Value {
+	a, sections := randomRecord(r, 1+r.Intn(size+1))
+	return reflect.ValueOf(quickRecord{ADR: a, Sections: sections})
+}
+
+func TestRoundtripRandom(t *testing.T) {
+	check := func(q quickRecord) bool {
+		problem, ok := roundtrip(q.ADR, q.Sections)
+		if !ok {
+			// the serializer refused a value the format cannot hold
+			return true
+		}
+		if problem != "" {
+			t.Logf("%s: %s", q.ADR.Meta.Path, problem)
+			return false
+		}
+		return true
+	}
+
+	err := quick.Check(check, &quick.Config{MaxCount: 2000})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRoundtripCorpus(t *testing.T) {
+	dir := path.Join("testdata", "corpus")
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checked := 0
+	for _, f := range files {
+		if f.IsDir() || !isRecordFile(f.Name()) {
+			continue
+		}
+		file := path.Join(dir, f.Name())
+		body, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a, err := parseADRContent(file, body)
+		if err != nil {
+			continue
+		}
+		// undeclared keys are not in the model
+		if len(unmodeledKeys(a, string(body))) > 0 {
+			continue
+		}
+		problem, ok := roundtrip(a, splitSections(string(body)))
+		if ok && problem != "" {
+			t.Errorf("%s: %s", file, problem)
+		}
+		checked++
+	}
+	if checked == 0 {
+		t.Fatalf("no records of %s were round tripped", dir)
+	}
+}
+
+// roundtrip serializes and parses a record, ok is false when the record cannot
+// be serialized and problem describes what changed otherwise
+func roundtrip(a *ADR, sections []Section) (problem string, ok bool) {
+	content, err := serializeADR(a, sections)
+	if err != nil {
+		return err.Error(), false
+	}
+
+	parsed, err := parseADRContent(a.Meta.Path, []byte(content))
+	if err != nil {
+		return fmt.Sprintf("serialized record does not parse: %s\n%s", err, content), true
+	}
+	diffs := recordDiff(a, parsed)
+	if !reflect.DeepEqual(dropEmpty(sections), splitSections(content)) {
+		diffs = append(diffs, "sections")
+	}
+	if len(diffs) > 0 {
+		return fmt.Sprintf("%s changed\n%s", strings.Join(diffs, ", "), content), true
+	}
+
+	return "", true
+}
+
+// recordDiff names the fields differing between two records, empty and nil
+// slices are the same
+func recordDiff(a *ADR, b *ADR) []string {
+	diffs := []string{}
+	if a.Heading != b.Heading {
+		diffs = append(diffs, "Heading")
+	}
+
+	va, vb := reflect.ValueOf(a.Meta), reflect.ValueOf(b.Meta)
+	for i := 0; i < va.NumField(); i++ {
+		x, y := va.Field(i), vb.Field(i)
+		if x.Kind() == reflect.Slice && x.Len() == 0 && y.Len() == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(x.Interface(), y.Interface()) {
+			diffs = append(diffs, va.Type().Field(i).Name)
+		}
+	}
+
+	return diffs
+}
+
+func unmodeledKeys(a *ADR, content string) []string {
+	keys := []string{}
+	for _, r := range findMetadata(strings.Split(content, "\n")).Rows {
+		if _, ok := a.Meta.Extra[r.Key]; ok {
+			continue
+		}
+		if !containsFold(metadataKeys, r.Key) && !containsFold(keys, r.Key) {
+			keys = append(keys, r.Key)
+		}
+	}
+
+	return keys
+}
+
+// dropEmpty leaves out an empty preamble, reading always yields one section
+// for it so it is added back instead
+func dropEmpty(sections []Section) []Section {
+	if len(sections) == 0 || sections[0].Level != 0 {
+		return append([]Section{{}}, sections...)
+	}
+
+	return sections
+}
+
+// randomRecord builds a valid ADR model with values chosen to hit the corners
+// of the format, separators, quotes, unicode and optional metadata
+func randomRecord(r *rand.Rand, index int) (*ADR, []Section) {
+	words := []string{"cache", "Kafka", "zürich", "api", "v2", "日本", "x-y", "a_b", "p99", "route", "'quoted'", "café", "*", "-", "50%"}
+	// tricky words are rare so most records still serialize
+	tricky := []string{"a,b", "pipe|", "<tag>", "(team)", `"q"`, "== h", "|==="}
+	text := func(n int) string {
+		parts := []string{}
+		for i := 0; i <= r.Intn(n); i++ {
+			if r.Intn(40) == 0 {
+				parts = append(parts, tricky[r.Intn(len(tricky))])
+				continue
+			}
+			parts = append(parts, words[r.Intn(len(words))])
+		}
+		return strings.Join(parts, " ")
+	}
+	list := func(n int) []string {
+		items := []string{}
+		for i := 0; i < r.Intn(n+1); i++ {
+			items = append(items, text(2))
+		}
+		return items
+	}
+
+	ext := ".adoc"
+	if r.Intn(4) == 0 {
+		ext = ".md"
+	}
+	a := &ADR{Heading: text(6)}
+	a.Meta = ADRMeta{
+		Index:  index,
+		Path:   path.Join("adr", fmt.Sprintf("%04d-roundtrip%s", index, ext)),
+		Date:   time.Date(2000+r.Intn(30), time.Month(1+r.Intn(12)), 1+r.Intn(28), 0, 0, 0, 0, time.UTC),
+		Status: validStatus[r.Intn(len(validStatus))],
+		Tags:   append([]string{text(1)}, list(2)...),
+	}
+	for i := 0; i <= r.Intn(3); i++ {
+		p := Person{Name: "@" + text(1)}
+		if r.Intn(3) == 0 {
+			p.Email = fmt.Sprintf("dev%d@example.com", r.Intn(100))
+		}
+		if r.Intn(3) == 0 {
+			p.Team = text(1)
+		}
+		a.Meta.Authors = append(a.Meta.Authors, p.Name)
+		a.Meta.People = append(a.Meta.People, p)
+	}
+	if r.Intn(3) == 0 {
+		a.Meta.Impact = []string{"High", "Medium", "Low"}[r.Intn(3)]
+	}
+	if r.Intn(4) == 0 {
+		a.Meta.Cost = []CostItem{{Kind: costOneOff, Amount: float64(r.Intn(100000)), Currency: "EUR"}, {Kind: costRecurring, Amount: float64(r.Intn(1000)) + 0.5, Currency: "USD", Period: "month"}}
+	}
+	if r.Intn(4) == 0 {
+		a.Meta.Outcome = validOutcomes[r.Intn(len(validOutcomes))]
+	}
+	if r.Intn(4) == 0 {
+		a.Meta.Reviewed = a.Meta.Date.AddDate(0, 6, 0)
+	}
+	a.Meta.Incidents = list(2)
+	if index > 1 && r.Intn(5) == 0 {
+		a.Meta.Supersedes = []string{fmt.Sprintf("ADR-%d", 1+r.Intn(index-1))}
+	}
+	if r.Intn(5) == 0 {
+		a.Meta.SupersededBy = []string{fmt.Sprintf("ADR-%d", index+1+r.Intn(10))}
+	}
+
+	sections := []Section{{Level: 0, Body: text(8)}}
+	for i := 0; i < r.Intn(4); i++ {
+		sections = append(sections, Section{Title: text(3), Level: 1 + r.Intn(2), Body: text(20)})
+	}
+
+	return a, sections
+}
@@ -0,0 +1,724 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+const servePageTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; max-width: 60em; margin: 2em auto; padding: 0 1em; }
+table { border-collapse: collapse; }
+td, th { border: 1px solid #ccc; padding: .3em .6em; text-align: left; }
+pre { white-space: pre-wrap; }
+.freshness { font-size: 1.2em; }
+.fresh { color: #2a2; } .aging { color: #e90; } .stale { color: #d22; } .retired { color: #999; }
+#switcher { position: fixed; inset: 0; background: rgba(0,0,0,.3); display: none; }
+#switcher.open { display: block; }
+#switcher div { background: #fff; max-width: 36em; margin: 10vh auto 0; padding: .6em; border-radius: 6px; box-shadow: 0 4px 24px rgba(0,0,0,.3); }
+#switcher input { width: 100%; box-sizing: border-box; font-size: 1.1em; padding: .4em; }
+#switcher ul { list-style: none; margin: .4em 0 0; padding: 0; }
+#switcher li { padding: .3em .4em; cursor: pointer; }
+#switcher li.selected { background: #d0ebff; }
+#switcher small { color: #666; }
+.hint { color: #666; }
+.print-footer { display: none; }
+@media print {
+  @page { margin: 2cm; }
+  body { font-family: Georgia, "Times New Roman", serif; font-size: 11pt; line-height: 1.4; max-width: none; margin: 0; padding: 0; }
+  .hint, .noprint, #switcher { display: none !important; }
+  h1 { font-size: 18pt; margin: 0 0 .4em; }
+  h2 { font-size: 13pt; break-after: avoid; }
+  pre { font-family: inherit; break-inside: avoid-page; }
+  a { color: inherit; text-decoration: none; }
+  table.meta { width: 100%; border-top: 2px solid #000; border-bottom: 2px solid #000; margin-bottom: 1em; }
+  table.meta th, table.meta td { border: none; padding: .1em .6em .1em 0; }
+  .print-footer { display: flex; gap: 1em; align-items: center; border-top: 1px solid #000; margin-top: 2em; padding-top: .6em; font-size: 9pt; break-inside: avoid; }
+  .print-footer svg { width: 2.5cm; height: 2.5cm; }
+}
+</style>
+</head>
+<body>
+<p class="hint"><small>Press <kbd>/</kbd> or <kbd>Ctrl</kbd>+<kbd>K</kbd> to jump to a record</small></p>
+{{template "content" .}}
+<div id="switcher" role="dialog" aria-label="Jump to a record"><div>
+<input type="search" placeholder="Jump to a record by title, label or tag" aria-label="Jump to a record" autocomplete="off">
+<ul role="listbox"></ul>
+</div></div>
+<script src="/switcher.js"></script>
+</body>
+</html>
+{{define "table"}}
+<table>
+<tr><th></th><th>Index</th><th>Tags</th><th>Description</th><th>Status</th></tr>
+{{- range .}}
+{{- $f := freshness .}}
+<tr><td><span class="freshness {{$f.Level}}" title="{{$f}}">&#9679;</span></td><td><a href="/{{page .}}">{{label .}}</a></td><td>{{join .Meta.Tags}}</td><td>{{.Heading}}</td><td>{{.Meta.Status}}{{with .Meta.Supersedes}}, supersedes {{join .}}{{end}}{{with .Meta.SupersededBy}}, superseded by {{join .}}{{end}}</td></tr>
+{{- end}}
+</table>
+{{end}}
+{{define "record"}}
+<h1>{{label .ADR}} {{.ADR.Heading}}</h1>
+<table class="meta">
+<tr><th>Date</th><td>{{.ADR.Meta.Date.Format "2006-01-02"}}</td></tr>
+<tr><th>Author</th><td>{{join .ADR.Meta.Authors}}</td></tr>
+<tr><th>Status</th><td>{{.ADR.Meta.Status}}</td></tr>
+<tr><th>Freshness</th><td>{{freshness .ADR}}</td></tr>
+<tr><th>Tags</th><td>{{join .ADR.Meta.Tags}}</td></tr>
+{{- range $key, $value := .ADR.Meta.Extra}}
+<tr><th>{{$key}}</th><td>{{$value}}</td></tr>
+{{- end}}
+{{- with .ADR.Meta.Source}}
+<tr><th>Source</th><td>{{.}}{{if .Vendored}}, vendored copy{{end}}</td></tr>
+{{- end}}
+</table>
+{{- range .Sections}}
+{{- if .Title}}
+<h2>{{.Title}}</h2>
+{{- end}}
+<pre>{{.Body}}</pre>
+{{- end}}
+<footer class="print-footer">
+{{- with permalink .ADR}}
+{{qr .}}
+{{- end}}
+<p>{{label .ADR}} {{.ADR.Heading}}, {{.ADR.Meta.Status}}<br>{{or (permalink .ADR) .ADR.Meta.Path}}</p>
+</footer>
+{{end}}`
+
+// serveScript opens the quick switcher and binds the Print buttons, it is
+// served as /switcher.js since the content security policy refuses inline
+// scripts
+const serveScript = `(function () {
+  var box = document.getElementById("switcher"), input = box.querySelector("input"), list = box.querySelector("ul");
+  var records = null, matches = [], selected = 0;
+
+  // score is fuzzy, the letters of the query in order, consecutive letters and
+  // word starts count more, -1 when a letter is missing
+  var score = function (query, text) {
+    var s = 0, run = 0, at = 0;
+    for (var i = 0; i < query.length; i++) {
+      var found = text.indexOf(query[i], at);
+      if (found < 0) { return -1; }
+      run = found === at ? run + 1 : 0;
+      s += 1 + run * 2 + (found === 0 || " -:".indexOf(text[found - 1]) >= 0 ? 3 : 0);
+      at = found + 1;
+    }
+    return s - text.length / 100;
+  };
+  var show = function () {
+    var query = input.value.toLowerCase().replace(/\s+/g, "");
+    matches = [];
+    (records || []).forEach(function (r) {
+      var s = score(query, (r.label + " " + r.title + " " + r.tags.join(" ")).toLowerCase());
+      if (s >= 0) { matches.push({ record: r, score: s }); }
+    });
+    matches.sort(function (a, b) { return b.score - a.score; });
+    matches = matches.slice(0, 10);
+    selected = Math.min(selected, Math.max(matches.length - 1, 0));
+    list.innerHTML = "";
+    matches.forEach(function (m, i) {
+      var li = document.createElement("li");
+      li.setAttribute("role", "option");
+      li.className = i === selected ? "selected" : "";
+      li.textContent = m.record.label + " " + m.record.title + " ";
+      var meta = document.createElement("small");
+      meta.textContent = [m.record.status].concat(m.record.tags).filter(Boolean).join(", ");
+      li.appendChild(meta);
+      li.addEventListener("click", function () { location.href = m.record.url; });
+      list.appendChild(li);
+    });
+  };
+  var open = function () {
+    box.classList.add("open");
+    input.value = "";
+    selected = 0;
+    input.focus();
+    if (records) { show(); return; }
+    fetch("/api/switcher").then(function (r) { return r.json(); }).then(function (r) { records = r; show(); });
+  };
+  var close = function () { box.classList.remove("open"); };
+
+  document.addEventListener("keydown", function (e) {
+    var typing = /^(INPUT|TEXTAREA|SELECT)$/.test(document.activeElement.tagName);
+    if ((e.key === "k" && (e.ctrlKey || e.metaKey)) || (e.key === "/" && !typing)) {
+      e.preventDefault();
+      open();
+    }
+  });
+  input.addEventListener("input", function () { selected = 0; show(); });
+  input.addEventListener("keydown", function (e) {
+    if (e.key === "ArrowDown" || e.key === "ArrowUp") {
+      e.preventDefault();
+      selected = (selected + (e.key === "ArrowDown" ? 1 : matches.length - 1)) % Math.max(matches.length, 1);
+      show();
+    } else if (e.key === "Enter" && matches[selected]) {
+      location.href = matches[selected].record.url;
+    } else if (e.key === "Escape") {
+      close();
+    }
+  });
+  box.addEventListener("click", function (e) { if (e.target === box) { close(); } });
+  document.querySelectorAll("[data-print]").forEach(function (b) {
+    b.addEventListener("click", function () { window.print(); });
+  });
+})();
+`
+
+const serveIndexTemplate = `{{define "content"}}
+<h1>Architecture Decision Records</h1>
+{{- if .Personal}}
+<p><a href="/me">What is relevant to you</a></p>
+{{- end}}
+{{- range .Tags}}
+<h2>{{title .Tag}}</h2>
+{{template "table" .Adrs}}
+{{- end}}
+{{- range .Sections}}
+<h2>{{.Title}}</h2>
+{{template "table" .Records}}
+{{- end}}
+{{- with .Inherited}}
+<h2>Inherited decisions</h2>
+<table>
+<tr><th>Index</th><th>Description</th><th>Status</th></tr>
+{{- range .}}
+<tr><td><a href="{{.Meta.Path}}">{{label .}}</a></td><td>{{.Heading}}</td><td>{{.Meta.Status}}{{with .Meta.SupersededBy}}, superseded here by {{join .}}{{end}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Errors}}
+<h2>Broken records</h2>
+<ul>
+{{- range .Errors}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+{{- end}}
+<p><small>Built {{.Built.Format "2006-01-02 15:04:05"}}</small></p>
+{{end}}`
+
+const serveMeTemplate = `{{define "content"}}
+<p><a href="/">All records</a></p>
+<h1>{{.Title}}</h1>
+<p>{{with .Reader.Teams}}Teams {{join .}}, following {{join $.Reader.Tags}}{{else}}No teams, the records you wrote are listed{{end}}</p>
+<h2>Waiting for your approval</h2>
+{{- if .Approvals}}
+{{template "table" .Approvals}}
+{{- else}}
+<p>Nothing waits for your approval.</p>
+{{- end}}
+<h2>Changed in the last {{.RecentDays}} days</h2>
+{{- if .Changed}}
+<table>
+<tr><th>Changed</th><th>Index</th><th>Description</th><th>Status</th></tr>
+{{- range .Changed}}
+<tr><td>{{.Changed.Format "2006-01-02"}}</td><td><a href="/{{page .ADR}}">{{label .ADR}}</a></td><td>{{.ADR.Heading}}</td><td>{{.ADR.Meta.Status}}</td></tr>
+{{- end}}
+</table>
+{{- else}}
+<p>None of your records changed.</p>
+{{- end}}
+<h2>Your decisions</h2>
+{{template "table" .Records}}
+{{end}}`
+
+const serveRecordTemplate = `{{define "content"}}
+<p class="noprint"><a href="/">All records</a> <button type="button" data-print>Print</button></p>
+{{template "record" .}}
+{{end}}`
+
+const servePreviewTemplate = `{{define "content"}}
+<p><a href="/">All records</a></p>
+<h1>Preview</h1>
+<form method="post" action="/preview">
+<p><label>File name <input name="name" size="40" value="{{.Name}}"></label></p>
+<p><textarea name="content" rows="20" cols="100">{{.Content}}</textarea></p>
+<p><button type="submit">Preview</button></p>
+</form>
+{{- with .Findings}}
+<h2>Findings</h2>
+<ul>
+{{- range .}}
+<li>{{.Severity}}: {{.Message}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- with .Page}}
+<hr>
+{{template "record" .}}
+{{- end}}
+{{end}}`
+
+// serveSnapshot is a fully rendered catalog, requests are answered from the
+// current snapshot while the next one is built in the background
+type serveSnapshot struct {
+	ADRs        []*ADR
+	Errors      []error
+	Pages       map[string][]byte
+	ETags       map[string]string
+	Built       time.Time
+	Fingerprint string
+	// Records are the records by the page showing them
+	Records map[string]*ADR
+	// Updated is when the records last changed, kept for the personal view
+	Updated map[*ADR]time.Time
+}
+
+type server struct {
+	dir        string
+	current    atomic.Value
+	rebuilding int32
+	mu         sync.Mutex
+	// views counts the record pages read, nil unless analytics.views is set
+	views *pageViews
+	// teams are the teams of the personal view by name
+	teams map[string]teamConfig
+}
+
+var serveTemplates = template.Must(template.New("page").Funcs(template.FuncMap{
+	"join":  templateFuncs["join"],
+	"title": templateFuncs["title"],
+	"label": recordLabel,
+	"page":  renderedPath,
+	// the printed page links to the published record
+	"permalink": siteLink,
+	"qr":        qrSVG,
+	// replaced by the snapshot, freshness depends on the whole catalog
+	"freshness": func(a *ADR) Freshness { return Freshness{} },
+}).Parse(servePageTemplate))
+
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	listen := fs.String("listen", ":8080", "address to listen on")
+	dir := fs.String("dir", settings.Dir, "directory containing ADR files")
+	poll := fs.Duration("poll", 2*time.Second, "interval for checking the files and git HEAD for changes")
+	rate := fs.Float64("rate", 10, "requests per second allowed per client IP address or known token, 0 disables rate limiting")
+	burst := fs.Int("burst", 40, "requests a client may make at once before -rate applies")
+	maxBody := fs.Int64("max-body", 1<<20, "maximum request body size in bytes")
+	fs.Parse(args)
+	if *rate > 0 && *burst < 1 {
+		return fmt.Errorf("-burst must be at least 1 with a -rate, %d would refuse every request", *burst)
+	}
+
+	teams, err := loadTeams(cfg.Personal.Teams)
+	if err != nil {
+		return err
+	}
+	s := &server{dir: *dir, teams: teams}
+	err = s.rebuild()
+	if err != nil {
+		return err
+	}
+	go s.watch(*poll)
+
+	if cfg.Analytics.Views != "" {
+		s.views, err = loadPageViews(cfg.Analytics.Views)
+		if err != nil {
+			return err
+		}
+		go func() {
+			for now := range time.Tick(time.Minute) {
+				err := s.views.save(now, cfg.Analytics.retain())
+				if err != nil {
+					log.Printf("Could not save the view counts: %s", err)
+				}
+			}
+		}()
+	}
+
+	// clients with a token of the serve credential are limited per token
+	tokens, err := resolveCredential("serve", "ADR_SERVE_TOKENS")
+	if err != nil {
+		return err
+	}
+	limits := requestLimits{Rate: *rate, Burst: *burst, MaxBody: *maxBody}
+	if tokens != "" {
+		limits.Tokens = parseCommaList(tokens)
+	}
+	handler := limitRequests(s.routes(), limits)
+	accessLog, err := openAccessLog(cfg.Analytics.AccessLog)
+	if err != nil {
+		return err
+	}
+	if accessLog != nil {
+		handler = logAccess(handler, accessLog, cfg.Analytics.KeepAddresses)
+	}
+
+	// the spans of the rebuilds are exported while serving, the command span
+	// once a signal stops the server
+	exportPeriodically(exportInterval)
+	srv := &http.Server{Addr: *listen, Handler: handler}
+	stop := make(chan os.Signal, 1)
+	stopped := make(chan struct{})
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		defer close(stopped)
+		sig := <-stop
+		log.Printf("Stopping on %s", sig)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+	}()
+
+	log.Printf("Serving %s on %s", *dir, *listen)
+	err = srv.ListenAndServe()
+	if err != http.ErrServerClosed {
+		return err
+	}
+	// requests in flight finish first
+	<-stopped
+
+	return nil
+}
+
+func (s *server) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/records", s.handleRecords)
+	mux.HandleFunc("/api/switcher", s.handleSwitcher)
+	mux.HandleFunc("/api/views", s.handleViews)
+	mux.HandleFunc("/api/me", s.handleMeAPI)
+	mux.HandleFunc("/me", s.handleMe)
+	mux.HandleFunc("/switcher.js", handleScript)
+	mux.HandleFunc("/api/validate", s.handleValidate)
+	mux.HandleFunc("/api/preview", s.handlePreviewAPI)
+	mux.HandleFunc("/preview", s.handlePreview)
+	mux.HandleFunc("/readme", s.handleReadme)
+	mux.HandleFunc("/readme/", s.handleReadme)
+	mux.HandleFunc("/", s.handlePage)
+
+	return mux
+}
+
+func (s *server) snapshot() *serveSnapshot {
+	return s.current.Load().(*serveSnapshot)
+}
+
+// watch polls for changes, a git pull moves HEAD and edits change the file
+// sizes or modification times, either triggers a rebuild
+func (s *server) watch(interval time.Duration) {
+	for range time.Tick(interval) {
+		fp, err := catalogFingerprint(includeDirs(s.dir)...)
+		if err != nil {
+			log.Printf("Could not check %s for changes: %s", s.dir, err)
+			continue
+		}
+		if fp != s.snapshot().Fingerprint {
+			s.rebuildAsync()
+		}
+	}
+}
+
+// rebuildAsync starts a rebuild unless one is running, requests keep being
+// served from the stale snapshot until it is replaced
+func (s *server) rebuildAsync() {
+	if !atomic.CompareAndSwapInt32(&s.rebuilding, 0, 1) {
+		return
+	}
+
+	go func() {
+		defer atomic.StoreInt32(&s.rebuilding, 0)
+		err := s.rebuild()
+		if err != nil {
+			log.Printf("Rebuild failed, serving the previous catalog: %s", err)
+		}
+	}()
+}
+
+func (s *server) rebuild() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	start := time.Now()
+	fp, err := catalogFingerprint(includeDirs(s.dir)...)
+	if err != nil {
+		return err
+	}
+
+	adrs, errs, err := scanADRs(s.dir)
+	if err != nil {
+		return err
+	}
+	adrs, errs, err = withIncludes(adrs, errs)
+	if err != nil {
+		return err
+	}
+	inherited, errs, err := withInherited(adrs, errs)
+	if err != nil {
+		return err
+	}
+	adrs = searchADRs(settings.Filter.apply(adrs), "")
+
+	snap := &serveSnapshot{ADRs: adrs, Errors: errs, Pages: map[string][]byte{}, Records: map[string]*ADR{}, Updated: map[*ADR]time.Time{}, ETags: map[string]string{}, Built: time.Now(), Fingerprint: fp}
+
+	if cfg.Personal.enabled() {
+		for _, a := range adrs {
+			snap.Updated[a] = recordUpdated(a, true)
+		}
+	}
+
+	records, sections := typeSections(adrs)
+	err = snap.render("", serveIndexTemplate, struct {
+		Title     string
+		Tags      []TagADRs
+		Sections  []TypeSection
+		Inherited []*ADR
+		Errors    []error
+		Built     time.Time
+		Personal  bool
+	}{"Architecture Decision Records", groupByTag(records), sections, inherited, errs, snap.Built, cfg.Personal.enabled()})
+	if err != nil {
+		return err
+	}
+
+	for _, a := range adrs {
+		body, err := ioutil.ReadFile(a.Meta.Path)
+		if err != nil {
+			return err
+		}
+
+		err = snap.render(renderedPath(a), serveRecordTemplate, recordPage(a, body))
+		if err != nil {
+			return err
+		}
+		snap.Records[renderedPath(a)] = a
+	}
+
+	s.renderIndexTemplates(snap, adrs)
+
+	list, err := json.Marshal(adrs)
+	if err != nil {
+		return err
+	}
+	snap.store("api/records", list)
+	var yml bytes.Buffer
+	err = encodeValue(&yml, "yaml", adrs)
+	if err != nil {
+		return err
+	}
+	snap.store("api/records.yaml", yml.Bytes())
+	entries := []switcherEntry{}
+	for _, a := range adrs {
+		entries = append(entries, switcherEntry{Label: recordLabel(a), Title: a.Heading, Status: a.Meta.Status, Tags: append([]string{}, a.Meta.Tags...), URL: "/" + renderedPath(a)})
+	}
+	switcher, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	snap.store("api/switcher", switcher)
+
+	s.current.Store(snap)
+	log.Printf("Built %d records in %s", len(adrs), time.Since(start).Round(time.Millisecond))
+
+	return nil
+}
+
+// renderIndexTemplates renders the index template of the catalog at /readme
+// and those of the profiles at /readme/<profile>, they may be written by any
+// team sharing the instance so they run sandboxed and a failing one only
+// takes its own page down
+func (s *server) renderIndexTemplates(snap *serveSnapshot, adrs []*ADR) {
+	templates := map[string]Profile{"readme": settings}
+	for name := range cfg.Profiles {
+		p, err := cfg.profile(name)
+		if err == nil && p.Template != "" {
+			templates["readme/"+name] = p
+		}
+	}
+
+	for page, p := range templates {
+		if _, err := os.Stat(p.Template); err != nil {
+			continue
+		}
+		var buf bytes.Buffer
+		err := renderIndexesWith(p.Filter.apply(adrs), p.Template, &buf, renderOptions{Limits: cfg.Sandbox.limits()})
+		if err != nil {
+			log.Printf("Could not render %s for /%s: %s", p.Template, page, err)
+			buf.Reset()
+			fmt.Fprintf(&buf, "%s could not be rendered: %s\n", p.Template, err)
+		}
+		snap.store(page, buf.Bytes())
+	}
+}
+
+func (snap *serveSnapshot) render(page string, content string, data interface{}) error {
+	body, err := snap.execute(content, data)
+	if err != nil {
+		return fmt.Errorf("rendering %s: %s", page, err)
+	}
+	snap.store(page, body)
+
+	return nil
+}
+
+// execute renders content within the page layout against the snapshot
+func (snap *serveSnapshot) execute(content string, data interface{}) ([]byte, error) {
+	t, err := serveTemplates.Clone()
+	if err != nil {
+		return nil, err
+	}
+	t.Funcs(template.FuncMap{"freshness": func(a *ADR) Freshness {
+		return assessFreshness(a, snap.ADRs, snap.Built)
+	}})
+	_, err = t.Parse(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	err = t.Execute(&buf, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// recordPageData is the data of the record template
+type recordPageData struct {
+	ADR      *ADR
+	Title    string
+	Sections []Section
+}
+
+func recordPage(a *ADR, body []byte) recordPageData {
+	return recordPageData{a, recordLabel(a) + " " + a.Heading, splitSections(string(body))}
+}
+
+func (snap *serveSnapshot) store(page string, body []byte) {
+	sum := sha1.Sum(body)
+	snap.Pages[page] = body
+	snap.ETags[page] = `"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// serveCached answers from the snapshot with an ETag, browsers and proxies may
+// keep using their copy while they revalidate in the background
+func serveCached(w http.ResponseWriter, r *http.Request, snap *serveSnapshot, page string, contentType string) {
+	body, ok := snap.Pages[page]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	etag := snap.ETags[page]
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache, stale-while-revalidate=60")
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Write(body)
+}
+
+// handleReadme answers the rendered index templates as text, they are
+// AsciiDoc or Markdown
+func (s *server) handleReadme(w http.ResponseWriter, r *http.Request) {
+	serveCached(w, r, s.snapshot(), strings.TrimPrefix(r.URL.Path, "/"), "text/plain; charset=utf-8")
+}
+
+func (s *server) handlePage(w http.ResponseWriter, r *http.Request) {
+	page := strings.TrimPrefix(r.URL.Path, "/")
+	if page == "index.html" {
+		page = ""
+	}
+
+	snap := s.snapshot()
+	serveCached(w, r, snap, page, "text/html; charset=utf-8")
+	if a, ok := snap.Records[page]; ok && s.views != nil && countsView(r) {
+		s.views.record(recordLabel(a), time.Now())
+	}
+}
+
+// handleViews answers the records by their views of the last days, 30 unless
+// ?days= asks for another period
+func (s *server) handleViews(w http.ResponseWriter, r *http.Request) {
+	if s.views == nil {
+		http.Error(w, "view counts are disabled, set analytics.views in "+configFile, http.StatusNotFound)
+		return
+	}
+	days := 30
+	if d := r.URL.Query().Get("days"); d != "" {
+		n, err := strconv.Atoi(d)
+		if err != nil || n < 1 {
+			http.Error(w, "invalid days "+strconv.Quote(d), http.StatusBadRequest)
+			return
+		}
+		days = n
+	}
+
+	writeResult(w, r, http.StatusOK, mostRead(s.snapshot().ADRs, s.views.since(time.Now().AddDate(0, 0, 1-days))))
+}
+
+func (s *server) handleRecords(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Vary", "Accept")
+	if wantsYAML(r) {
+		serveCached(w, r, s.snapshot(), "api/records.yaml", "application/yaml")
+		return
+	}
+	serveCached(w, r, s.snapshot(), "api/records", "application/json")
+}
+
+// switcherEntry is a record as the quick switcher of the pages searches it
+type switcherEntry struct {
+	Label  string   `json:"label"`
+	Title  string   `json:"title"`
+	Status string   `json:"status"`
+	Tags   []string `json:"tags"`
+	URL    string   `json:"url"`
+}
+
+func (s *server) handleSwitcher(w http.ResponseWriter, r *http.Request) {
+	serveCached(w, r, s.snapshot(), "api/switcher", "application/json")
+}
+
+func handleScript(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+	w.Write([]byte(serveScript))
+}
+
+// catalogFingerprint summarizes names, sizes and modification times of the
+// files below dirs together with their git HEAD without parsing anything
+func catalogFingerprint(dirs ...string) (string, error) {
+	h := sha1.New()
+	for _, dir := range dirs {
+		err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(h, "%s %d %d\n", p, info.Size(), info.ModTime().UnixNano())
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+
+		head, _ := git("-C", dir, "rev-parse", "HEAD")
+		fmt.Fprintf(h, "HEAD %s\n", head)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// validation is the answer of POST /api/validate, Valid is false when there
+// is at least one error finding
+type validation struct {
+	Valid    bool      `json:"valid"`
+	File     string    `json:"file"`
+	Record   *ADR      `json:"record,omitempty"`
+	Findings []Finding `json:"findings"`
+}
+
+// readDraft reads a posted record, the name query parameter is the file name
+// the draft would be stored under, it decides the record type and index
+func (s *server) readDraft(w http.ResponseWriter, r *http.Request) (string, []byte, bool) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "POST a record", http.StatusMethodNotAllowed)
+		return "", nil, false
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "invalid body: "+err.Error(), http.StatusRequestEntityTooLarge)
+		return "", nil, false
+	}
+
+	file, err := s.draftPath(r.URL.Query().Get("name"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return "", nil, false
+	}
+
+	return file, body, true
+}
+
+// draftPath places a draft named name in the served directory, an empty name
+// is the next free ADR
+func (s *server) draftPath(name string) (string, error) {
+	if name == "" {
+		t := typeByName("")
+		name = t.fileName(nextIndex(s.snapshot().ADRs, t), "draft")
+	}
+	if name != path.Base(name) || !isRecordFile(name) {
+		return "", fmt.Errorf("name must be a record file name ending in .adoc or .md")
+	}
+
+	return path.Join(s.dir, name), nil
+}
+
+func (s *server) handleValidate(w http.ResponseWriter, r *http.Request) {
+	file, body, ok := s.readDraft(w, r)
+	if !ok {
+		return
+	}
+
+	writeResult(w, r, http.StatusOK, s.validateDraft(file, body))
+}
+
+// validateDraft checks a posted record against the served catalog, a shared
+// index is only a warning as the draft may not be merged as is
+func (s *server) validateDraft(file string, body []byte) validation {
+	adrs := s.snapshot().ADRs
+	v := validateRecord(file, body, adrs, severityWarning)
+	catalogHints(&v, adrs)
+
+	return v
+}
+
+// handlePreviewAPI answers a posted draft with its record page, drafts that
+// cannot be read at all get their findings as JSON
+func (s *server) handlePreviewAPI(w http.ResponseWriter, r *http.Request) {
+	file, body, ok := s.readDraft(w, r)
+	if !ok {
+		return
+	}
+
+	v := s.validateDraft(file, body)
+	if v.Record == nil {
+		writeResult(w, r, http.StatusUnprocessableEntity, v)
+		return
+	}
+
+	page, err := s.snapshot().execute(serveRecordTemplate, recordPage(v.Record, body))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(page)
+}
+
+// handlePreview is a form around /api/preview for browsers, it works without
+// scripts as the content security policy forbids inline ones
+func (s *server) handlePreview(w http.ResponseWriter, r *http.Request) {
+	data := struct {
+		Title    string
+		Name     string
+		Content  string
+		Findings []Finding
+		Page     *recordPageData
+	}{Title: "Preview"}
+
+	if r.Method == http.MethodPost {
+		err := r.ParseForm()
+		if err != nil {
+			http.Error(w, "invalid form: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		data.Name = r.PostForm.Get("name")
+		data.Content = strings.Replace(r.PostForm.Get("content"), "\r\n", "\n", -1)
+
+		file, err := s.draftPath(data.Name)
+		if err != nil {
+			data.Findings = []Finding{{Severity: severityError, Rule: "invalid-filename", Message: err.Error()}}
+		} else {
+			v := s.validateDraft(file, []byte(data.Content))
+			data.Findings = v.Findings
+			if v.Record != nil {
+				page := recordPage(v.Record, []byte(data.Content))
+				data.Page = &page
+			}
+		}
+	}
+
+	page, err := s.snapshot().execute(servePreviewTemplate, data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(page)
+}
+
+// wantsYAML is set by ?format=yaml or an Accept header asking for yaml
+func wantsYAML(r *http.Request) bool {
+	return r.URL.Query().Get("format") == "yaml" || strings.Contains(r.Header.Get("Accept"), "yaml")
+}
+
+// writeResult answers with v as json, or as yaml when the request asks for it
+func writeResult(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+	format, contentType := "json", "application/json"
+	if wantsYAML(r) {
+		format, contentType = "yaml", "application/yaml"
+	}
+
+	var body bytes.Buffer
+	err := encodeValue(&body, format, v)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("encoding response: %s", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	w.Write(body.Bytes())
+}
@@ -0,0 +1,426 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// siteStyle is written to style.css of the site
+const siteStyle = `body { font-family: sans-serif; max-width: 60em; margin: 0 auto; padding: 0 1em 2em; line-height: 1.45; }
+nav { display: flex; flex-wrap: wrap; gap: 1em; align-items: center; padding: .8em 0; border-bottom: 1px solid #ddd; margin-bottom: 1em; }
+nav form { margin-left: auto; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+td, th { border: 1px solid #ccc; padding: .3em .6em; text-align: left; vertical-align: top; }
+th { background: #f4f4f4; }
+pre { background: #f6f8fa; padding: .6em; overflow-x: auto; }
+code { background: #f6f8fa; padding: 0 .2em; }
+.admonition { border-left: 4px solid #4a90d9; padding-left: .6em; }
+.pager { display: flex; justify-content: space-between; border-top: 1px solid #ddd; padding-top: .8em; margin-top: 2em; }
+.badges a { margin-right: .6em; }
+.print-footer { display: none; }
+@media print {
+  @page { margin: 2cm; }
+  body { font-family: Georgia, "Times New Roman", serif; font-size: 11pt; line-height: 1.4; max-width: none; padding: 0; }
+  nav, .pager, .noprint { display: none; }
+  h1 { font-size: 18pt; margin: 0 0 .4em; }
+  h2, h3, h4 { break-after: avoid; }
+  pre, table, .admonition { break-inside: avoid-page; }
+  pre, code { background: none; }
+  a { color: inherit; text-decoration: none; }
+  table.meta { width: 100%; border-top: 2px solid #000; border-bottom: 2px solid #000; }
+  table.meta th, table.meta td { border: none; background: none; padding: .1em .6em .1em 0; }
+  .print-footer { display: flex; gap: 1em; align-items: center; border-top: 1px solid #000; margin-top: 2em; padding-top: .6em; font-size: 9pt; break-inside: avoid; }
+  .print-footer svg { width: 2.5cm; height: 2.5cm; }
+}
+`
+
+const siteTemplates = `{{define "layout"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}} - {{.Site}}</title>
+<link rel="stylesheet" href="{{.Root}}style.css">
+<link rel="alternate" type="application/atom+xml" title="{{.Site}}" href="{{.Root}}adrs.xml">
+</head>
+<body>
+<nav>
+<a href="{{.Root}}index.html"><strong>{{.Site}}</strong></a>
+<a href="{{.Root}}tags.html">Tags</a>
+<a href="{{.Root}}statuses.html">Statuses</a>
+<form action="{{.Root}}index.html" method="get"><input type="search" name="q" placeholder="Search decisions" aria-label="Search decisions"></form>
+</nav>
+{{template "content" .}}
+</body>
+</html>
+{{end}}
+{{define "records"}}
+<table class="records">
+<thead><tr><th>Index</th><th>Title</th><th>Status</th><th>Tags</th><th>Date</th></tr></thead>
+<tbody>
+{{- range .Records}}
+<tr data-search="{{.Search}}"><td><a href="{{$.Root}}{{.Page}}">{{.Label}}</a></td><td>{{.ADR.Heading}}</td><td><a href="{{$.Root}}{{statusPage .ADR.Meta.Status}}">{{.ADR.Meta.Status}}</a></td><td class="badges">{{range .ADR.Meta.Tags}}<a href="{{$.Root}}{{tagPage .}}">{{.}}</a>{{end}}</td><td>{{date .ADR.Meta.Date}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{end}}
+{{define "index"}}{{template "layout" .}}{{end}}
+{{define "list"}}{{template "layout" .}}{{end}}
+{{define "groups"}}{{template "layout" .}}{{end}}
+{{define "record"}}{{template "layout" .}}{{end}}`
+
+const siteIndexContent = `{{define "content"}}
+<h1>{{.Title}}</h1>
+<p id="matches"></p>
+{{template "records" .}}
+<script>
+(function () {
+  var q = new URLSearchParams(location.search).get("q") || "";
+  var input = document.querySelector("nav input[name=q]");
+  var filter = function () {
+    var words = input.value.toLowerCase().split(/\s+/).filter(Boolean), shown = 0;
+    document.querySelectorAll("table.records tbody tr").forEach(function (row) {
+      var text = row.dataset.search;
+      var match = words.every(function (w) { return text.indexOf(w) >= 0; });
+      row.hidden = !match;
+      if (match) { shown++; }
+    });
+    document.getElementById("matches").textContent = words.length ? shown + " matching decisions" : "";
+  };
+  input.value = q;
+  input.addEventListener("input", filter);
+  input.form.addEventListener("submit", function (e) { e.preventDefault(); filter(); });
+  filter();
+})();
+</script>
+{{end}}`
+
+const siteListContent = `{{define "content"}}
+<h1>{{.Title}}</h1>
+{{template "records" .}}
+{{end}}`
+
+const siteGroupsContent = `{{define "content"}}
+<h1>{{.Title}}</h1>
+<ul>
+{{- range .Groups}}
+<li><a href="{{$.Root}}{{.Page}}">{{.Name}}</a> ({{.Count}})</li>
+{{- end}}
+</ul>
+{{end}}`
+
+const siteRecordContent = `{{define "content"}}
+{{- $r := .Record}}
+<h1>{{$r.Label}} {{$r.ADR.Heading}}</h1>
+<p class="noprint"><button type="button" onclick="window.print()">Print</button></p>
+<table class="meta">
+<tr><th>Date</th><td>{{date $r.ADR.Meta.Date}}</td></tr>
+<tr><th>Author</th><td>{{join $r.ADR.Meta.Authors}}</td></tr>
+<tr><th>Status</th><td><a href="{{.Root}}{{statusPage $r.ADR.Meta.Status}}">{{$r.ADR.Meta.Status}}</a></td></tr>
+<tr><th>Tags</th><td class="badges">{{range $r.ADR.Meta.Tags}}<a href="{{$.Root}}{{tagPage .}}">{{.}}</a>{{end}}</td></tr>
+{{- with $r.ADR.Meta.Impact}}
+<tr><th>Impact</th><td>{{.}}</td></tr>
+{{- end}}
+{{- if not $r.ADR.Meta.Reviewed.IsZero}}
+<tr><th>Reviewed</th><td>{{date $r.ADR.Meta.Reviewed}}</td></tr>
+{{- end}}
+{{- range $key, $value := $r.ADR.Meta.Extra}}
+<tr><th>{{$key}}</th><td>{{$value}}</td></tr>
+{{- end}}
+{{- with $r.Supersedes}}
+<tr><th>Supersedes</th><td>{{range .}}<a href="{{$.Root}}{{.Page}}">{{.Label}}</a> {{end}}</td></tr>
+{{- end}}
+{{- with $r.SupersededBy}}
+<tr><th>Superseded by</th><td>{{range .}}<a href="{{$.Root}}{{.Page}}">{{.Label}}</a> {{end}}</td></tr>
+{{- end}}
+{{- with $r.ConflictsWith}}
+<tr><th>Conflicts with</th><td>{{range .}}<a href="{{$.Root}}{{.Page}}">{{.Label}}</a> {{end}}</td></tr>
+{{- end}}
+</table>
+{{- range $r.Sections}}
+{{- if .Title}}
+<h{{.Level}}>{{.Title}}</h{{.Level}}>
+{{- end}}
+{{.Body}}
+{{- end}}
+<footer class="print-footer">
+{{- with permalink $r.ADR}}
+{{qr .}}
+{{- end}}
+<p>{{$r.Label}} {{$r.ADR.Heading}}, {{$r.ADR.Meta.Status}}<br>{{or (permalink $r.ADR) $r.Page}}</p>
+</footer>
+<div class="pager">
+<span>{{with .Previous}}&larr; <a href="{{$.Root}}{{.Page}}">{{.Label}} {{.ADR.Heading}}</a>{{end}}</span>
+<span>{{with .Next}}<a href="{{$.Root}}{{.Page}}">{{.Label}} {{.ADR.Heading}}</a> &rarr;{{end}}</span>
+</div>
+{{end}}`
+
+// sitePage is the data of every site template, Root leads from the page back
+// to the root of the site
+type sitePage struct {
+	Site     string
+	Title    string
+	Root     string
+	Records  []*siteRecord
+	Groups   []siteGroup
+	Record   *siteRecord
+	Previous *siteRecord
+	Next     *siteRecord
+}
+
+type siteRecord struct {
+	ADR           *ADR
+	Label         string
+	Page          string
+	Search        string
+	Sections      []siteSection
+	Supersedes    []*siteRecord
+	SupersededBy  []*siteRecord
+	ConflictsWith []*siteRecord
+}
+
+type siteSection struct {
+	Title string
+	Level int
+	Body  template.HTML
+}
+
+type siteGroup struct {
+	Name  string
+	Page  string
+	Count int
+}
+
+// runSite renders the catalog as a static site, a page per record at the
+// path siteURL links expect plus an index with search and pages per tag and
+// per status
+func runSite(args []string) error {
+	fs := flag.NewFlagSet("site", flag.ExitOnError)
+	dir := fs.String("dir", settings.Dir, "directory containing ADR files")
+	output := fs.String("output", "site", "directory to write the site to")
+	title := fs.String("title", "Architecture Decision Records", "title of the site")
+	fs.Parse(args)
+
+	adrs, err := loadADRs(*dir)
+	if err != nil {
+		return err
+	}
+	adrs = searchADRs(settings.Filter.apply(adrs), "")
+
+	records, err := siteRecords(adrs)
+	if err != nil {
+		return err
+	}
+
+	tmpl := template.Must(template.New("site").Funcs(template.FuncMap{
+		"join":       templateFuncs["join"],
+		"date":       func(t interface{ Format(string) string }) string { return t.Format(dateLayout) },
+		"tagPage":    siteTagPage,
+		"statusPage": siteStatusPage,
+		"permalink":  siteLink,
+		"qr":         qrSVG,
+	}).Parse(siteTemplates))
+
+	artifacts := map[string]string{}
+	write := func(page string, content string, data sitePage) error {
+		t, err := template.Must(tmpl.Clone()).Parse(content)
+		if err != nil {
+			return err
+		}
+		data.Site = *title
+		data.Root = strings.Repeat("../", strings.Count(page, "/"))
+
+		file := filepath.Join(*output, filepath.FromSlash(page))
+		err = os.MkdirAll(filepath.Dir(file), 0755)
+		if err != nil {
+			return err
+		}
+		artifacts[file] = "html"
+		return writeOutput(file, func(w io.Writer) error {
+			return t.ExecuteTemplate(w, "layout", data)
+		})
+	}
+
+	err = os.MkdirAll(*output, 0755)
+	if err != nil {
+		return err
+	}
+	style := filepath.Join(*output, "style.css")
+	err = ioutil.WriteFile(style, []byte(siteStyle), 0644)
+	if err != nil {
+		return err
+	}
+	artifacts[style] = "css"
+
+	err = write("index.html", siteIndexContent, sitePage{Title: *title, Records: records})
+	if err != nil {
+		return err
+	}
+	for i, r := range records {
+		page := sitePage{Title: r.Label + " " + r.ADR.Heading, Record: r}
+		if i > 0 {
+			page.Previous = records[i-1]
+		}
+		if i < len(records)-1 {
+			page.Next = records[i+1]
+		}
+		err = write(r.Page, siteRecordContent, page)
+		if err != nil {
+			return err
+		}
+	}
+
+	groups := func(name func(a *ADR) []string, pageOf func(string) string, list string, heading string) error {
+		members := map[string][]*siteRecord{}
+		for _, r := range records {
+			for _, n := range name(r.ADR) {
+				members[n] = append(members[n], r)
+			}
+		}
+		names := []string{}
+		for n := range members {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+
+		found := []siteGroup{}
+		for _, n := range names {
+			page := pageOf(n)
+			found = append(found, siteGroup{Name: n, Page: page, Count: len(members[n])})
+			err := write(page, siteListContent, sitePage{Title: fmt.Sprintf("%s %s", heading, n), Records: members[n]})
+			if err != nil {
+				return err
+			}
+		}
+
+		return write(list, siteGroupsContent, sitePage{Title: heading + "s", Groups: found})
+	}
+	err = groups(func(a *ADR) []string { return a.Meta.Tags }, siteTagPage, "tags.html", "Tag")
+	if err != nil {
+		return err
+	}
+	err = groups(func(a *ADR) []string { return []string{a.Meta.Status} }, siteStatusPage, "statuses.html", "Status")
+	if err != nil {
+		return err
+	}
+
+	feed := filepath.Join(*output, feedFile)
+	err = writeOutput(feed, func(w io.Writer) error {
+		return writeFeed(w, adrs, *title, 20, true)
+	})
+	if err != nil {
+		return err
+	}
+	artifacts[feed] = "atom"
+
+	manifest := filepath.Join(*output, manifestFile)
+	err = writeManifest(manifest, adrs, artifacts)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %d records and %d pages to %s\n", len(records), len(artifacts), *output)
+
+	return nil
+}
+
+// siteRecords prepares the pages of the records in index order, the sections
+// are rendered and references to other records link to their pages
+func siteRecords(adrs []*ADR) ([]*siteRecord, error) {
+	sorted := append([]*ADR{}, adrs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Meta.Type != sorted[j].Meta.Type {
+			return sorted[i].Meta.Type < sorted[j].Meta.Type
+		}
+		return sorted[i].Meta.Index < sorted[j].Meta.Index
+	})
+
+	byADR := map[*ADR]*siteRecord{}
+	byFile := map[string]*siteRecord{}
+	records := []*siteRecord{}
+	for _, a := range sorted {
+		r := &siteRecord{ADR: a, Label: recordLabel(a), Page: sitePagePath(a)}
+		byADR[a] = r
+		byFile[path.Base(a.Meta.Path)] = r
+		records = append(records, r)
+	}
+	resolve := func(refs []string) []*siteRecord {
+		found := []*siteRecord{}
+		for _, ref := range refs {
+			if a, err := resolveRecord(adrs, ref); err == nil {
+				found = append(found, byADR[a])
+			}
+		}
+		return found
+	}
+
+	for _, r := range records {
+		body, err := ioutil.ReadFile(r.ADR.Meta.Path)
+		if err != nil {
+			return nil, err
+		}
+		r.Supersedes = resolve(r.ADR.Meta.Supersedes)
+		r.SupersededBy = resolve(r.ADR.Meta.SupersededBy)
+		r.ConflictsWith = resolve(r.ADR.Meta.ConflictsWith)
+
+		root := strings.Repeat("../", strings.Count(r.Page, "/"))
+		m := markupRenderer{
+			link: func(target string) string {
+				if to, ok := byFile[path.Base(target)]; ok && !strings.Contains(target, "://") {
+					return root + to.Page
+				}
+				return target
+			},
+			ref: func(label string) string {
+				if a, err := resolveRecord(adrs, label); err == nil {
+					return root + byADR[a].Page
+				}
+				return ""
+			},
+		}
+
+		text := []string{r.Label, r.ADR.Heading, r.ADR.Meta.Status, strings.Join(r.ADR.Meta.Tags, " "), strings.Join(r.ADR.Meta.Authors, " ")}
+		for _, s := range splitSections(string(body)) {
+			if s.Title == "" && s.Body == "" {
+				continue
+			}
+			// the record title is the h1 of the page
+			r.Sections = append(r.Sections, siteSection{Title: s.Title, Level: minInt(s.Level+1, 6), Body: m.render(s.Body)})
+			text = append(text, s.Title, s.Body)
+		}
+		r.Search = strings.ToLower(strings.Join(strings.Fields(strings.Join(text, " ")), " "))
+	}
+
+	return records, nil
+}
+
+// sitePagePath is the page of a record relative to the site root, it matches
+// the renderedPath siteURL links point at
+func sitePagePath(a *ADR) string {
+	page := filepath.ToSlash(renderedPath(a))
+	if filepath.IsAbs(page) || strings.HasPrefix(page, "../") {
+		page = path.Join(path.Base(path.Dir(page)), path.Base(page))
+	}
+
+	return strings.TrimPrefix(page, "./")
+}
+
+func siteTagPage(tag string) string {
+	return "tags/" + slugify(tag) + ".html"
+}
+
+func siteStatusPage(status string) string {
+	slug := slugify(status)
+	if slug == "" {
+		slug = "none"
+	}
+
+	return "status/" + slug + ".html"
+}
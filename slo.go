@@ -0,0 +1,177 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// SLO is a decision latency objective, records may spend at most Days in the
+// From statuses, e.g. proposals decided within 30 days
+type SLO struct {
+	Name string `yaml:"name"`
+	// From are the statuses the clock runs in, the pending ones when empty
+	From []string `yaml:"from"`
+	Days int      `yaml:"days"`
+	// Tags restrict the objective to records carrying one of them
+	Tags []string `yaml:"tags"`
+}
+
+func (s SLO) String() string {
+	if s.Name != "" {
+		return s.Name
+	}
+
+	return fmt.Sprintf("%s within %d days", strings.Join(s.statuses(), ", "), s.Days)
+}
+
+func (s SLO) statuses() []string {
+	if len(s.From) > 0 {
+		return s.From
+	}
+
+	return statusesIn(lifecyclePending)
+}
+
+func (s SLO) applies(a *ADR) bool {
+	return Filter{Tags: s.Tags}.match(a)
+}
+
+// checkSLOs rejects objectives that can never be met or missed
+func checkSLOs(slos []SLO) error {
+	for i, s := range slos {
+		if s.Days <= 0 {
+			return fmt.Errorf("invalid days %d of slo %d %q, expected a positive number in %s", s.Days, i+1, s.String(), configFile)
+		}
+	}
+
+	return nil
+}
+
+// sloResult is the time one record spent in the statuses of an SLO, Decided is
+// nil while it is still in them
+type sloResult struct {
+	SLO     string     `json:"slo"`
+	Record  string     `json:"record"`
+	Title   string     `json:"title"`
+	Status  string     `json:"status"`
+	Since   time.Time  `json:"since"`
+	Decided *time.Time `json:"decided,omitempty"`
+	Days    float64    `json:"days"`
+	// State is met, open, late when it was decided past the objective and
+	// overdue when it is still open past it
+	State string `json:"state"`
+}
+
+func (r sloResult) violated() bool {
+	return r.State == "late" || r.State == "overdue"
+}
+
+// runSLO measures the records against the configured decision latency
+// objectives and fails when any is violated, for CI
+func runSLO(args []string) error {
+	fs := flag.NewFlagSet("slo", flag.ExitOnError)
+	dir := fs.String("dir", settings.Dir, "directory containing ADR files")
+	all := fs.Bool("all", false, "list the records meeting the objectives too")
+	fs.Parse(args)
+
+	if len(cfg.SLOs) == 0 {
+		return fmt.Errorf("no slos configured in %s, e.g. slos: [{name: proposals decided within 30 days, days: 30}]", configFile)
+	}
+
+	adrs, err := loadADRs(*dir)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	results := []sloResult{}
+	violations := 0
+	for _, a := range searchADRs(settings.Filter.apply(adrs), "") {
+		timeline, err := statusTimeline("", a.Meta.Path)
+		if err != nil || len(timeline) == 0 {
+			// records git does not know yet count from their date
+			timeline = []statusChange{{Status: a.Meta.Status, Time: a.Meta.Date}}
+		}
+		for _, s := range cfg.SLOs {
+			if !s.applies(a) {
+				continue
+			}
+			r, ok := measureSLO(s, a, timeline, now)
+			if !ok {
+				continue
+			}
+			if r.violated() {
+				violations++
+			}
+			results = append(results, r)
+		}
+	}
+	setResults(results)
+
+	p := newPainter(os.Stdout)
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, r := range results {
+		if !*all && !r.violated() {
+			continue
+		}
+		state := p.ok(r.State)
+		if r.violated() {
+			state = p.error(r.State)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s since %s\t%s\n", state, r.Record, r.Title, p.status(r.Status), age(time.Duration(r.Days*24*float64(time.Hour))), r.Since.Format("2006-01-02"), r.SLO)
+	}
+	w.Flush()
+
+	if violations > 0 {
+		return fmt.Errorf("%d decision latency SLO violations", violations)
+	}
+	fmt.Printf("%d records meet %d SLOs\n", len(results), len(cfg.SLOs))
+
+	return nil
+}
+
+// measureSLO times the first stay of a in the statuses of s, false when the
+// record never was in them
+func measureSLO(s SLO, a *ADR, timeline []statusChange, now time.Time) (sloResult, bool) {
+	since, decided, ok := timeIn(timeline, func(status string) bool {
+		return containsFold(s.statuses(), status)
+	})
+	if since.IsZero() {
+		return sloResult{}, false
+	}
+
+	end := now
+	if ok {
+		end = decided
+	}
+	r := sloResult{
+		SLO:    s.String(),
+		Record: recordLabel(a),
+		Title:  a.Heading,
+		Status: a.Meta.Status,
+		Since:  since,
+		Days:   math.Round(end.Sub(since).Hours()/24*10) / 10,
+	}
+	if ok {
+		r.Decided = &decided
+	}
+
+	late := end.Sub(since) > time.Duration(s.Days)*24*time.Hour
+	switch {
+	case ok && late:
+		r.State = "late"
+	case ok:
+		r.State = "met"
+	case late:
+		r.State = "overdue"
+	default:
+		r.State = "open"
+	}
+
+	return r, true
+}
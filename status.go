@@ -0,0 +1,148 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"adr-index/analysis/adrref"
+)
+
+func runStatus(args []string) error {
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+	dir := fs.String("dir", settings.Dir, "directory containing ADR files")
+	fs.Parse(args)
+
+	adrs, errs, err := scanADRs(*dir)
+	if err != nil {
+		return err
+	}
+
+	if structuredOutput() {
+		setResults(statusResults(*dir, adrs, errs))
+	}
+	printStatus(os.Stdout, *dir, adrs, errs, time.Now())
+
+	return nil
+}
+
+// statusReport is the structured form of the status overview
+type statusReport struct {
+	Dir      string          `json:"dir"`
+	Valid    int             `json:"valid"`
+	Broken   int             `json:"broken"`
+	Statuses map[string]int  `json:"statuses"`
+	Newest   *adrref.Record  `json:"newest,omitempty"`
+	Pending  []adrref.Record `json:"pending"`
+	Invalid  []InvalidRecord `json:"invalid"`
+}
+
+func statusResults(dir string, adrs []*ADR, errs []error) statusReport {
+	r := statusReport{
+		Dir:      dir,
+		Valid:    len(adrs),
+		Broken:   len(errs),
+		Statuses: map[string]int{},
+		Pending:  []adrref.Record{},
+		Invalid:  invalidRecords(errs),
+	}
+	for _, s := range validStatus {
+		r.Statuses[s] = 0
+	}
+	for _, a := range adrs {
+		r.Statuses[a.Meta.Status]++
+	}
+	if a := newestRecord(adrs); a != nil {
+		newest := catalogRecord(a)
+		r.Newest = &newest
+	}
+	for _, a := range NewCatalog(searchADRs(adrs, "")).ByLifecycle(lifecyclePending) {
+		r.Pending = append(r.Pending, catalogRecord(a))
+	}
+
+	return r
+}
+
+func newestRecord(adrs []*ADR) *ADR {
+	var newest *ADR
+	for _, a := range adrs {
+		if newest == nil || a.Meta.Date.After(newest.Meta.Date) || (a.Meta.Date.Equal(newest.Meta.Date) && a.Meta.Index > newest.Meta.Index) {
+			newest = a
+		}
+	}
+
+	return newest
+}
+
+func printStatus(out io.Writer, dir string, adrs []*ADR, errs []error, now time.Time) {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	defer w.Flush()
+	p := newPainter(out)
+
+	broken := fmt.Sprintf("%d broken", len(errs))
+	if len(errs) > 0 {
+		broken = p.error(broken)
+	}
+	fmt.Fprintf(w, "Decision records in %s: %d valid, %s\n\n", dir, len(adrs), broken)
+
+	counts := map[string]int{}
+	for _, a := range adrs {
+		counts[a.Meta.Status]++
+	}
+
+	fmt.Fprintln(w, "By status")
+	for _, s := range validStatus {
+		fmt.Fprintf(w, "  %s\t%d\n", p.status(s), counts[s])
+		delete(counts, s)
+	}
+	// statuses only valid for other record types
+	for _, s := range sortedKeys(counts) {
+		label := s
+		if label == "" {
+			label = "(none)"
+		}
+		fmt.Fprintf(w, "  %s\t%d\n", p.status(label), counts[s])
+	}
+
+	if newest := newestRecord(adrs); newest != nil {
+		fmt.Fprintf(w, "\nNewest\t%s %s (%s, %s)\n", recordLabel(newest), newest.Heading, newest.Meta.Date.Format("2006-01-02"), p.status(newest.Meta.Status))
+	}
+
+	pending := NewCatalog(searchADRs(adrs, "")).ByLifecycle(lifecyclePending)
+
+	fmt.Fprintf(w, "\nPending proposals (%d)\n", len(pending))
+	for _, a := range pending {
+		fmt.Fprintf(w, "  %s\t%s\t%s\n", recordLabel(a), a.Heading, age(now.Sub(a.Meta.Date)))
+	}
+
+	fmt.Fprintf(w, "\nBroken validations (%d)\n", len(errs))
+	for _, e := range errs {
+		fmt.Fprintf(w, "  %s\n", p.error(e.Error()))
+	}
+}
+
+func age(d time.Duration) string {
+	days := int(d.Hours() / 24)
+	switch {
+	case days < 1:
+		return "today"
+	case days == 1:
+		return "1 day"
+	}
+
+	return fmt.Sprintf("%d days", days)
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+// runSupersede scaffolds the record replacing an existing one and links both,
+// the old record becomes Superseded and names its successor in place, the new
+// record lists what pointed at the old one as follow-up updates
+func runSupersede(args []string) error {
+	fs := flag.NewFlagSet("supersede", flag.ExitOnError)
+	dir := fs.String("dir", settings.Dir, "directory containing ADR files")
+	template := fs.String("template", "", "skeleton used for the new record, defaults to the skeleton of the record type")
+	author := fs.String("author", gitAuthor(), "comma separated authors of the new record")
+	tags := fs.String("tags", "", "comma separated tags of the new record, defaults to the tags of the superseded one")
+	src := fs.String("src", ".", "comma separated source trees to scan for references to the old record, empty to skip the scan")
+	noImpact := fs.Bool("no-impact", false, "leave the checklist of records, code and indexes pointing at the old record out of the new one")
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: supersede [flags] <old-index> \"<new title>\"")
+	}
+
+	old, err := resolveFromDir(*dir, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	t := typeByName(old.Meta.Type)
+	if !isValidStatusFor(t, "Superseded") {
+		return fmt.Errorf("%s records have no Superseded status, add it to the statuses of the type in %s", t.Name, configFile)
+	}
+	if isSuperseded(old) {
+		return fmt.Errorf("%s is already superseded in %s", recordLabel(old), old.Meta.Path)
+	}
+
+	body, err := ioutil.ReadFile(old.Meta.Path)
+	if err != nil {
+		return err
+	}
+	if *template == "" {
+		*template = t.Skeleton
+	}
+	newTags := old.Meta.Tags
+	if *tags != "" {
+		newTags = parseCommaList(*tags)
+	}
+
+	appendix := ""
+	if !*noImpact {
+		adrs, err := loadADRs(*dir)
+		if err != nil {
+			return err
+		}
+		items, err := analyzeImpact(old, adrs, sourceRoots(*src), *dir)
+		if err != nil {
+			return err
+		}
+		if len(items) > 0 {
+			appendix = followUpSection(old.Meta.Path, old, items)
+		}
+	}
+
+	target, err := createADR(*dir, scaffold{
+		Type:       t,
+		Title:      fs.Arg(1),
+		Authors:    parseCommaList(*author),
+		Tags:       newTags,
+		Status:     "Proposed",
+		Date:       time.Now(),
+		Template:   *template,
+		Supersedes: []string{recordLabel(old)},
+		Appendix:   appendix,
+	})
+	if err != nil {
+		return err
+	}
+	successor, err := parseADR(target)
+	if err != nil {
+		os.Remove(target)
+		return fmt.Errorf("scaffolded record does not validate, check %s: %s", *template, err)
+	}
+
+	content := setMetaValue(string(body), "Status", "Superseded")
+	content = ensureMetaRow(content, "Superseded by", strings.Join(append(old.Meta.SupersededBy, recordLabel(successor)), ", "))
+	err = writeOutput(old.Meta.Path, func(w io.Writer) error {
+		_, err := io.WriteString(w, content)
+		return err
+	})
+	if err != nil {
+		os.Remove(target)
+		return err
+	}
+
+	// both records are written, a catalog that no longer loads points at rows
+	// the metadata table could not take
+	if _, err := loadADRs(*dir); err != nil {
+		return fmt.Errorf("%s supersedes %s but the catalog does not validate: %s", target, old.Meta.Path, err)
+	}
+	fmt.Println(target)
+	fmt.Printf("Marked %s as superseded by %s\n", old.Meta.Path, recordLabel(successor))
+
+	return nil
+}
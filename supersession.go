@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// verifySupersessions checks the Supersedes and Superseded by rows between
+// local records, the referenced record must exist and name the record back,
+// references into inherited catalogs such as org:ADR-3 are left to
+// applyOverrides
+func verifySupersessions(adrs []*ADR) []error {
+	errs := []error{}
+	check := func(a *ADR, key string, refs []string, row string, back func(*ADR) []string, backRow string) {
+		for _, ref := range refs {
+			if strings.Contains(ref, ":") {
+				continue
+			}
+			target, err := resolveRecord(adrs, ref)
+			if err != nil {
+				errs = append(errs, &ErrInvalidReference{Path: a.Meta.Path, Key: key, Ref: ref, Reason: fmt.Sprintf("%s %s which does not exist", row, ref)})
+				continue
+			}
+			if target == a {
+				errs = append(errs, &ErrInvalidReference{Path: a.Meta.Path, Key: key, Ref: ref, Reason: fmt.Sprintf("%s itself", row)})
+				continue
+			}
+			if !refersTo(adrs, back(target), a) {
+				errs = append(errs, &ErrInvalidReference{
+					Path:   a.Meta.Path,
+					Key:    key,
+					Ref:    ref,
+					Reason: fmt.Sprintf("%s %s but %s has no %s row naming %s", row, ref, recordLabel(target), backRow, recordLabel(a)),
+					Target: target.Meta.Path,
+					Back:   backRow,
+					Label:  recordLabel(a),
+				})
+			}
+		}
+	}
+
+	for _, a := range adrs {
+		check(a, "Supersedes", a.Meta.Supersedes, "supersedes", func(t *ADR) []string { return t.Meta.SupersededBy }, "Superseded by")
+		check(a, "Superseded by", a.Meta.SupersededBy, "is superseded by", func(t *ADR) []string { return t.Meta.Supersedes }, "Supersedes")
+	}
+
+	return errs
+}
+
+// refersTo reports whether one of refs resolves to a
+func refersTo(adrs []*ADR, refs []string, a *ADR) bool {
+	for _, ref := range refs {
+		if r, err := resolveRecord(adrs, ref); err == nil && r == a {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/template"
+)
+
+// TagIndexConfig has build write an index per tag next to the main index,
+// large catalogs with many tags are easier to read a tag at a time
+type TagIndexConfig struct {
+	// Output names the index of a tag, a text/template receiving the Tag and
+	// its Slug, e.g. index/{{.Slug}}.adoc
+	Output string `yaml:"output"`
+	// Template is the index template of the tag indexes, the main index
+	// template when empty
+	Template string `yaml:"template"`
+}
+
+// tagIndex is a tag and the file its index is written to
+type tagIndex struct {
+	Tag  string
+	Slug string
+	Path string
+}
+
+// tagIndexes names the index file of every tag of adrs, two tags may not
+// share a file
+func tagIndexes(adrs []*ADR, output string) ([]tagIndex, error) {
+	name, err := template.New("tagIndex.output").Option("missingkey=error").Parse(output)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tag index output %q: %s", output, err)
+	}
+
+	seen := map[string]bool{}
+	tags := []string{}
+	for _, a := range adrs {
+		for _, t := range a.Meta.Tags {
+			if !seen[t] {
+				seen[t] = true
+				tags = append(tags, t)
+			}
+		}
+	}
+	sort.Strings(tags)
+
+	indexes := []tagIndex{}
+	byPath := map[string]string{}
+	for _, t := range tags {
+		ti := tagIndex{Tag: t, Slug: slugify(t)}
+		var b bytes.Buffer
+		err := name.Execute(&b, ti)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tag index output %q: %s", output, err)
+		}
+		ti.Path = filepath.Clean(b.String())
+		if other, ok := byPath[ti.Path]; ok {
+			return nil, fmt.Errorf("tags %s and %s both have their index at %s, give the output a field that tells them apart", other, t, ti.Path)
+		}
+		byPath[ti.Path] = t
+		indexes = append(indexes, ti)
+	}
+
+	return indexes, nil
+}
+
+// writeTagIndexes renders the index of every tag with the records carrying it,
+// the template sees that tag only, it returns the files written
+func writeTagIndexes(adrs []*ADR, templatePath string, output string, opts renderOptions) ([]string, error) {
+	indexes, err := tagIndexes(adrs, output)
+	if err != nil {
+		return nil, err
+	}
+
+	written := []string{}
+	for _, ti := range indexes {
+		err = os.MkdirAll(filepath.Dir(ti.Path), 0755)
+		if err != nil {
+			return nil, err
+		}
+		opts.Tag = ti.Tag
+		err = writeOutput(ti.Path, func(w io.Writer) error {
+			return renderIndexesWith(NewCatalog(adrs).ByTag(ti.Tag), templatePath, w, opts)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("index of tag %s: %s", ti.Tag, err)
+		}
+		written = append(written, ti.Path)
+	}
+
+	return written, nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// statusChange is the status a record has from one commit on
+type statusChange struct {
+	Status string
+	Commit string
+	Time   time.Time
+}
+
+// statusTimeline reads the Status of file in every commit of rev that touched
+// it, renames are followed, and keeps the commits that changed it, oldest first
+func statusTimeline(rev string, file string) ([]statusChange, error) {
+	if rev == "" {
+		rev = "HEAD"
+	}
+	out, err := git("log", "--follow", "--name-only", "--format=%x00%H %ct", rev, "--", file)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []statusChange{}
+	for _, entry := range strings.Split(out, "\x00") {
+		lines := strings.Split(strings.TrimSpace(entry), "\n")
+		header := strings.Fields(lines[0])
+		if len(lines) < 2 || len(header) != 2 {
+			continue
+		}
+		sec, err := strconv.ParseInt(header[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		// the file is gone in a commit deleting it, its status is kept
+		content, err := git("show", header[0]+":"+lines[len(lines)-1])
+		if err != nil {
+			continue
+		}
+		changes = append(changes, statusChange{Status: statusOf(content), Commit: header[0], Time: time.Unix(sec, 0)})
+	}
+
+	// git log lists the newest commit first
+	kept := []statusChange{}
+	for i := len(changes) - 1; i >= 0; i-- {
+		c := changes[i]
+		if len(kept) == 0 || kept[len(kept)-1].Status != c.Status {
+			kept = append(kept, c)
+		}
+	}
+
+	return kept, nil
+}
+
+// statusOf reads the Status row of a record without validating it
+func statusOf(content string) string {
+	for _, r := range findMetadata(strings.Split(strings.Replace(content, "\r\n", "\n", -1), "\n")).Rows {
+		if r.Key == "Status" {
+			return r.Value
+		}
+	}
+
+	return ""
+}
+
+// decisionLatency is the time a record took from its first pending status to
+// the first status after it, false while it is pending or when it was never
+func decisionLatency(timeline []statusChange) (proposed time.Time, decided time.Time, ok bool) {
+	return timeIn(timeline, func(status string) bool {
+		return lifecycleOf(status) == lifecyclePending
+	})
+}
+
+// timeIn finds the first change to a status in and the first change after it
+// to one that is not, false while the record is still in one of them
+func timeIn(timeline []statusChange, in func(status string) bool) (entered time.Time, left time.Time, ok bool) {
+	for _, c := range timeline {
+		switch {
+		case entered.IsZero() && in(c.Status):
+			entered = c.Time
+		case !entered.IsZero() && !in(c.Status):
+			return entered, c.Time, true
+		}
+	}
+
+	return entered, time.Time{}, false
+}
+
+func medianDays(durations []time.Duration) float64 {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration{}, durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	m := sorted[len(sorted)/2]
+	if len(sorted)%2 == 0 {
+		m = (sorted[len(sorted)/2-1] + m) / 2
+	}
+
+	return m.Hours() / 24
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// span is a minimal OpenTelemetry span, spans are sent as OTLP/HTTP JSON when
+// OTEL_EXPORTER_OTLP_ENDPOINT or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT is set
+type span struct {
+	Name     string
+	TraceID  string
+	SpanID   string
+	ParentID string
+	Start    time.Time
+	End      time.Time
+	Attrs    map[string]interface{}
+	Err      error
+}
+
+type tracer struct {
+	endpoint string
+	headers  map[string]string
+	service  string
+	root     *span
+	mu       sync.Mutex
+	spans    []*span
+}
+
+// maxSpans bounds the spans kept between exports, the root span is kept apart
+// and not counted
+const maxSpans = 1000
+
+// exportInterval is how often long running commands such as serve export the
+// spans finished so far
+const exportInterval = 30 * time.Second
+
+// activeTracer is nil when tracing is not configured, spans are then no-ops
+var activeTracer *tracer
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// startTracing creates the root span of the command, a W3C TRACEPARENT in the
+// environment, as set by some CI systems, makes it part of the calling trace
+func startTracing(name string) {
+	endpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	if endpoint == "" {
+		base := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+		if base == "" {
+			return
+		}
+		endpoint = strings.TrimSuffix(base, "/") + "/v1/traces"
+	}
+
+	service := os.Getenv("OTEL_SERVICE_NAME")
+	if service == "" {
+		service = "adr-index"
+	}
+
+	headers := map[string]string{}
+	for _, h := range strings.Split(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"), ",") {
+		kv := strings.SplitN(h, "=", 2)
+		if len(kv) == 2 {
+			headers[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+		}
+	}
+
+	activeTracer = &tracer{endpoint: endpoint, headers: headers, service: service}
+
+	root := &span{Name: name, TraceID: randomHex(16), SpanID: randomHex(8), Start: time.Now(), Attrs: map[string]interface{}{}}
+	if parts := strings.Split(os.Getenv("TRACEPARENT"), "-"); len(parts) == 4 && len(parts[1]) == 32 {
+		root.TraceID = parts[1]
+		root.ParentID = parts[2]
+	}
+	activeTracer.root = root
+}
+
+// startSpan opens a pipeline stage below the command span
+func startSpan(name string) *span {
+	if activeTracer == nil {
+		return nil
+	}
+
+	return &span{Name: name, TraceID: activeTracer.root.TraceID, SpanID: randomHex(8), ParentID: activeTracer.root.SpanID, Start: time.Now(), Attrs: map[string]interface{}{}}
+}
+
+func (s *span) set(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.Attrs[key] = value
+}
+
+// finish ends the span, a non-nil err marks it failed
+func (s *span) finish(err error) {
+	if s == nil {
+		return
+	}
+
+	s.End = time.Now()
+	s.Err = err
+
+	if s == activeTracer.root {
+		return
+	}
+	activeTracer.mu.Lock()
+	if len(activeTracer.spans) < maxSpans {
+		activeTracer.spans = append(activeTracer.spans, s)
+	}
+	activeTracer.mu.Unlock()
+}
+
+// stopTracing ends the command span and exports all spans, failures to export
+// are logged and never fail the command
+func stopTracing(err error) {
+	if activeTracer == nil {
+		return
+	}
+
+	activeTracer.root.finish(err)
+	exportSpans(true)
+}
+
+// exportPeriodically exports the spans finished so far every interval, for
+// commands that run until they are stopped
+func exportPeriodically(interval time.Duration) {
+	if activeTracer == nil {
+		return
+	}
+
+	go func() {
+		for range time.Tick(interval) {
+			exportSpans(false)
+		}
+	}()
+}
+
+// exportSpans sends and drops the finished spans, with the root span when the
+// command ended
+func exportSpans(withRoot bool) {
+	activeTracer.mu.Lock()
+	finished := activeTracer.spans
+	activeTracer.spans = nil
+	activeTracer.mu.Unlock()
+	if withRoot {
+		finished = append(finished, activeTracer.root)
+	}
+	if len(finished) == 0 || !networkAllowed("trace export to "+activeTracer.endpoint) {
+		return
+	}
+
+	spans := []map[string]interface{}{}
+	for _, s := range finished {
+		spans = append(spans, s.otlp())
+	}
+
+	payload := map[string]interface{}{
+		"resourceSpans": []interface{}{map[string]interface{}{
+			"resource": map[string]interface{}{
+				"attributes": otlpAttributes(map[string]interface{}{"service.name": activeTracer.service}),
+			},
+			"scopeSpans": []interface{}{map[string]interface{}{
+				"scope": map[string]string{"name": "adr-index"},
+				"spans": spans,
+			}},
+		}},
+	}
+
+	exportErr := apiRequest("POST", activeTracer.endpoint, activeTracer.headers, payload, nil)
+	if exportErr != nil {
+		log.Printf("Could not export traces to %s: %s", activeTracer.endpoint, exportErr)
+	}
+}
+
+func (s *span) otlp() map[string]interface{} {
+	status := map[string]interface{}{"code": 1}
+	if s.Err != nil {
+		status = map[string]interface{}{"code": 2, "message": s.Err.Error()}
+	}
+
+	return map[string]interface{}{
+		"traceId":           s.TraceID,
+		"spanId":            s.SpanID,
+		"parentSpanId":      s.ParentID,
+		"name":              s.Name,
+		"kind":              1,
+		"startTimeUnixNano": strconv.FormatInt(s.Start.UnixNano(), 10),
+		"endTimeUnixNano":   strconv.FormatInt(s.End.UnixNano(), 10),
+		"attributes":        otlpAttributes(s.Attrs),
+		"status":            status,
+	}
+}
+
+func otlpAttributes(attrs map[string]interface{}) []map[string]interface{} {
+	list := []map[string]interface{}{}
+	for k, v := range attrs {
+		var value map[string]interface{}
+		switch v := v.(type) {
+		case int:
+			value = map[string]interface{}{"intValue": strconv.Itoa(v)}
+		case bool:
+			value = map[string]interface{}{"boolValue": v}
+		case float64:
+			value = map[string]interface{}{"doubleValue": v}
+		default:
+			value = map[string]interface{}{"stringValue": fmt.Sprint(v)}
+		}
+		list = append(list, map[string]interface{}{"key": k, "value": value})
+	}
+
+	return list
+}
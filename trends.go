@@ -0,0 +1,315 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// trendsFile keeps one catalog snapshot per line, it is appended to and meant
+// to be committed so the history travels with the repository
+const trendsFile = ".adr-trends.jsonl"
+
+// trendSnapshot counts the catalog at one point in time, Revision is the commit
+// or tag the records were read from
+type trendSnapshot struct {
+	Taken    time.Time      `json:"taken"`
+	Revision string         `json:"revision,omitempty"`
+	Records  int            `json:"records"`
+	Statuses map[string]int `json:"statuses"`
+	Tags     map[string]int `json:"tags"`
+	// Decided counts the records with a proposal and a decision in their git
+	// history and LatencyDays is the median time between them
+	Decided     int     `json:"decided"`
+	LatencyDays float64 `json:"latencyDays"`
+}
+
+// runTrends records catalog snapshots in the trends store or charts the ones
+// recorded, -tags backfills the store from the git tags
+func runTrends(args []string) error {
+	fs := flag.NewFlagSet("trends", flag.ExitOnError)
+	dir := fs.String("dir", settings.Dir, "directory containing ADR files")
+	store := fs.String("store", "", "trends store, defaults to "+trendsFile+" in -dir")
+	record := fs.Bool("record", false, "add a snapshot of the working tree to the store")
+	tags := fs.Bool("tags", false, "add a snapshot for every git tag the store has none for")
+	fs.Parse(args)
+
+	if *store == "" {
+		*store = path.Join(*dir, trendsFile)
+	}
+	snapshots, err := readTrends(*store)
+	if err != nil {
+		return err
+	}
+
+	if *tags {
+		recorded := map[string]bool{}
+		for _, s := range snapshots {
+			recorded[s.Revision] = true
+		}
+		out, err := git("tag", "--sort=creatordate")
+		if err != nil {
+			return err
+		}
+		for _, tag := range strings.Fields(out) {
+			if recorded[tag] {
+				continue
+			}
+			s, err := snapshotAt(tag, *dir)
+			if err != nil {
+				return err
+			}
+			err = appendTrend(*store, s)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Recorded %s: %d records\n", tag, s.Records)
+		}
+	}
+	if *record {
+		adrs, _, err := scanADRs(*dir)
+		if err != nil {
+			return err
+		}
+		revision, _ := git("rev-parse", "--short", "HEAD")
+		s := snapshotOf(adrs, "", time.Now())
+		s.Revision = revision
+		err = appendTrend(*store, s)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Recorded %d records in %s\n", s.Records, *store)
+	}
+	if *tags || *record {
+		return nil
+	}
+
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].Taken.Before(snapshots[j].Taken)
+	})
+	setResults(snapshots)
+	if len(snapshots) == 0 {
+		return fmt.Errorf("no snapshots in %s, run adr-index trends -record or -tags first", *store)
+	}
+	printTrends(snapshots)
+
+	return nil
+}
+
+// snapshotAt snapshots the records of a git revision as of its commit time
+func snapshotAt(rev string, dir string) (trendSnapshot, error) {
+	adrs, _, err := scanADRsAt(rev, dir)
+	if err != nil {
+		return trendSnapshot{}, err
+	}
+	out, err := git("log", "-1", "--format=%ct", rev)
+	if err != nil {
+		return trendSnapshot{}, err
+	}
+	sec, err := strconv.ParseInt(out, 10, 64)
+	if err != nil {
+		return trendSnapshot{}, fmt.Errorf("no commit time for %s: %s", rev, err)
+	}
+
+	s := snapshotOf(adrs, rev, time.Unix(sec, 0))
+	s.Revision = rev
+
+	return s, nil
+}
+
+// snapshotOf counts adrs, the decision latency is read from the history of
+// each record up to rev
+func snapshotOf(adrs []*ADR, rev string, taken time.Time) trendSnapshot {
+	s := trendSnapshot{
+		Taken:    taken.UTC().Truncate(time.Second),
+		Records:  len(adrs),
+		Statuses: map[string]int{},
+		Tags:     map[string]int{},
+	}
+
+	latencies := []time.Duration{}
+	for _, a := range adrs {
+		status := a.Meta.Status
+		if isSuperseded(a) {
+			status = "Superseded"
+		}
+		s.Statuses[status]++
+		for _, t := range a.Meta.Tags {
+			s.Tags[strings.ToLower(t)]++
+		}
+
+		timeline, err := statusTimeline(rev, a.Meta.Path)
+		if err != nil {
+			continue
+		}
+		if proposed, decided, ok := decisionLatency(timeline); ok {
+			latencies = append(latencies, decided.Sub(proposed))
+		}
+	}
+	s.Decided = len(latencies)
+	s.LatencyDays = math.Round(medianDays(latencies)*10) / 10
+
+	return s
+}
+
+func readTrends(file string) ([]trendSnapshot, error) {
+	body, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return []trendSnapshot{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := []trendSnapshot{}
+	s := bufio.NewScanner(bytes.NewReader(body))
+	s.Buffer(nil, 1<<20)
+	for n := 1; s.Scan(); n++ {
+		if strings.TrimSpace(s.Text()) == "" {
+			continue
+		}
+		var snapshot trendSnapshot
+		if err := json.Unmarshal(s.Bytes(), &snapshot); err != nil {
+			return nil, fmt.Errorf("invalid snapshot on line %d: %s in %s", n, err, file)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	return snapshots, s.Err()
+}
+
+func appendTrend(file string, s trendSnapshot) error {
+	line, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	unlock, err := lockRepo()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
+
+// printTrends lists the snapshots and charts every series as a sparkline from
+// the oldest to the newest snapshot
+func printTrends(snapshots []trendSnapshot) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "Taken\tRevision\tRecords\tPending\tActive\tTerminal\tTags\tLatency")
+	for _, s := range snapshots {
+		byLifecycle := map[string]int{}
+		for status, n := range s.Statuses {
+			byLifecycle[lifecycleOf(status)] += n
+		}
+		latency := "-"
+		if s.Decided > 0 {
+			latency = fmt.Sprintf("%.1f days", s.LatencyDays)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\t%d\t%s\n", s.Taken.Format("2006-01-02"), s.Revision, s.Records,
+			byLifecycle[lifecyclePending], byLifecycle[lifecycleActive], byLifecycle[lifecycleTerminal], len(s.Tags), latency)
+	}
+	w.Flush()
+
+	series := func(value func(s trendSnapshot) float64) []float64 {
+		values := []float64{}
+		for _, s := range snapshots {
+			values = append(values, value(s))
+		}
+		return values
+	}
+
+	fmt.Println()
+	w = tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	chart := func(name string, values []float64, unit string) {
+		fmt.Fprintf(w, "%s\t%s\t%s → %s%s\n", name, sparkline(values), formatTrend(values[0]), formatTrend(values[len(values)-1]), unit)
+	}
+	chart("Records", series(func(s trendSnapshot) float64 { return float64(s.Records) }), "")
+	for _, status := range trendKeys(snapshots, func(s trendSnapshot) map[string]int { return s.Statuses }, 0) {
+		status := status
+		name := status
+		if name == "" {
+			name = "(none)"
+		}
+		chart(name, series(func(s trendSnapshot) float64 { return float64(s.Statuses[status]) }), "")
+	}
+	for _, tag := range trendKeys(snapshots, func(s trendSnapshot) map[string]int { return s.Tags }, 5) {
+		tag := tag
+		chart("tag "+tag, series(func(s trendSnapshot) float64 { return float64(s.Tags[tag]) }), "")
+	}
+	chart("Latency", series(func(s trendSnapshot) float64 { return s.LatencyDays }), " days")
+	w.Flush()
+}
+
+// trendKeys lists the keys of a counted series, the most counted in the latest
+// snapshot first, max limits them unless it is 0
+func trendKeys(snapshots []trendSnapshot, counts func(s trendSnapshot) map[string]int, max int) []string {
+	latest := counts(snapshots[len(snapshots)-1])
+	seen := map[string]bool{}
+	keys := []string{}
+	for _, s := range snapshots {
+		for k := range counts(s) {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if latest[keys[i]] != latest[keys[j]] {
+			return latest[keys[i]] > latest[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	if max > 0 && len(keys) > max {
+		keys = keys[:max]
+	}
+
+	return keys
+}
+
+var sparkBars = []rune("▁▂▃▄▅▆▇█")
+
+func sparkline(values []float64) string {
+	min, max := values[0], values[0]
+	for _, v := range values {
+		min = math.Min(min, v)
+		max = math.Max(max, v)
+	}
+
+	b := &strings.Builder{}
+	for _, v := range values {
+		i := 0
+		if max > min {
+			i = int((v - min) / (max - min) * float64(len(sparkBars)-1))
+		}
+		b.WriteRune(sparkBars[i])
+	}
+
+	return b.String()
+}
+
+func formatTrend(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
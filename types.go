@@ -0,0 +1,286 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RecordType describes a kind of decision record, all types share the parser but
+// each has its own filename convention, required metadata and index section
+type RecordType struct {
+	Key  string `yaml:"-"`
+	Name string `yaml:"name"`
+	// Label prefixes the index in rendered links, e.g. ADR-12 or RFC-3
+	Label string `yaml:"label"`
+	// Pattern is a regular expression matched against the file name, its first
+	// group is the index, types without a pattern are only selected by a Type row
+	Pattern string `yaml:"pattern"`
+	// Filename is the fmt layout of new files, it receives the index and the title slug
+	Filename string   `yaml:"filename"`
+	Required []string `yaml:"required"`
+	// Statuses overrides the globally valid statuses for this type
+	Statuses []string `yaml:"statuses"`
+	Skeleton string   `yaml:"skeleton"`
+	// Sections are the body sections lint requires of records of the type
+	Sections []string `yaml:"sections"`
+	// Section is the index heading records of this type are listed under, full
+	// ADRs are grouped by tag instead
+	Section string `yaml:"section"`
+
+	pattern *regexp.Regexp
+}
+
+// TypeSection holds the records of one type for rendering
+type TypeSection struct {
+	Title   string
+	Label   string
+	Type    *RecordType
+	Records []*ADR
+}
+
+const (
+	adrTypeKey  = "adr"
+	noteTypeKey = "note"
+)
+
+func builtinTypes() map[string]RecordType {
+	return map[string]RecordType{
+		adrTypeKey: {
+			Name:     "ADR",
+			Label:    "ADR",
+			Pattern:  `^(\d+)-`,
+			Filename: "%04d-%s.adoc",
+			Required: []string{"Date", "Author", "Status", "Tags"},
+			Skeleton: "adr-template.adoc",
+		},
+		noteTypeKey: {
+			Name:     designNoteType,
+			Label:    "Note",
+			Filename: "%04d-%s.adoc",
+			Required: []string{"Date", "Author"},
+			Section:  "Design Notes",
+		},
+	}
+}
+
+// compileTypes merges the configured types over the built in ones, configured
+// patterns are tried before the ADR pattern which acts as the fallback
+func compileTypes(configured map[string]RecordType) ([]*RecordType, error) {
+	merged := builtinTypes()
+	for k, t := range configured {
+		merged[k] = t
+	}
+
+	keys := []string{}
+	for k := range merged {
+		if k != adrTypeKey {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	keys = append(keys, adrTypeKey)
+
+	types := []*RecordType{}
+	for _, k := range keys {
+		t := merged[k]
+		t.Key = k
+		if t.Name == "" {
+			t.Name = k
+		}
+		if t.Label == "" {
+			t.Label = t.Name
+		}
+		if t.Filename == "" {
+			t.Filename = "%04d-%s.adoc"
+		}
+		if t.Pattern != "" {
+			re, err := regexp.Compile(t.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern for record type %s: %s", k, err)
+			}
+			if re.NumSubexp() < 1 {
+				return nil, fmt.Errorf("pattern for record type %s needs a group capturing the index", k)
+			}
+			t.pattern = re
+		}
+
+		types = append(types, &t)
+	}
+
+	return types, nil
+}
+
+// typeByName resolves the value of a Type metadata row by key or name, the
+// empty name is the ADR type
+func typeByName(name string) *RecordType {
+	if name == "" {
+		name = adrTypeKey
+	}
+
+	for _, t := range cfg.types {
+		if strings.EqualFold(t.Key, name) || strings.EqualFold(t.Name, name) {
+			return t
+		}
+	}
+
+	return nil
+}
+
+// isRecordFile tells records from other files by extension, Markdown files must
+// also be named like a record since those directories often hold a README
+func isRecordFile(name string) bool {
+	switch path.Ext(name) {
+	case ".adoc":
+		return true
+	case ".md":
+		for _, t := range cfg.types {
+			if t.pattern != nil && t.pattern.MatchString(path.Base(name)) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func typeForFile(file string) *RecordType {
+	base := path.Base(file)
+	for _, t := range cfg.types {
+		if t.pattern != nil && t.pattern.MatchString(base) {
+			return t
+		}
+	}
+
+	return typeByName("")
+}
+
+// metaType is the value stored in ADRMeta.Type, empty for full ADRs
+func (t *RecordType) metaType() string {
+	if t.Key == adrTypeKey {
+		return ""
+	}
+
+	return t.Name
+}
+
+func (t *RecordType) requires(key string) bool {
+	for _, r := range t.Required {
+		if strings.EqualFold(r, key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (t *RecordType) statuses() []string {
+	if len(t.Statuses) > 0 {
+		return t.Statuses
+	}
+
+	return validStatus
+}
+
+// index extracts the sequence number from the file name, types without a
+// pattern use the NNNN-title convention of ADRs
+func (t *RecordType) index(adrPath string) (int, error) {
+	base := strings.TrimSuffix(path.Base(adrPath), path.Ext(adrPath))
+
+	if t.pattern != nil && t.Key != adrTypeKey {
+		m := t.pattern.FindStringSubmatch(path.Base(adrPath))
+		if m == nil {
+			return 0, fmt.Errorf("invalid filename %s for %s in %s", base, t.Name, adrPath)
+		}
+		idx, err := strconv.Atoi(m[1])
+		if err != nil {
+			return 0, fmt.Errorf("invalid file sequence %s in %s", m[1], adrPath)
+		}
+		return idx, nil
+	}
+
+	parts := strings.Split(base, "-")
+	if len(parts) < 2 {
+		return 0, fmt.Errorf("invalid filename %s in %s", base, adrPath)
+	}
+
+	idx, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, fmt.Errorf("invalid file sequence %s in %s", parts[0], adrPath)
+	}
+
+	return idx, nil
+}
+
+// recordLabel names a record the way the index links it, e.g. ADR-12 or Note-3,
+// included records carry the include name, platform:ADR-3
+func recordLabel(a *ADR) string {
+	if a.Meta.Source != nil {
+		return fmt.Sprintf("%s:%s-%d", a.Meta.Source.Name, typeByName(a.Meta.Type).Label, a.Meta.Index)
+	}
+	return fmt.Sprintf("%s-%d", typeByName(a.Meta.Type).Label, a.Meta.Index)
+}
+
+// resolveRecord finds a record by a bare index, which means an ADR, or by a
+// label prefixed index like ADR-12 or Note-3
+func resolveRecord(adrs []*ADR, ref string) (*ADR, error) {
+	label := adrTypeKey
+	number := ref
+	if i := strings.LastIndex(ref, "-"); i > 0 {
+		label, number = ref[:i], ref[i+1:]
+	}
+
+	idx, err := strconv.Atoi(number)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a record index", ref)
+	}
+
+	for _, a := range adrs {
+		t := typeByName(a.Meta.Type)
+		if a.Meta.Index == idx && (strings.EqualFold(t.Label, label) || strings.EqualFold(t.Key, label)) {
+			return a, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%s does not exist", ref)
+}
+
+func (t *RecordType) fileName(index int, title string) string {
+	return fmt.Sprintf(t.Filename, index, slugify(title))
+}
+
+// typeSections splits off every record that is not a full ADR into a section per type
+func typeSections(adrs []*ADR) ([]*ADR, []TypeSection) {
+	records := []*ADR{}
+	byType := map[string][]*ADR{}
+	for _, a := range adrs {
+		if a.Meta.Type == "" {
+			records = append(records, a)
+			continue
+		}
+		byType[a.Meta.Type] = append(byType[a.Meta.Type], a)
+	}
+
+	sections := []TypeSection{}
+	for _, t := range cfg.types {
+		matched := byType[t.metaType()]
+		if t.Key == adrTypeKey || len(matched) == 0 {
+			continue
+		}
+
+		sort.Slice(matched, func(i, j int) bool {
+			return matched[i].Meta.Index < matched[j].Meta.Index
+		})
+
+		title := t.Section
+		if title == "" {
+			title = t.Name
+		}
+		sections = append(sections, TypeSection{Title: title, Label: t.Label, Type: t, Records: matched})
+	}
+
+	return records, sections
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"text/tabwriter"
+)
+
+// runValidate reads every record and reports all problems grouped by file, it
+// fails only after everything is reported
+func runValidate(args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	dir := fs.String("dir", settings.Dir, "directory containing ADR files")
+	fs.Parse(args)
+
+	files := fs.Args()
+	if len(files) == 0 {
+		entries, err := ioutil.ReadDir(*dir)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if !e.IsDir() && isRecordFile(e.Name()) {
+				files = append(files, path.Join(*dir, e.Name()))
+			}
+		}
+	}
+
+	results := []validation{}
+	records := []*ADR{}
+	for _, file := range files {
+		body, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		v := validateRecord(file, body, records, severityError)
+		v.File = file
+		if v.Record != nil {
+			records = append(records, v.Record)
+		}
+		results = append(results, v)
+	}
+	// conflicts span records, they are reported on the record they were found on
+	for _, err := range verifyConflicts(records) {
+		file := ""
+		switch e := err.(type) {
+		case *ErrConflictingDecision:
+			file = e.Path
+		case *ErrInvalidReference:
+			file = e.Path
+		}
+		for i := range results {
+			if results[i].File == file {
+				results[i].Findings = append(results[i].Findings, newFinding(severityError, err))
+				results[i].Valid = false
+			}
+		}
+	}
+	for i := range results {
+		catalogHints(&results[i], records)
+	}
+	setResults(results)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	p := newPainter(os.Stdout)
+	failed := 0
+	for _, v := range results {
+		if len(v.Findings) == 0 {
+			fmt.Fprintf(w, "%s %s\n", v.File, p.ok("ok"))
+			continue
+		}
+		if !v.Valid {
+			failed++
+		}
+		fmt.Fprintf(w, "%s\n", v.File)
+		for _, f := range v.Findings {
+			severity := p.paint(colorYellow, f.Severity)
+			if f.Severity == severityError {
+				severity = p.error(f.Severity)
+			}
+			line := ""
+			if f.Line > 0 {
+				line = fmt.Sprintf("line %d", f.Line)
+			}
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", severity, line, f.Rule, f.Message)
+			if f.Fix != nil {
+				fmt.Fprintf(w, "  \t\t\t%s %s\n", p.paint(colorBlue, "fix:"), f.Fix.Description)
+			}
+		}
+	}
+	w.Flush()
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d records in %s are invalid", failed, len(results), *dir)
+	}
+
+	return nil
+}
+
+// validateRecord parses leniently to find every problem of a record, lint
+// findings are warnings unless --strict is given, an index shared with one of
+// others is reported with the duplicate severity
+func validateRecord(file string, body []byte, others []*ADR, duplicate string) validation {
+	rp := &recordParser{mode: parseLenient, Collect: true}
+	adr, err := rp.parse(file, body)
+
+	v := validation{File: path.Base(file), Record: adr, Findings: rp.Findings}
+	if err != nil {
+		v.Findings = append(v.Findings, newFinding(severityError, err))
+	}
+	if adr != nil {
+		for _, other := range others {
+			if other.Meta.Type == adr.Meta.Type && other.Meta.Index == adr.Meta.Index && path.Base(other.Meta.Path) != v.File {
+				v.Findings = append(v.Findings, newFinding(duplicate, &ErrDuplicateIndex{Index: adr.Meta.Index, Path: v.File, Other: other.Meta.Path}))
+			}
+		}
+	}
+
+	sort.SliceStable(v.Findings, func(i, j int) bool {
+		return v.Findings[i].Line < v.Findings[j].Line
+	})
+
+	v.Valid = true
+	for i := range v.Findings {
+		if parsing == parseStrict {
+			v.Findings[i].Severity = severityError
+		}
+		if v.Findings[i].Severity == severityError {
+			v.Valid = false
+		}
+	}
+
+	return v
+}